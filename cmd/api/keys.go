@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"http-task-executor/internal/auth"
+	authRepository "http-task-executor/internal/auth/repository"
+	"http-task-executor/internal/config"
+	"http-task-executor/internal/logger"
+	"http-task-executor/internal/migration"
+	"http-task-executor/internal/models"
+	"http-task-executor/internal/postgres"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+const keysUsage = `Usage: api keys <command> [flags]
+
+Commands:
+  create --client=NAME   issue a new key for the client, the key is printed once
+  list                   list issued keys
+  revoke --id=ID         revoke the key
+`
+
+// runKeys manages api keys, it is invoked as "api keys <command>".
+func runKeys(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, keysUsage)
+		return 2
+	}
+
+	flags := flag.NewFlagSet("keys "+args[0], flag.ContinueOnError)
+	configPath := flags.String("config", "", "config file path")
+	client := flags.String("client", "", "client the key is issued to")
+	id := flags.Int64("id", 0, "id of the key")
+	if err := flags.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	appConfig := config.MustLoadPath(*configPath)
+	appLogger, err := logger.NewLogger(appConfig)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Init logger error: %v\n", err)
+		return 1
+	}
+
+	database, err := postgres.NewPostgresqlDatabase(appConfig)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Init postgresql database error: %v\n", err)
+		return 1
+	}
+	defer database.Close()
+
+	if err := migration.MigratePostgresql(database); err != nil {
+		fmt.Fprintf(os.Stderr, "MigratePostgresql database error: %v\n", err)
+		return 1
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	repo := authRepository.NewRepository(database, appLogger)
+
+	switch args[0] {
+	case "create":
+		err = createKey(ctx, repo, *client)
+	case "list":
+		err = listKeys(ctx, repo)
+	case "revoke":
+		err = revokeKey(ctx, repo, *id)
+	default:
+		fmt.Fprint(os.Stderr, keysUsage)
+		return 2
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "keys %s: %v\n", args[0], err)
+		return 1
+	}
+	return 0
+}
+
+func createKey(ctx context.Context, repo auth.Repository, client string) error {
+	if client == "" {
+		return fmt.Errorf("--client is required")
+	}
+
+	key, apiKey, err := models.NewApiKey(client)
+	if err != nil {
+		return err
+	}
+	apiKey, err = repo.Create(ctx, apiKey)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Created key %d for client %s, store it now as it can't be shown again:\n%s\n", apiKey.Id, apiKey.Client, key)
+	return nil
+}
+
+func listKeys(ctx context.Context, repo auth.Repository) error {
+	keys, err := repo.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tCLIENT\tCREATED\tREVOKED")
+	for _, key := range keys {
+		revoked := "-"
+		if key.RevokedAt != nil {
+			revoked = key.RevokedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", key.Id, key.Client, key.CreatedAt.Format(time.RFC3339), revoked)
+	}
+	return w.Flush()
+}
+
+func revokeKey(ctx context.Context, repo auth.Repository, id int64) error {
+	if id <= 0 {
+		return fmt.Errorf("--id is required")
+	}
+
+	if err := repo.Revoke(ctx, id); err != nil {
+		return err
+	}
+
+	fmt.Printf("Revoked key %d\n", id)
+	return nil
+}
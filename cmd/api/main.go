@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"github.com/jmoiron/sqlx"
 	_ "http-task-executor/docs"
 	"http-task-executor/internal/config"
@@ -8,7 +9,10 @@ import (
 	"http-task-executor/internal/logger"
 	"http-task-executor/internal/migration"
 	"http-task-executor/internal/postgres"
+	"http-task-executor/internal/tracing"
 	"log"
+	"os"
+	"time"
 )
 
 // @title Task executor Rest API
@@ -18,7 +22,14 @@ import (
 // @contact.url https://github.com/belikandrey
 // @contact.email belikandrey01@gmail.com
 // @BasePath /
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "keys" {
+		os.Exit(runKeys(os.Args[2:]))
+	}
+
 	log.Println("Starting api server")
 
 	appConfig := config.MustLoad()
@@ -31,6 +42,20 @@ func main() {
 
 	appLogger.Infof("Env: %s, LogLevel: %s", appConfig.Env, appConfig.LoggerConfig.Level)
 
+	shutdownTracing, err := tracing.Init(appConfig.Tracing)
+	if err != nil {
+		appLogger.Fatalf("Init tracing error: %v", err)
+	}
+
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		err := shutdownTracing(ctx)
+		if err != nil {
+			appLogger.Errorf("Shutdown tracing error: %v", err)
+		}
+	}()
+
 	database, err := postgres.NewPostgresqlDatabase(appConfig)
 	if err != nil {
 		appLogger.Fatalf("Init postgresql database error: %v", err)
@@ -55,6 +80,6 @@ func main() {
 	httpServer := server.NewServer(appConfig, database, appLogger)
 
 	if err := httpServer.Start(); err != nil {
-		appLogger.Fatal("Start server error: %v", err)
+		appLogger.Fatalf("Start server error: %v", err)
 	}
 }
@@ -0,0 +1,24 @@
+package auth
+
+import "context"
+
+type clientKey struct{}
+
+// WithClient returns ctx carrying the authenticated client.
+func WithClient(ctx context.Context, client string) context.Context {
+	return context.WithValue(ctx, clientKey{}, client)
+}
+
+// ClientFromContext returns the authenticated client, nil when the request wasn't authenticated.
+func ClientFromContext(ctx context.Context) *string {
+	client, ok := ctx.Value(clientKey{}).(string)
+	if !ok {
+		return nil
+	}
+	return &client
+}
+
+// Owns reports whether client may access a resource owned by owner, unauthenticated requests access any resource.
+func Owns(client *string, owner *string) bool {
+	return client == nil || (owner != nil && *owner == *client)
+}
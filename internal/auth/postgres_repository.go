@@ -0,0 +1,14 @@
+//go:generate mockgen -source postgres_repository.go -destination mock/postgres_repository.go -package mock
+package auth
+
+import (
+	"context"
+	"http-task-executor/internal/models"
+)
+
+type Repository interface {
+	Create(ctx context.Context, key *models.ApiKey) (*models.ApiKey, error)
+	GetByHash(ctx context.Context, hash string) (*models.ApiKey, error)
+	List(ctx context.Context) ([]models.ApiKey, error)
+	Revoke(ctx context.Context, id int64) error
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"http-task-executor/internal/logger"
+	"http-task-executor/internal/models"
+)
+
+type ApiKeyRepository struct {
+	db  *sqlx.DB
+	log logger.Logger
+}
+
+func NewRepository(db *sqlx.DB, log logger.Logger) *ApiKeyRepository {
+	return &ApiKeyRepository{db: db, log: log}
+}
+
+func (r *ApiKeyRepository) Create(ctx context.Context, key *models.ApiKey) (*models.ApiKey, error) {
+	prepareContext, err := r.db.PrepareContext(ctx, "INSERT INTO api_key (client, key_hash) VALUES ($1, $2) RETURNING id, created_at")
+	if err != nil {
+		return nil, errors.Wrap(err, "ApiKeyRepository.Create.PrepareContext")
+	}
+
+	err = prepareContext.QueryRowContext(ctx, key.Client, key.Hash).Scan(&key.Id, &key.CreatedAt)
+	if err != nil {
+		return nil, errors.Wrap(err, "ApiKeyRepository.Create.QueryRowContext")
+	}
+	return key, nil
+}
+
+// GetByHash returns the key with the hash unless it was revoked.
+func (r *ApiKeyRepository) GetByHash(ctx context.Context, hash string) (*models.ApiKey, error) {
+	prepareContext, err := r.db.PrepareContext(ctx, "SELECT id, client, key_hash, created_at, revoked_at FROM api_key WHERE key_hash = $1 AND revoked_at IS NULL")
+	if err != nil {
+		return nil, errors.Wrap(err, "ApiKeyRepository.GetByHash.PrepareContext")
+	}
+
+	key := &models.ApiKey{}
+	err = prepareContext.QueryRowContext(ctx, hash).Scan(&key.Id, &key.Client, &key.Hash, &key.CreatedAt, &key.RevokedAt)
+	if err != nil {
+		return nil, errors.Wrap(err, "ApiKeyRepository.GetByHash.QueryRowContext")
+	}
+	return key, nil
+}
+
+func (r *ApiKeyRepository) List(ctx context.Context) ([]models.ApiKey, error) {
+	prepareContext, err := r.db.PrepareContext(ctx, "SELECT id, client, key_hash, created_at, revoked_at FROM api_key ORDER BY id")
+	if err != nil {
+		return nil, errors.Wrap(err, "ApiKeyRepository.List.PrepareContext")
+	}
+	rows, err := prepareContext.QueryContext(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "ApiKeyRepository.List.QueryContext")
+	}
+
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.log.Errorf("ApiKeyRepository.List.rows.Close(): %v", err)
+		}
+	}(rows)
+
+	result := make([]models.ApiKey, 0)
+	for rows.Next() {
+		key := models.ApiKey{}
+		err = rows.Scan(&key.Id, &key.Client, &key.Hash, &key.CreatedAt, &key.RevokedAt)
+		if err != nil {
+			return nil, errors.Wrap(err, "ApiKeyRepository.List.Scan")
+		}
+		result = append(result, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "ApiKeyRepository.List.rows.Err")
+	}
+	return result, nil
+}
+
+func (r *ApiKeyRepository) Revoke(ctx context.Context, id int64) error {
+	prepareContext, err := r.db.PrepareContext(ctx, "UPDATE api_key SET revoked_at = now() WHERE id = $1 AND revoked_at IS NULL")
+	if err != nil {
+		return errors.Wrap(err, "ApiKeyRepository.Revoke.PrepareContext")
+	}
+
+	result, err := prepareContext.ExecContext(ctx, id)
+	if err != nil {
+		return errors.Wrap(err, "ApiKeyRepository.Revoke.ExecContext")
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "ApiKeyRepository.Revoke.RowsAffected")
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
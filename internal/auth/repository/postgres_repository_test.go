@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	dbSql "database/sql"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"http-task-executor/internal/models"
+	"testing"
+	"time"
+)
+
+func TestApiKeyRepo_Create(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+
+	sqlxDb := sqlx.NewDb(db, "sqlmock")
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	keysRepo := NewRepository(sqlxDb, sugar)
+
+	sql := "INSERT INTO api_key (client, key_hash) VALUES ($1, $2) RETURNING id, created_at"
+	createdAt := time.Now()
+	key := &models.ApiKey{Client: "billing", Hash: models.HashApiKey("tek_key")}
+
+	mock.ExpectPrepare(sql)
+	mock.ExpectQuery(sql).WithArgs(key.Client, key.Hash).WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(1, createdAt))
+
+	created, err := keysRepo.Create(context.Background(), key)
+
+	require.NoError(t, err)
+	require.Equal(t, int64(1), created.Id)
+	require.Equal(t, createdAt, created.CreatedAt)
+}
+
+func TestApiKeyRepo_GetByHash(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+
+	sqlxDb := sqlx.NewDb(db, "sqlmock")
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	keysRepo := NewRepository(sqlxDb, sugar)
+
+	sql := "SELECT id, client, key_hash, created_at, revoked_at FROM api_key WHERE key_hash = $1 AND revoked_at IS NULL"
+	hash := models.HashApiKey("tek_key")
+
+	t.Run("Active key", func(t *testing.T) {
+		mock.ExpectPrepare(sql)
+		mock.ExpectQuery(sql).WithArgs(hash).WillReturnRows(sqlmock.NewRows([]string{"id", "client", "key_hash", "created_at", "revoked_at"}).
+			AddRow(1, "billing", hash, time.Now(), nil))
+
+		key, err := keysRepo.GetByHash(context.Background(), hash)
+
+		require.NoError(t, err)
+		require.Equal(t, "billing", key.Client)
+	})
+
+	t.Run("Unknown or revoked key", func(t *testing.T) {
+		mock.ExpectPrepare(sql)
+		mock.ExpectQuery(sql).WithArgs(hash).WillReturnRows(sqlmock.NewRows([]string{"id", "client", "key_hash", "created_at", "revoked_at"}))
+
+		_, err := keysRepo.GetByHash(context.Background(), hash)
+
+		require.ErrorIs(t, err, dbSql.ErrNoRows)
+	})
+}
+
+func TestApiKeyRepo_Revoke(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+
+	sqlxDb := sqlx.NewDb(db, "sqlmock")
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	keysRepo := NewRepository(sqlxDb, sugar)
+
+	sql := "UPDATE api_key SET revoked_at = now() WHERE id = $1 AND revoked_at IS NULL"
+
+	t.Run("Active key", func(t *testing.T) {
+		mock.ExpectPrepare(sql)
+		mock.ExpectExec(sql).WithArgs(int64(1)).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		require.NoError(t, keysRepo.Revoke(context.Background(), 1))
+	})
+
+	t.Run("Already revoked key", func(t *testing.T) {
+		mock.ExpectPrepare(sql)
+		mock.ExpectExec(sql).WithArgs(int64(1)).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		require.ErrorIs(t, keysRepo.Revoke(context.Background(), 1), dbSql.ErrNoRows)
+	})
+}
@@ -0,0 +1,175 @@
+package breaker
+
+import (
+	"errors"
+	"fmt"
+	"http-task-executor/internal/config"
+	"http-task-executor/internal/metrics"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	StateClosed   = "closed"
+	StateOpen     = "open"
+	StateHalfOpen = "half_open"
+)
+
+// Outcome is the result of a call admitted by the breaker.
+type Outcome int
+
+const (
+	Success Outcome = iota
+	Failure
+	// Ignored releases the call without counting it, e.g. when the task was cancelled.
+	Ignored
+)
+
+var ErrOpen = errors.New("circuit breaker is open")
+
+// State describes the breaker of a host.
+type State struct {
+	Host     string     `json:"host"`
+	State    string     `json:"state"`
+	Failures int        `json:"failures"`
+	OpenedAt *time.Time `json:"openedAt,omitempty"`
+}
+
+// Breakers keeps a circuit breaker per target host. A breaker opens after FailureThreshold
+// consecutive failures and rejects calls for OpenTimeout, then lets HalfOpenRequests trial
+// calls through: it closes once they all succeed and opens again on the first failure.
+type Breakers struct {
+	cfg   config.CircuitBreakerConfig
+	mu    sync.Mutex
+	hosts map[string]*breaker
+}
+
+type breaker struct {
+	state     string
+	failures  int
+	openedAt  time.Time
+	trials    int
+	successes int
+	// generation tells trials of an earlier half-open period apart.
+	generation int
+}
+
+func NewBreakers(cfg config.CircuitBreakerConfig) *Breakers {
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = 1
+	}
+	if cfg.HalfOpenRequests <= 0 {
+		cfg.HalfOpenRequests = 1
+	}
+	return &Breakers{cfg: cfg, hosts: make(map[string]*breaker)}
+}
+
+// Allow admits a call to the host unless its breaker is open. The returned func reports the
+// outcome of the call and has to be called exactly once. Nil or disabled breakers admit everything.
+func (b *Breakers) Allow(host string) (func(Outcome), error) {
+	if b == nil || !b.cfg.Enabled {
+		return func(Outcome) {}, nil
+	}
+	host = strings.ToLower(host)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	br, ok := b.hosts[host]
+	if !ok {
+		br = &breaker{state: StateClosed}
+		b.hosts[host] = br
+	}
+
+	if br.state == StateOpen && time.Since(br.openedAt) >= b.cfg.OpenTimeout {
+		b.transition(host, br, StateHalfOpen)
+	}
+	switch {
+	case br.state == StateOpen:
+		return nil, fmt.Errorf("%w for %s", ErrOpen, host)
+	case br.state == StateHalfOpen && br.trials >= b.cfg.HalfOpenRequests:
+		return nil, fmt.Errorf("%w for %s", ErrOpen, host)
+	case br.state == StateHalfOpen:
+		br.trials++
+		return b.report(host, br, br.generation), nil
+	default:
+		return b.report(host, br, -1), nil
+	}
+}
+
+// report records the outcome of a call, generation being the half-open period of a trial call or -1.
+func (b *Breakers) report(host string, br *breaker, generation int) func(Outcome) {
+	var once sync.Once
+	return func(outcome Outcome) {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			b.record(host, br, generation, outcome)
+		})
+	}
+}
+
+func (b *Breakers) record(host string, br *breaker, generation int, outcome Outcome) {
+	trial := generation >= 0
+	if trial {
+		if br.state != StateHalfOpen || br.generation != generation {
+			return
+		}
+		br.trials--
+	}
+
+	switch outcome {
+	case Success:
+		br.failures = 0
+		if trial {
+			br.successes++
+			if br.successes >= b.cfg.HalfOpenRequests {
+				b.transition(host, br, StateClosed)
+			}
+		}
+	case Failure:
+		br.failures++
+		if trial || (br.state == StateClosed && br.failures >= b.cfg.FailureThreshold) {
+			b.transition(host, br, StateOpen)
+		}
+	}
+}
+
+func (b *Breakers) transition(host string, br *breaker, state string) {
+	br.state = state
+	br.generation++
+	br.trials = 0
+	br.successes = 0
+	switch state {
+	case StateOpen:
+		br.openedAt = time.Now()
+	case StateClosed:
+		br.failures = 0
+	}
+	metrics.CircuitBreakerTransitions.WithLabelValues(host, state).Inc()
+}
+
+// States returns the breakers of all hosts called so far, sorted by host.
+func (b *Breakers) States() []State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	states := make([]State, 0, len(b.hosts))
+	for host, br := range b.hosts {
+		state := State{Host: host, State: br.state, Failures: br.failures}
+		if br.state == StateOpen && time.Since(br.openedAt) >= b.cfg.OpenTimeout {
+			state.State = StateHalfOpen
+		}
+		if state.State != StateClosed {
+			openedAt := br.openedAt
+			state.OpenedAt = &openedAt
+		}
+		states = append(states, state)
+	}
+	slices.SortFunc(states, func(a, b State) int {
+		return strings.Compare(a.Host, b.Host)
+	})
+	return states
+}
@@ -0,0 +1,86 @@
+package breaker
+
+import (
+	"github.com/stretchr/testify/require"
+	"http-task-executor/internal/config"
+	"testing"
+	"time"
+)
+
+func TestBreakers(t *testing.T) {
+	t.Parallel()
+
+	breakers := NewBreakers(config.CircuitBreakerConfig{Enabled: true, FailureThreshold: 2, OpenTimeout: 20 * time.Millisecond, HalfOpenRequests: 1})
+
+	call := func(outcome Outcome) {
+		report, err := breakers.Allow("partner.com")
+		require.NoError(t, err)
+		report(outcome)
+	}
+
+	t.Run("Opens after consecutive failures", func(t *testing.T) {
+		call(Failure)
+		call(Success)
+		call(Failure)
+		require.Equal(t, StateClosed, breakers.States()[0].State)
+
+		call(Failure)
+		_, err := breakers.Allow("partner.com")
+		require.ErrorIs(t, err, ErrOpen)
+		require.Equal(t, StateOpen, breakers.States()[0].State)
+		require.NotNil(t, breakers.States()[0].OpenedAt)
+
+		_, err = breakers.Allow("other.com")
+		require.NoError(t, err)
+	})
+
+	t.Run("Half-open failure opens again", func(t *testing.T) {
+		time.Sleep(20 * time.Millisecond)
+		require.Equal(t, StateHalfOpen, breakers.States()[1].State)
+
+		report, err := breakers.Allow("partner.com")
+		require.NoError(t, err)
+		_, err = breakers.Allow("partner.com")
+		require.ErrorIs(t, err, ErrOpen)
+
+		report(Failure)
+		_, err = breakers.Allow("partner.com")
+		require.ErrorIs(t, err, ErrOpen)
+	})
+
+	t.Run("Ignored trial frees the slot", func(t *testing.T) {
+		time.Sleep(20 * time.Millisecond)
+
+		report, err := breakers.Allow("partner.com")
+		require.NoError(t, err)
+		report(Ignored)
+		report(Failure)
+
+		require.Equal(t, StateHalfOpen, breakers.States()[1].State)
+	})
+
+	t.Run("Half-open success closes", func(t *testing.T) {
+		call(Success)
+
+		state := breakers.States()[1]
+		require.Equal(t, StateClosed, state.State)
+		require.Zero(t, state.Failures)
+		require.Nil(t, state.OpenedAt)
+	})
+}
+
+func TestBreakers_Disabled(t *testing.T) {
+	t.Parallel()
+
+	breakers := NewBreakers(config.CircuitBreakerConfig{FailureThreshold: 1})
+	for i := 0; i < 3; i++ {
+		report, err := breakers.Allow("partner.com")
+		require.NoError(t, err)
+		report(Failure)
+	}
+	require.Empty(t, breakers.States())
+
+	var nilBreakers *Breakers
+	_, err := nilBreakers.Allow("partner.com")
+	require.NoError(t, err)
+}
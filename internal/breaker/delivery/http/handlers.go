@@ -0,0 +1,32 @@
+package http
+
+import (
+	"github.com/go-chi/render"
+	"http-task-executor/internal/breaker"
+	"http-task-executor/internal/logger"
+	"net/http"
+)
+
+type BreakerHandlers struct {
+	logger   logger.Logger
+	breakers *breaker.Breakers
+}
+
+func NewBreakerHandlers(logger logger.Logger, breakers *breaker.Breakers) *BreakerHandlers {
+	return &BreakerHandlers{logger: logger, breakers: breakers}
+}
+
+// List godoc
+// @Summary List circuit breakers
+// @Description Returns the circuit breaker state of every target host called since the start of the instance. Open breakers fail tasks to the host without calling it. Requires a key of an admin client
+// @Tags Admin
+// @Produce json
+// @Success 200 {array} breaker.State
+// @Security ApiKeyAuth
+// @Router /admin/breakers [get]
+func (h *BreakerHandlers) List() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, h.breakers.States())
+	}
+}
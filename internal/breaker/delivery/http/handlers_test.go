@@ -0,0 +1,36 @@
+package http
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"http-task-executor/internal/breaker"
+	"http-task-executor/internal/config"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestBreakerHandlers_List(t *testing.T) {
+	t.Parallel()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	breakers := breaker.NewBreakers(config.CircuitBreakerConfig{Enabled: true, FailureThreshold: 1, OpenTimeout: time.Minute})
+	report, err := breakers.Allow("partner.com")
+	require.NoError(t, err)
+	report(breaker.Failure)
+
+	rec := httptest.NewRecorder()
+	NewBreakerHandlers(sugar, breakers).List().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/breakers", nil))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	var states []breaker.State
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &states))
+	require.Len(t, states, 1)
+	require.Equal(t, "partner.com", states[0].Host)
+	require.Equal(t, breaker.StateOpen, states[0].State)
+	require.Equal(t, 1, states[0].Failures)
+}
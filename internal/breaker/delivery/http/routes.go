@@ -0,0 +1,7 @@
+package http
+
+import "github.com/go-chi/chi/v5"
+
+func MapBreakerRoutes(router chi.Router, handlers *BreakerHandlers) {
+	router.Get("/admin/breakers", handlers.List())
+}
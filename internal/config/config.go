@@ -8,11 +8,27 @@ import (
 )
 
 type Config struct {
-	Env                    string           `yaml:"env" env-required:"true"`
-	ServerConfig           HttpServerConfig `yaml:"http_server"`
-	Postgres               PostgresConfig   `yaml:"postgres"`
-	LoggerConfig           LoggerConfig     `yaml:"logger"`
-	ExternalServiceTimeout time.Duration    `yaml:"external_service_timeout"`
+	Env                    string               `yaml:"env" env-required:"true"`
+	ServerConfig           HttpServerConfig     `yaml:"http_server"`
+	Postgres               PostgresConfig       `yaml:"postgres"`
+	LoggerConfig           LoggerConfig         `yaml:"logger"`
+	ExternalServiceTimeout time.Duration        `yaml:"external_service_timeout"`
+	MaxRequestBodySize     int64                `yaml:"max_request_body_size" env-default:"1048576"`
+	MaxPayloadSize         int64                `yaml:"max_payload_size" env-default:"10485760"`
+	MaxResponseBodySize    int64                `yaml:"max_response_body_size" env-default:"1048576"`
+	MaxBatchSize           int                  `yaml:"max_batch_size" env-default:"500"`
+	WorkerPool             WorkerPoolConfig     `yaml:"worker_pool"`
+	Scheduler              SchedulerConfig      `yaml:"scheduler"`
+	Callback               CallbackConfig       `yaml:"callback"`
+	Events                 EventsConfig         `yaml:"events"`
+	Tracing                TracingConfig        `yaml:"tracing"`
+	Health                 HealthConfig         `yaml:"health"`
+	Reaper                 ReaperConfig         `yaml:"reaper"`
+	Auth                   AuthConfig           `yaml:"auth"`
+	RateLimit              RateLimitConfig      `yaml:"rate_limit"`
+	CircuitBreaker         CircuitBreakerConfig `yaml:"circuit_breaker"`
+	Egress                 EgressConfig         `yaml:"egress"`
+	HttpClient             HttpClientConfig     `yaml:"http_client"`
 }
 
 type HttpServerConfig struct {
@@ -32,6 +48,130 @@ type PostgresConfig struct {
 	Driver   string `yaml:"driver" env-required:"true"`
 }
 
+type WorkerPoolConfig struct {
+	Workers      int           `yaml:"workers" env-default:"10"`
+	PollInterval time.Duration `yaml:"poll_interval" env-default:"1s"`
+	DrainTimeout time.Duration `yaml:"drain_timeout" env-default:"20s"`
+}
+
+type SchedulerConfig struct {
+	PollInterval time.Duration `yaml:"poll_interval" env-default:"1s"`
+	BatchSize    int           `yaml:"batch_size" env-default:"100"`
+}
+
+// CallbackConfig sets how callbacks are delivered. Workers deliveries run at once, so a slow
+// callback URL holds up a single worker rather than every other delivery.
+type CallbackConfig struct {
+	Workers      int           `yaml:"workers" env-default:"4"`
+	PollInterval time.Duration `yaml:"poll_interval" env-default:"1s"`
+	Timeout      time.Duration `yaml:"timeout" env-default:"10s"`
+	MaxAttempts  int           `yaml:"max_attempts" env-default:"5"`
+	BackoffBase  time.Duration `yaml:"backoff_base" env-default:"1s"`
+	BackoffMax   time.Duration `yaml:"backoff_max" env-default:"5m"`
+}
+
+type EventsConfig struct {
+	BufferSize int `yaml:"buffer_size" env-default:"64"`
+}
+
+// TracingConfig has no default for SampleRatio, as cleanenv would apply it over an explicit 0.
+type TracingConfig struct {
+	Exporter    string  `yaml:"exporter" env-default:"none"`
+	Endpoint    string  `yaml:"endpoint" env-default:"localhost:4318"`
+	Insecure    bool    `yaml:"insecure"`
+	File        string  `yaml:"file"`
+	ServiceName string  `yaml:"service_name" env-default:"http-task-executor"`
+	SampleRatio float64 `yaml:"sample_ratio"`
+}
+
+// ReaperConfig sets how long a task may go without a heartbeat before it is considered stuck.
+// Lease has to be well above HeartbeatInterval.
+type ReaperConfig struct {
+	PollInterval      time.Duration `yaml:"poll_interval" env-default:"30s"`
+	Lease             time.Duration `yaml:"lease" env-default:"2m"`
+	HeartbeatInterval time.Duration `yaml:"heartbeat_interval" env-default:"30s"`
+}
+
+// RateLimitConfig caps outbound requests per target host, Hosts overriding Default for the listed hosts.
+// A worker waits at most MaxWait for the host, tasks that would wait longer are returned to the queue
+// until the host is expected to admit them.
+type RateLimitConfig struct {
+	Default HostLimitConfig            `yaml:"default"`
+	Hosts   map[string]HostLimitConfig `yaml:"hosts"`
+	MaxWait time.Duration              `yaml:"max_wait" env-default:"1s"`
+}
+
+// HostLimitConfig allows Rate requests per second with bursts of Burst and at most Concurrency
+// requests in flight, zero meaning no limit.
+type HostLimitConfig struct {
+	Rate        float64 `yaml:"rate"`
+	Burst       int     `yaml:"burst"`
+	Concurrency int     `yaml:"concurrency"`
+}
+
+// CircuitBreakerConfig has no default for Enabled, as cleanenv would apply it over an explicit false.
+type CircuitBreakerConfig struct {
+	Enabled          bool          `yaml:"enabled"`
+	FailureThreshold int           `yaml:"failure_threshold" env-default:"5"`
+	OpenTimeout      time.Duration `yaml:"open_timeout" env-default:"30s"`
+	HalfOpenRequests int           `yaml:"half_open_requests" env-default:"1"`
+}
+
+// EgressConfig restricts where tasks and callbacks are sent. Loopback, private, link-local and other
+// non-public addresses are denied unless AllowPrivate is set, AllowCIDRs take precedence over the denied
+// ranges. Hosts are exact names or *.domain patterns, empty AllowHosts and Ports allowing any.
+type EgressConfig struct {
+	Schemes      []string `yaml:"schemes" env-default:"http,https"`
+	Ports        []int    `yaml:"ports"`
+	AllowPrivate bool     `yaml:"allow_private"`
+	AllowCIDRs   []string `yaml:"allow_cidrs"`
+	DenyCIDRs    []string `yaml:"deny_cidrs"`
+	AllowHosts   []string `yaml:"allow_hosts"`
+	DenyHosts    []string `yaml:"deny_hosts"`
+}
+
+// HttpClientConfig tunes the transport shared by task executions and callbacks. Proxy takes an http,
+// https or socks5 url, hosts matching NoProxy (exact names or *.domain patterns) are called directly.
+// Tasks may pick one of TLSProfiles by name instead of TLS, each profile getting a transport of its own.
+type HttpClientConfig struct {
+	Proxy                 string               `yaml:"proxy"`
+	NoProxy               []string             `yaml:"no_proxy"`
+	TLS                   TLSConfig            `yaml:"tls"`
+	TLSProfiles           map[string]TLSConfig `yaml:"tls_profiles"`
+	MaxIdleConns          int                  `yaml:"max_idle_conns" env-default:"100"`
+	MaxIdleConnsPerHost   int                  `yaml:"max_idle_conns_per_host" env-default:"10"`
+	MaxConnsPerHost       int                  `yaml:"max_conns_per_host"`
+	IdleConnTimeout       time.Duration        `yaml:"idle_conn_timeout" env-default:"90s"`
+	DialTimeout           time.Duration        `yaml:"dial_timeout" env-default:"30s"`
+	KeepAlive             time.Duration        `yaml:"keep_alive" env-default:"30s"`
+	TLSHandshakeTimeout   time.Duration        `yaml:"tls_handshake_timeout" env-default:"10s"`
+	ResponseHeaderTimeout time.Duration        `yaml:"response_header_timeout"`
+	ExpectContinueTimeout time.Duration        `yaml:"expect_continue_timeout" env-default:"1s"`
+}
+
+// TLSConfig trusts the PEM bundle in CAFile on top of the system roots and presents
+// CertFile/KeyFile as client certificate. ServerName overrides the name the server certificate is verified against.
+type TLSConfig struct {
+	CAFile             string `yaml:"ca_file"`
+	ServerName         string `yaml:"server_name"`
+	CertFile           string `yaml:"cert_file"`
+	KeyFile            string `yaml:"key_file"`
+	MinVersion         string `yaml:"min_version" env-default:"1.2"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"`
+}
+
+// AuthConfig has no default for Enabled, as cleanenv would apply it over an explicit false.
+// AdminClients lists the clients allowed to use the /admin routes.
+type AuthConfig struct {
+	Enabled      bool     `yaml:"enabled"`
+	AdminClients []string `yaml:"admin_clients"`
+}
+
+type HealthConfig struct {
+	Timeout    time.Duration `yaml:"timeout" env-default:"2s"`
+	MaxBacklog int64         `yaml:"max_backlog"`
+}
+
 type LoggerConfig struct {
 	Filename string `yaml:"filename" env-required:"true"`
 	Level    string `yaml:"level" env-required:"true"`
@@ -39,7 +179,14 @@ type LoggerConfig struct {
 }
 
 func MustLoad() *Config {
-	path := getConfigPath()
+	return MustLoadPath(getConfigPath())
+}
+
+// MustLoadPath reads the config from path, falling back to CONFIG_PATH when it is empty.
+func MustLoadPath(path string) *Config {
+	if path == "" {
+		path = os.Getenv("CONFIG_PATH")
+	}
 
 	if path == "" {
 		panic("config file path not found")
@@ -63,9 +210,5 @@ func getConfigPath() string {
 	flag.StringVar(&path, "config", "", "config file path")
 	flag.Parse()
 
-	if path == "" {
-		path = os.Getenv("CONFIG_PATH")
-	}
-
 	return path
 }
@@ -0,0 +1,262 @@
+package egress
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"http-task-executor/internal/config"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+var ErrDenied = errors.New("egress denied")
+
+// privatePrefixes are the ranges which don't belong to the public internet: loopback, RFC1918,
+// carrier-grade NAT, link-local (incl. cloud metadata), documentation, benchmarking, multicast
+// and reserved ones.
+var privatePrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("10.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("127.0.0.0/8"),
+	netip.MustParsePrefix("169.254.0.0/16"),
+	netip.MustParsePrefix("172.16.0.0/12"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("192.168.0.0/16"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("224.0.0.0/4"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("::/128"),
+	netip.MustParsePrefix("::1/128"),
+	netip.MustParsePrefix("fc00::/7"),
+	netip.MustParsePrefix("fe80::/10"),
+	netip.MustParsePrefix("ff00::/8"),
+}
+
+// The NAT64 and 6to4 prefixes embed an IPv4 address which the gateway ends up connecting to.
+var (
+	nat64Prefix = netip.MustParsePrefix("64:ff9b::/96")
+	sixToFour   = netip.MustParsePrefix("2002::/16")
+)
+
+const lookupTimeout = 2 * time.Second
+
+// Policy decides which targets the service may call. It is checked when a task is validated
+// and again when a connection is dialed, so a host resolving to a denied address later on
+// (DNS rebinding) is still refused. A nil policy allows everything.
+type Policy struct {
+	schemes    []string
+	ports      []int
+	allowCIDRs []netip.Prefix
+	denyCIDRs  []netip.Prefix
+	allowHosts []string
+	denyHosts  []string
+	lookup     func(ctx context.Context, network, host string) ([]netip.Addr, error)
+}
+
+func NewPolicy(cfg config.EgressConfig) (*Policy, error) {
+	p := &Policy{ports: cfg.Ports, lookup: net.DefaultResolver.LookupNetIP}
+	for _, scheme := range cfg.Schemes {
+		p.schemes = append(p.schemes, strings.ToLower(scheme))
+	}
+
+	var err error
+	p.allowCIDRs, err = parsePrefixes(cfg.AllowCIDRs)
+	if err != nil {
+		return nil, err
+	}
+	p.denyCIDRs, err = parsePrefixes(cfg.DenyCIDRs)
+	if err != nil {
+		return nil, err
+	}
+	if !cfg.AllowPrivate {
+		p.denyCIDRs = append(p.denyCIDRs, privatePrefixes...)
+	}
+
+	for _, host := range cfg.AllowHosts {
+		p.allowHosts = append(p.allowHosts, normalizeHost(host))
+	}
+	for _, host := range cfg.DenyHosts {
+		p.denyHosts = append(p.denyHosts, normalizeHost(host))
+	}
+	return p, nil
+}
+
+// CheckURL validates the scheme, port and host of the url, resolving the host to check its addresses.
+// Hosts which can't be resolved yet pass, the dialer checks them once they do.
+func (p *Policy) CheckURL(ctx context.Context, rawUrl string) error {
+	if p == nil {
+		return nil
+	}
+	target, err := url.Parse(rawUrl)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDenied, err)
+	}
+	if err := p.check(target); err != nil {
+		return err
+	}
+
+	host := target.Hostname()
+	if _, err := netip.ParseAddr(host); err == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, lookupTimeout)
+	defer cancel()
+	addrs, err := p.lookup(ctx, "ip", host)
+	if err != nil {
+		return nil
+	}
+	for _, addr := range addrs {
+		if err := p.checkAddr(addr); err != nil {
+			return fmt.Errorf("%w (%s resolves to %s)", err, host, addr.Unmap())
+		}
+	}
+	return nil
+}
+
+// CheckRedirect is an http.Client CheckRedirect refusing redirects to targets denied by the policy.
+func (p *Policy) CheckRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return errors.New("stopped after 10 redirects")
+	}
+	if p == nil {
+		return nil
+	}
+	return p.check(req.URL)
+}
+
+// Control is a net.Dialer Control checking the address a connection is about to be made to.
+func (p *Policy) Control(network, address string, _ syscall.RawConn) error {
+	if p == nil {
+		return nil
+	}
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDenied, err)
+	}
+	if err := p.checkPort(int(addrPort.Port())); err != nil {
+		return err
+	}
+	return p.checkAddr(addrPort.Addr())
+}
+
+// check validates the url without resolving its host.
+func (p *Policy) check(target *url.URL) error {
+	scheme := strings.ToLower(target.Scheme)
+	if len(p.schemes) > 0 && !slices.Contains(p.schemes, scheme) {
+		return fmt.Errorf("%w: scheme %s is not allowed", ErrDenied, scheme)
+	}
+
+	port := target.Port()
+	if port == "" {
+		port = defaultPort(scheme)
+	}
+	portNumber, err := strconv.Atoi(port)
+	if err != nil {
+		return fmt.Errorf("%w: invalid port %s", ErrDenied, port)
+	}
+	if err := p.checkPort(portNumber); err != nil {
+		return err
+	}
+
+	host := normalizeHost(target.Hostname())
+	if host == "" {
+		return fmt.Errorf("%w: empty host", ErrDenied)
+	}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return p.checkAddr(addr)
+	}
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return p.checkAddr(netip.IPv6Loopback())
+	}
+	if slices.ContainsFunc(p.denyHosts, func(pattern string) bool { return MatchHost(pattern, host) }) {
+		return fmt.Errorf("%w: host %s is denied", ErrDenied, host)
+	}
+	if len(p.allowHosts) > 0 && !slices.ContainsFunc(p.allowHosts, func(pattern string) bool { return MatchHost(pattern, host) }) {
+		return fmt.Errorf("%w: host %s is not allowed", ErrDenied, host)
+	}
+	return nil
+}
+
+func (p *Policy) checkPort(port int) error {
+	if len(p.ports) > 0 && !slices.Contains(p.ports, port) {
+		return fmt.Errorf("%w: port %d is not allowed", ErrDenied, port)
+	}
+	return nil
+}
+
+// checkAddr denies addresses in the denied ranges unless an allowed range contains them. An IPv4
+// address embedded by NAT64 or 6to4 has to pass as well.
+func (p *Policy) checkAddr(addr netip.Addr) error {
+	// Prefixes never contain zoned addresses, so the zone has to go.
+	addr = addr.Unmap().WithZone("")
+	if embedded, ok := embeddedIPv4(addr); ok {
+		if err := p.checkAddr(embedded); err != nil {
+			return err
+		}
+	}
+	for _, prefix := range p.allowCIDRs {
+		if prefix.Contains(addr) {
+			return nil
+		}
+	}
+	for _, prefix := range p.denyCIDRs {
+		if prefix.Contains(addr) {
+			return fmt.Errorf("%w: address %s is not allowed", ErrDenied, addr)
+		}
+	}
+	return nil
+}
+
+// embeddedIPv4 returns the IPv4 address carried in a NAT64 or 6to4 address.
+func embeddedIPv4(addr netip.Addr) (netip.Addr, bool) {
+	bytes := addr.As16()
+	switch {
+	case nat64Prefix.Contains(addr):
+		return netip.AddrFrom4([4]byte(bytes[12:16])), true
+	case sixToFour.Contains(addr):
+		return netip.AddrFrom4([4]byte(bytes[2:6])), true
+	}
+	return netip.Addr{}, false
+}
+
+func parsePrefixes(cidrs []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid egress cidr %s: %w", cidr, err)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// MatchHost matches the host against an exact name or a *.domain pattern covering its subdomains.
+func MatchHost(pattern string, host string) bool {
+	if suffix, ok := strings.CutPrefix(pattern, "*"); ok {
+		return strings.HasSuffix(host, suffix)
+	}
+	return pattern == host
+}
+
+func normalizeHost(host string) string {
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
+
+func defaultPort(scheme string) string {
+	if scheme == "https" {
+		return "443"
+	}
+	return "80"
+}
@@ -0,0 +1,124 @@
+package egress
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/require"
+	"http-task-executor/internal/config"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"testing"
+)
+
+func TestPolicy_CheckURL(t *testing.T) {
+	t.Parallel()
+
+	policy, err := NewPolicy(config.EgressConfig{
+		Schemes:    []string{"http", "https"},
+		Ports:      []int{80, 443, 8443},
+		AllowCIDRs: []string{"10.1.2.0/24"},
+		DenyCIDRs:  []string{"93.184.215.0/24"},
+		DenyHosts:  []string{"*.internal.partner.com"},
+	})
+	require.NoError(t, err)
+	policy.lookup = func(ctx context.Context, network, host string) ([]netip.Addr, error) {
+		switch host {
+		case "rebound.partner.com":
+			return []netip.Addr{netip.MustParseAddr("8.8.8.8"), netip.MustParseAddr("192.168.1.10")}, nil
+		case "partner.com":
+			return []netip.Addr{netip.MustParseAddr("8.8.8.8")}, nil
+		}
+		return nil, errors.New("no such host")
+	}
+
+	allowed := []string{
+		"https://partner.com/api",
+		"https://partner.com:8443/api",
+		"http://10.1.2.3/",
+		"http://[64:ff9b::808:808]/",
+		"http://[2002:808:808::1]/",
+		"https://not-resolvable.partner.com/",
+		"https://internal.partner.com/",
+	}
+	for _, target := range allowed {
+		require.NoError(t, policy.CheckURL(context.Background(), target), target)
+	}
+
+	denied := []string{
+		"http://169.254.169.254/latest/meta-data/",
+		"http://127.0.0.1/",
+		"http://[::1]/",
+		"http://[::ffff:10.0.0.1]/",
+		"http://[64:ff9b::a9fe:a9fe]/",
+		"http://[64:ff9b::127.0.0.1]/",
+		"http://[2002:a9fe:a9fe::1]/",
+		"http://[2002:c0a8:0101::]/",
+		"http://192.0.2.10/",
+		"http://198.51.100.10/",
+		"http://203.0.113.10/",
+		"http://172.16.0.1/",
+		"http://localhost/",
+		"http://api.localhost./",
+		"http://93.184.215.14/",
+		"https://rebound.partner.com/",
+		"https://partner.com:22/",
+		"ftp://partner.com/",
+		"gopher://partner.com/",
+		"https://db.internal.partner.com/",
+	}
+	for _, target := range denied {
+		require.ErrorIs(t, policy.CheckURL(context.Background(), target), ErrDenied, target)
+	}
+}
+
+func TestPolicy_AllowHosts(t *testing.T) {
+	t.Parallel()
+
+	policy, err := NewPolicy(config.EgressConfig{AllowHosts: []string{"partner.com", "*.partner.com"}, AllowPrivate: true})
+	require.NoError(t, err)
+
+	require.NoError(t, policy.check(&url.URL{Scheme: "https", Host: "partner.com"}))
+	require.NoError(t, policy.check(&url.URL{Scheme: "https", Host: "API.Partner.com"}))
+	require.ErrorIs(t, policy.check(&url.URL{Scheme: "https", Host: "evilpartner.com"}), ErrDenied)
+	require.NoError(t, policy.check(&url.URL{Scheme: "http", Host: "127.0.0.1:8080"}))
+}
+
+func TestPolicy_Control(t *testing.T) {
+	t.Parallel()
+
+	policy, err := NewPolicy(config.EgressConfig{Ports: []int{443}})
+	require.NoError(t, err)
+
+	require.NoError(t, policy.Control("tcp4", "8.8.8.8:443", nil))
+	require.ErrorIs(t, policy.Control("tcp4", "8.8.8.8:25", nil), ErrDenied)
+	require.ErrorIs(t, policy.Control("tcp4", "169.254.169.254:443", nil), ErrDenied)
+	require.ErrorIs(t, policy.Control("tcp6", "[fe80::1%eth0]:443", nil), ErrDenied)
+
+	var nilPolicy *Policy
+	require.NoError(t, nilPolicy.Control("tcp4", "127.0.0.1:80", nil))
+}
+
+func TestPolicy_CheckRedirect(t *testing.T) {
+	t.Parallel()
+
+	policy, err := NewPolicy(config.EgressConfig{Schemes: []string{"https"}})
+	require.NoError(t, err)
+
+	redirect := func(target string) *http.Request {
+		req, err := http.NewRequest(http.MethodGet, target, nil)
+		require.NoError(t, err)
+		return req
+	}
+
+	require.NoError(t, policy.CheckRedirect(redirect("https://partner.com/next"), nil))
+	require.ErrorIs(t, policy.CheckRedirect(redirect("http://partner.com/next"), nil), ErrDenied)
+	require.ErrorIs(t, policy.CheckRedirect(redirect("https://169.254.169.254/"), nil), ErrDenied)
+}
+
+func TestNewPolicy_InvalidCidr(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewPolicy(config.EgressConfig{AllowCIDRs: []string{"10.0.0.0/33"}})
+	require.Error(t, err)
+}
@@ -0,0 +1,71 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+)
+
+type VersionFunc func(ctx context.Context) (current int64, latest int64, err error)
+
+type WorkerStats interface {
+	Workers() int
+	Busy() int
+}
+
+type BacklogCounter interface {
+	CountBacklog(ctx context.Context) (int64, error)
+}
+
+// Database pings the database through the connection pool.
+func Database(db *sqlx.DB) Check {
+	return func(ctx context.Context) Component {
+		stats := db.Stats()
+		details := map[string]any{
+			"openConnections": stats.OpenConnections,
+			"inUse":           stats.InUse,
+			"idle":            stats.Idle,
+		}
+		if err := db.PingContext(ctx); err != nil {
+			return down(err, details)
+		}
+		return up(details)
+	}
+}
+
+// Migrations reports down until the database schema reaches the latest embedded migration.
+func Migrations(version VersionFunc) Check {
+	return func(ctx context.Context) Component {
+		current, latest, err := version(ctx)
+		if err != nil {
+			return down(err, nil)
+		}
+		details := map[string]any{"current": current, "latest": latest}
+		if current < latest {
+			return down(fmt.Errorf("schema version %d is behind %d", current, latest), details)
+		}
+		return up(details)
+	}
+}
+
+// Executor reports the worker saturation and the backlog of due tasks. It goes down when every
+// worker is busy and the backlog exceeds maxBacklog, zero maxBacklog never fails the check.
+func Executor(workers WorkerStats, backlog BacklogCounter, maxBacklog int64) Check {
+	return func(ctx context.Context) Component {
+		total, busy := workers.Workers(), workers.Busy()
+		details := map[string]any{
+			"workers":    total,
+			"busy":       busy,
+			"saturation": float64(busy) / float64(max(total, 1)),
+		}
+		count, err := backlog.CountBacklog(ctx)
+		if err != nil {
+			return down(err, details)
+		}
+		details["backlog"] = count
+		if maxBacklog > 0 && busy >= total && count > maxBacklog {
+			return down(fmt.Errorf("all %d workers are busy and %d tasks are waiting", total, count), details)
+		}
+		return up(details)
+	}
+}
@@ -0,0 +1,53 @@
+package http
+
+import (
+	"github.com/go-chi/render"
+	"http-task-executor/internal/health"
+	"http-task-executor/internal/logger"
+	"net/http"
+)
+
+type HealthHandlers struct {
+	logger  logger.Logger
+	checker *health.Checker
+}
+
+func NewHealthHandlers(logger logger.Logger, checker *health.Checker) *HealthHandlers {
+	return &HealthHandlers{logger: logger, checker: checker}
+}
+
+// Liveness godoc
+// @Summary Liveness probe
+// @Description Answers 200 as long as the process serves requests. Dependencies are left to the readiness probe, so an outage of one doesn't get the instance restarted
+// @Tags Health
+// @Produce json
+// @Success 200 {object} health.Report
+// @Router /healthz [get]
+func (h *HealthHandlers) Liveness() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report := health.Report{Status: health.StatusUp, Components: map[string]health.Component{}}
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, report)
+	}
+}
+
+// Readiness godoc
+// @Summary Readiness probe
+// @Description Reports the status of the database, the schema migrations and the executor, answering 503 while any of them is down
+// @Tags Health
+// @Produce json
+// @Success 200 {object} health.Report
+// @Failure 503 {object} health.Report
+// @Router /readyz [get]
+func (h *HealthHandlers) Readiness() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report := h.checker.Check(r.Context())
+		if !report.Up() {
+			h.logger.Errorf("Readiness check failed: %+v", report.Components)
+			render.Status(r, http.StatusServiceUnavailable)
+		} else {
+			render.Status(r, http.StatusOK)
+		}
+		render.JSON(w, r, report)
+	}
+}
@@ -0,0 +1,49 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"http-task-executor/internal/health"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHealthHandlers(t *testing.T) {
+	t.Parallel()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	checker := health.NewChecker(time.Second)
+	checker.Register("database", func(ctx context.Context) health.Component {
+		return health.Component{Status: health.StatusDown, Error: errors.New("connection refused").Error()}
+	})
+	handlers := NewHealthHandlers(sugar, checker)
+
+	t.Run("Liveness answers ok without checking components", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handlers.Liveness().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		var report health.Report
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+		require.Equal(t, health.StatusUp, report.Status)
+		require.Empty(t, report.Components)
+	})
+
+	t.Run("Readiness fails while a component is down", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handlers.Readiness().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+		require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		var report health.Report
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+		require.Equal(t, health.StatusDown, report.Status)
+		require.Equal(t, "connection refused", report.Components["database"].Error)
+	})
+}
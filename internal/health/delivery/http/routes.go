@@ -0,0 +1,8 @@
+package http
+
+import "github.com/go-chi/chi/v5"
+
+func MapHealthRoutes(router chi.Router, handlers *HealthHandlers) {
+	router.Get("/healthz", handlers.Liveness())
+	router.Get("/readyz", handlers.Readiness())
+}
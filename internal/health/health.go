@@ -0,0 +1,81 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// Component is the state of a single dependency as reported by its check.
+type Component struct {
+	Status  string         `json:"status"`
+	Error   string         `json:"error,omitempty"`
+	Details map[string]any `json:"details,omitempty"`
+}
+
+type Report struct {
+	Status     string               `json:"status"`
+	Components map[string]Component `json:"components"`
+}
+
+func (r Report) Up() bool {
+	return r.Status == StatusUp
+}
+
+type Check func(ctx context.Context) Component
+
+// Checker runs the registered checks concurrently, each bounded by the timeout, so a hanging
+// dependency can't hold up the probe.
+type Checker struct {
+	timeout time.Duration
+	checks  map[string]Check
+}
+
+func NewChecker(timeout time.Duration) *Checker {
+	if timeout <= 0 {
+		timeout = 2 * time.Second
+	}
+	return &Checker{timeout: timeout, checks: make(map[string]Check)}
+}
+
+func (c *Checker) Register(name string, check Check) {
+	c.checks[name] = check
+}
+
+func (c *Checker) Check(ctx context.Context) Report {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	report := Report{Status: StatusUp, Components: make(map[string]Component, len(c.checks))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			component := check(ctx)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Components[name] = component
+			if component.Status != StatusUp {
+				report.Status = StatusDown
+			}
+		}()
+	}
+	wg.Wait()
+	return report
+}
+
+func up(details map[string]any) Component {
+	return Component{Status: StatusUp, Details: details}
+}
+
+func down(err error, details map[string]any) Component {
+	return Component{Status: StatusDown, Error: err.Error(), Details: details}
+}
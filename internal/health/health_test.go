@@ -0,0 +1,113 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+type workerStats struct {
+	workers int
+	busy    int
+}
+
+func (w workerStats) Workers() int {
+	return w.workers
+}
+
+func (w workerStats) Busy() int {
+	return w.busy
+}
+
+type backlogCounter struct {
+	count int64
+	err   error
+}
+
+func (b backlogCounter) CountBacklog(ctx context.Context) (int64, error) {
+	return b.count, b.err
+}
+
+func TestChecker_Check(t *testing.T) {
+	t.Parallel()
+
+	t.Run("All components up", func(t *testing.T) {
+		checker := NewChecker(time.Second)
+		checker.Register("first", func(ctx context.Context) Component { return up(nil) })
+		checker.Register("second", func(ctx context.Context) Component { return up(nil) })
+
+		report := checker.Check(context.Background())
+
+		require.True(t, report.Up())
+		require.Len(t, report.Components, 2)
+	})
+
+	t.Run("One component down", func(t *testing.T) {
+		checker := NewChecker(time.Second)
+		checker.Register("first", func(ctx context.Context) Component { return up(nil) })
+		checker.Register("second", func(ctx context.Context) Component { return down(errors.New("unavailable"), nil) })
+
+		report := checker.Check(context.Background())
+
+		require.False(t, report.Up())
+		require.Equal(t, StatusUp, report.Components["first"].Status)
+		require.Equal(t, "unavailable", report.Components["second"].Error)
+	})
+
+	t.Run("Hanging check is bounded by timeout", func(t *testing.T) {
+		checker := NewChecker(10 * time.Millisecond)
+		checker.Register("hanging", func(ctx context.Context) Component {
+			<-ctx.Done()
+			return down(ctx.Err(), nil)
+		})
+
+		report := checker.Check(context.Background())
+
+		require.False(t, report.Up())
+	})
+}
+
+func TestMigrations(t *testing.T) {
+	t.Parallel()
+
+	version := func(current, latest int64, err error) VersionFunc {
+		return func(ctx context.Context) (int64, int64, error) { return current, latest, err }
+	}
+
+	require.Equal(t, StatusUp, Migrations(version(5, 5, nil))(context.Background()).Status)
+	require.Equal(t, StatusDown, Migrations(version(4, 5, nil))(context.Background()).Status)
+	require.Equal(t, StatusDown, Migrations(version(0, 0, errors.New("no version table")))(context.Background()).Status)
+}
+
+func TestExecutor(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Saturated with backlog over limit", func(t *testing.T) {
+		component := Executor(workerStats{workers: 2, busy: 2}, backlogCounter{count: 11}, 10)(context.Background())
+
+		require.Equal(t, StatusDown, component.Status)
+		require.Equal(t, int64(11), component.Details["backlog"])
+		require.Equal(t, 1.0, component.Details["saturation"])
+	})
+
+	t.Run("Free workers with backlog over limit", func(t *testing.T) {
+		component := Executor(workerStats{workers: 2, busy: 1}, backlogCounter{count: 11}, 10)(context.Background())
+
+		require.Equal(t, StatusUp, component.Status)
+	})
+
+	t.Run("No backlog limit", func(t *testing.T) {
+		component := Executor(workerStats{workers: 2, busy: 2}, backlogCounter{count: 1000}, 0)(context.Background())
+
+		require.Equal(t, StatusUp, component.Status)
+	})
+
+	t.Run("Backlog count error", func(t *testing.T) {
+		component := Executor(workerStats{workers: 2}, backlogCounter{err: errors.New("connection refused")}, 0)(context.Background())
+
+		require.Equal(t, StatusDown, component.Status)
+		require.Equal(t, "connection refused", component.Error)
+	})
+}
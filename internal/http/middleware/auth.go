@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/go-chi/render"
+	"http-task-executor/internal/auth"
+	"http-task-executor/internal/logger"
+	"http-task-executor/internal/models"
+	httpErrors "http-task-executor/pkg/errors/http"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+const ApiKeyHeader = "X-API-Key"
+
+// Auth authenticates requests by the API key sent in X-API-Key or as a bearer token, and puts
+// the client owning the key into the request context.
+func Auth(log logger.Logger, keys auth.Repository) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			key := apiKey(r)
+			if key == "" {
+				writeError(w, r, httpErrors.NewUnauthorizedError(errors.New("missing API key")))
+				return
+			}
+
+			apiKey, err := keys.GetByHash(r.Context(), models.HashApiKey(key))
+			if err != nil {
+				if errors.Is(err, sql.ErrNoRows) {
+					writeError(w, r, httpErrors.NewUnauthorizedError(errors.New("invalid API key")))
+					return
+				}
+				log.Errorf("Auth.GetByHash : %v", err)
+				writeError(w, r, err)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(auth.WithClient(r.Context(), apiKey.Client)))
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// Admin lets through the requests of the listed clients only, it has to run after Auth.
+func Admin(clients []string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			client := auth.ClientFromContext(r.Context())
+			if client == nil || !slices.Contains(clients, *client) {
+				writeError(w, r, httpErrors.NewForbiddenError(errors.New("admin access required")))
+				return
+			}
+			next.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+func apiKey(r *http.Request) string {
+	if key := r.Header.Get(ApiKeyHeader); key != "" {
+		return key
+	}
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if ok && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token)
+	}
+	return ""
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	code, data := httpErrors.ErrorResponse(err)
+	render.Status(r, code)
+	render.JSON(w, r, data)
+}
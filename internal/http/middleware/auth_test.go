@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"database/sql"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"http-task-executor/internal/auth"
+	"http-task-executor/internal/auth/mock"
+	"http-task-executor/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAuth(t *testing.T) {
+	t.Parallel()
+	ctrx := gomock.NewController(t)
+	defer ctrx.Finish()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+	mockKeys := mock.NewMockRepository(ctrx)
+
+	var client *string
+	handler := Auth(sugar, mockKeys)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		client = auth.ClientFromContext(r.Context())
+	}))
+
+	t.Run("Valid key in header", func(t *testing.T) {
+		mockKeys.EXPECT().GetByHash(gomock.Any(), models.HashApiKey("tek_valid")).Return(&models.ApiKey{Id: 1, Client: "billing"}, nil).Times(1)
+
+		req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+		req.Header.Set(ApiKeyHeader, "tek_valid")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "billing", *client)
+	})
+
+	t.Run("Valid bearer token", func(t *testing.T) {
+		mockKeys.EXPECT().GetByHash(gomock.Any(), models.HashApiKey("tek_valid")).Return(&models.ApiKey{Id: 1, Client: "billing"}, nil).Times(1)
+
+		req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+		req.Header.Set("Authorization", "Bearer tek_valid")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("Missing key", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/tasks", nil))
+
+		require.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("Unknown or revoked key", func(t *testing.T) {
+		mockKeys.EXPECT().GetByHash(gomock.Any(), models.HashApiKey("tek_revoked")).Return(nil, sql.ErrNoRows).Times(1)
+
+		req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+		req.Header.Set(ApiKeyHeader, "tek_revoked")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+}
+
+func TestAdmin(t *testing.T) {
+	t.Parallel()
+
+	handler := Admin([]string{"ops"})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	t.Run("Admin client", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/admin/breakers", nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req.WithContext(auth.WithClient(req.Context(), "ops")))
+
+		require.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("Other client", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/admin/breakers", nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req.WithContext(auth.WithClient(req.Context(), "billing")))
+
+		require.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/breakers", nil))
+
+		require.Equal(t, http.StatusForbidden, rec.Code)
+	})
+}
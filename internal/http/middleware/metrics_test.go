@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"http-task-executor/internal/metrics"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMetrics_LabelsByRoutePattern(t *testing.T) {
+	t.Parallel()
+
+	router := chi.NewRouter()
+	router.Use(Metrics)
+	router.Get("/metrics-test/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	for _, path := range []string{"/metrics-test/1", "/metrics-test/2"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	require.Equal(t, 2.0, testutil.ToFloat64(metrics.HTTPRequests.WithLabelValues(http.MethodGet, "/metrics-test/{id}", "418")))
+}
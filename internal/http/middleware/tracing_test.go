@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTracing_ContinuesIncomingTrace(t *testing.T) {
+	t.Parallel()
+
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	var traceId string
+	router := chi.NewRouter()
+	router.Use(Tracing)
+	router.Get("/tracing-test/{id}", func(w http.ResponseWriter, r *http.Request) {
+		traceId = trace.SpanContextFromContext(r.Context()).TraceID().String()
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/tracing-test/1", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", traceId)
+}
@@ -1,35 +1,104 @@
 package server
 
 import (
+	"context"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	httpSwagger "github.com/swaggo/http-swagger"
+	authRepository "http-task-executor/internal/auth/repository"
+	"http-task-executor/internal/breaker"
+	breakerHttp "http-task-executor/internal/breaker/delivery/http"
+	"http-task-executor/internal/egress"
+	"http-task-executor/internal/health"
+	healthHttp "http-task-executor/internal/health/delivery/http"
 	mw "http-task-executor/internal/http/middleware"
+	"http-task-executor/internal/metrics"
+	"http-task-executor/internal/migration"
+	recurringHttp "http-task-executor/internal/recurring/delivery/http"
+	recurringRepository "http-task-executor/internal/recurring/repository"
+	"http-task-executor/internal/recurring/scheduler"
+	recurringUseCase "http-task-executor/internal/recurring/usecase"
+	"http-task-executor/internal/tasks/callback"
 	taskHttp "http-task-executor/internal/tasks/delivery/http"
+	"http-task-executor/internal/tasks/events"
 	"http-task-executor/internal/tasks/executor"
+	"http-task-executor/internal/tasks/reaper"
 	"http-task-executor/internal/tasks/repository"
 	"http-task-executor/internal/tasks/usecase"
+	"http-task-executor/internal/tasks/worker"
 	"time"
 )
 
-func (s *Server) AddHandlers(router chi.Router) {
+func (s *Server) AddHandlers(router chi.Router) error {
 	s.setupMV(router)
 
+	egressPolicy, err := egress.NewPolicy(s.config.Egress)
+	if err != nil {
+		return err
+	}
+
 	taskRepo := repository.NewRepository(s.database, s.logger)
-	taskExec := executor.NewExecutor(s.logger, taskRepo, &executor.ClientProvider{}, s.config.ExternalServiceTimeout)
-	taskUseCase := usecase.NewTaskUseCase(s.logger, taskRepo, taskExec)
+	clientProvider, err := executor.NewClientProvider(s.config.HttpClient, egressPolicy)
+	if err != nil {
+		return err
+	}
+	if s.config.HttpClient.TLS.InsecureSkipVerify {
+		s.logger.Warnf("TLS certificates of 3rd services are not verified")
+	}
+	breakers := breaker.NewBreakers(s.config.CircuitBreaker)
+	s.eventBus = events.NewPostgresBus(s.logger, s.database, taskRepo, events.NewBus(s.logger, s.config.Events.BufferSize))
+	s.executor = executor.NewExecutor(s.logger, taskRepo, clientProvider, s.eventBus, executor.NewHostLimiter(s.config.RateLimit), breakers, s.config.ExternalServiceTimeout, s.config.MaxResponseBodySize, s.config.Reaper.HeartbeatInterval)
+	s.pool = worker.NewPool(s.logger, taskRepo, s.executor, s.config.WorkerPool)
+	s.dispatcher = callback.NewDispatcher(s.logger, taskRepo, clientProvider, s.config.Callback)
+	s.reaper = reaper.NewReaper(s.logger, taskRepo, s.eventBus, s.config.Reaper)
+	taskUseCase := usecase.NewTaskUseCase(s.config, s.logger, taskRepo, s.pool, s.executor, s.eventBus, egressPolicy)
 	taskHandlers := taskHttp.NewTaskHandlers(s.config, s.logger, taskUseCase)
+	s.streams = taskHandlers.Close
+
+	recurringRepo := recurringRepository.NewRepository(s.database, s.logger)
+	s.scheduler = scheduler.NewScheduler(s.logger, recurringRepo, taskUseCase, s.config.Scheduler)
+	recurringTaskUseCase := recurringUseCase.NewRecurringTaskUseCase(s.logger, recurringRepo, taskUseCase)
+	recurringHandlers := recurringHttp.NewRecurringTaskHandlers(s.config, s.logger, recurringTaskUseCase)
+
+	router.Group(func(router chi.Router) {
+		if s.config.Auth.Enabled {
+			router.Use(mw.Auth(s.logger, authRepository.NewRepository(s.database, s.logger)))
+		}
+
+		router.Group(func(router chi.Router) {
+			router.Use(middleware.Timeout(60 * time.Second))
+			taskHttp.MapTasksRoutes(router, taskHandlers)
+			recurringHttp.MapRecurringTasksRoutes(router, recurringHandlers)
+		})
+		taskHttp.MapTaskEventsRoutes(router, taskHandlers)
+
+		router.Group(func(router chi.Router) {
+			if s.config.Auth.Enabled {
+				router.Use(mw.Admin(s.config.Auth.AdminClients))
+			}
+			breakerHttp.MapBreakerRoutes(router, breakerHttp.NewBreakerHandlers(s.logger, breakers))
+		})
+	})
 
-	taskHttp.MapTasksRoutes(router, taskHandlers)
+	checker := health.NewChecker(s.config.Health.Timeout)
+	checker.Register("database", health.Database(s.database))
+	checker.Register("migrations", health.Migrations(func(ctx context.Context) (int64, int64, error) {
+		return migration.Version(ctx, s.database)
+	}))
+	checker.Register("executor", health.Executor(s.pool, taskRepo, s.config.Health.MaxBacklog))
+	healthHttp.MapHealthRoutes(router, healthHttp.NewHealthHandlers(s.logger, checker))
 
 	router.Get("/swagger/*", httpSwagger.WrapHandler)
+	router.Handle("/metrics", metrics.Handler())
+	return nil
 }
 
 func (s *Server) setupMV(router chi.Router) {
 	router.Use(middleware.RequestID)
 	router.Use(middleware.RealIP)
 	router.Use(mw.New(s.logger))
+	router.Use(mw.Tracing)
+	router.Use(mw.Metrics)
 	router.Use(middleware.Recoverer)
-	router.Use(middleware.Timeout(60 * time.Second))
 	router.Use(middleware.URLFormat)
 }
@@ -8,6 +8,12 @@ import (
 	"github.com/jmoiron/sqlx"
 	"http-task-executor/internal/config"
 	"http-task-executor/internal/logger"
+	"http-task-executor/internal/recurring/scheduler"
+	"http-task-executor/internal/tasks/callback"
+	"http-task-executor/internal/tasks/events"
+	"http-task-executor/internal/tasks/executor"
+	"http-task-executor/internal/tasks/reaper"
+	"http-task-executor/internal/tasks/worker"
 	"net/http"
 	"os"
 	"os/signal"
@@ -18,9 +24,17 @@ import (
 const shutdownTimeout = 5 * time.Second
 
 type Server struct {
-	config   *config.Config
-	database *sqlx.DB
-	logger   logger.Logger
+	config     *config.Config
+	database   *sqlx.DB
+	logger     logger.Logger
+	pool       *worker.Pool
+	scheduler  *scheduler.Scheduler
+	dispatcher *callback.Dispatcher
+	eventBus   *events.PostgresBus
+	executor   *executor.Executor
+	reaper     *reaper.Reaper
+	// streams ends the open event streams on shutdown.
+	streams func()
 }
 
 func NewServer(config *config.Config, database *sqlx.DB, logger logger.Logger) *Server {
@@ -32,7 +46,9 @@ func (s *Server) Start() error {
 
 	router := chi.NewRouter()
 
-	s.AddHandlers(router)
+	if err := s.AddHandlers(router); err != nil {
+		return err
+	}
 
 	srv := &http.Server{
 		Addr:         fmt.Sprintf("%s:%d", s.config.ServerConfig.Host, s.config.ServerConfig.Port),
@@ -40,6 +56,19 @@ func (s *Server) Start() error {
 		ReadTimeout:  s.config.ServerConfig.ReadTimeout,
 		WriteTimeout: s.config.ServerConfig.WriteTimeout,
 	}
+	srv.RegisterOnShutdown(s.streams)
+
+	// Heartbeats, cancellations and the event listener keep running while the in-flight executions
+	// drain, only claiming and dispatching new work stops first.
+	runCtx, stopRunning := context.WithCancel(context.Background())
+	s.eventBus.Start(runCtx)
+	s.executor.Start(runCtx)
+
+	claimCtx, stopClaiming := context.WithCancel(runCtx)
+	s.pool.Start(claimCtx)
+	s.scheduler.Start(claimCtx)
+	s.dispatcher.Start(claimCtx)
+	s.reaper.Start(claimCtx)
 
 	go func() {
 		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
@@ -54,10 +83,48 @@ func (s *Server) Start() error {
 
 	s.logger.Infof("Shutting down server on %s", sign.String())
 
+	drainDeadline := time.Now().Add(s.config.WorkerPool.DrainTimeout)
+	stopClaiming()
+
 	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
 
 	defer cancel()
 
 	s.logger.Infof("Shutting down server properly...")
-	return srv.Shutdown(ctx)
+	err := srv.Shutdown(ctx)
+	if errors.Is(err, context.DeadlineExceeded) {
+		s.logger.Warnf("Server shutdown timed out after %s, closing remaining connections", shutdownTimeout)
+		err = srv.Close()
+	}
+
+	s.drain(drainDeadline)
+	stopRunning()
+	return err
+}
+
+// drain waits for the in-flight executions to finish. Executions still running at the deadline
+// are interrupted, which returns their tasks to new for another instance to pick up.
+func (s *Server) drain(deadline time.Time) {
+	done := make(chan struct{})
+	go func() {
+		s.pool.Wait()
+		close(done)
+	}()
+
+	s.logger.Infof("Waiting for in-flight executions until %s", deadline.Format(time.RFC3339))
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+	select {
+	case <-done:
+		s.logger.Infof("In-flight executions finished")
+		return
+	case <-timer.C:
+	}
+
+	s.logger.Infof("Drain deadline exceeded, interrupting %d executions", s.executor.Interrupt())
+	select {
+	case <-done:
+	case <-time.After(shutdownTimeout):
+		s.logger.Errorf("Workers did not stop after interrupting executions")
+	}
 }
@@ -0,0 +1,83 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"time"
+)
+
+const namespace = "task_executor"
+
+var (
+	TasksCreated = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tasks_created_total",
+		Help:      "Number of created tasks.",
+	})
+	TasksFinished = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tasks_finished_total",
+		Help:      "Number of tasks which reached a final status.",
+	}, []string{"status"})
+	TasksReaped = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tasks_reaped_total",
+		Help:      "Number of stuck tasks recovered by the reaper, by the action taken.",
+	}, []string{"action"})
+	InFlightExecutions = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "executions_in_flight",
+		Help:      "Number of tasks being executed.",
+	})
+	OutboundRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "outbound_request_duration_seconds",
+		Help:      "Latency of requests to 3rd services.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "host"})
+	OutboundResponseSize = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "outbound_response_size_bytes",
+		Help:      "Size of response bodies of 3rd services.",
+		Buckets:   prometheus.ExponentialBuckets(256, 4, 8),
+	}, []string{"method", "host"})
+	OutboundLimiterWait = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "outbound_limiter_wait_seconds",
+		Help:      "Time tasks waited for the rate and concurrency limits of the target host.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"host"})
+	CircuitBreakerTransitions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "circuit_breaker_transitions_total",
+		Help:      "Number of times the circuit breaker of a host moved to a state.",
+	}, []string{"host", "state"})
+	DBQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Latency of repository methods.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Number of handled HTTP requests.",
+	}, []string{"method", "route", "code"})
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of handled HTTP requests.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+)
+
+// ObserveQuery records the latency of the repository method started at start, meant to be deferred.
+func ObserveQuery(method string, start time.Time) {
+	DBQueryDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+}
+
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
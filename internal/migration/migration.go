@@ -1,18 +1,47 @@
 package migration
 
 import (
+	"context"
 	"github.com/jmoiron/sqlx"
 	"github.com/pressly/goose/v3"
 	root "http-task-executor"
+	"sync"
 )
 
+const migrationsDir = "migrations"
+
 func MigratePostgresql(db *sqlx.DB) error {
+	if err := setup(); err != nil {
+		return err
+	}
 
-	goose.SetBaseFS(root.MigrationFS)
+	return goose.Up(db.DB, migrationsDir)
+}
 
-	if err := goose.SetDialect("postgres"); err != nil {
-		return err
+// Version returns the schema version applied to the database and the latest embedded migration version.
+func Version(ctx context.Context, db *sqlx.DB) (int64, int64, error) {
+	if err := setup(); err != nil {
+		return 0, 0, err
 	}
 
-	return goose.Up(db.DB, "migrations")
+	current, err := goose.GetDBVersionContext(ctx, db.DB)
+	if err != nil {
+		return 0, 0, err
+	}
+	migrations, err := goose.CollectMigrations(migrationsDir, 0, goose.MaxVersion)
+	if err != nil {
+		return 0, 0, err
+	}
+	last, err := migrations.Last()
+	if err != nil {
+		return 0, 0, err
+	}
+	return current, last.Version, nil
 }
+
+// setup configures goose globals once, as readiness probes read the version concurrently.
+var setup = sync.OnceValue(func() error {
+	goose.SetBaseFS(root.MigrationFS)
+
+	return goose.SetDialect("postgres")
+})
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+)
+
+const apiKeyPrefix = "tek_"
+
+// ApiKey authenticates a client. Only the hash of the key is stored, the key itself is
+// shown once when it is created.
+type ApiKey struct {
+	Id        int64      `db:"id"`
+	Client    string     `db:"client"`
+	Hash      string     `db:"key_hash"`
+	CreatedAt time.Time  `db:"created_at"`
+	RevokedAt *time.Time `db:"revoked_at"`
+}
+
+// NewApiKey generates a random key for the client, returning it along with the record to store.
+func NewApiKey(client string) (string, *ApiKey, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", nil, err
+	}
+	key := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+	return key, &ApiKey{Client: client, Hash: HashApiKey(key)}, nil
+}
+
+func HashApiKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
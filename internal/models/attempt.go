@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+type TaskAttempt struct {
+	Id             int64     `db:"id"`
+	TaskId         int64     `db:"task_id"`
+	Attempt        int       `db:"attempt"`
+	StartedAt      time.Time `db:"started_at"`
+	FinishedAt     time.Time `db:"finished_at"`
+	DurationMs     int64     `db:"duration_ms"`
+	ResponseStatus *int64    `db:"response_status_code"`
+	ResponseLength *int64    `db:"response_length"`
+	Error          *string   `db:"error"`
+}
+
+func (a *TaskAttempt) Fail(err error) {
+	msg := err.Error()
+	a.Error = &msg
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// Batch is the result of a batch submission. Id is only set when the batch was
+// created to track the aggregate progress of its tasks.
+type Batch struct {
+	Id      *int64
+	TaskIds []int64
+}
+
+type BatchProgress struct {
+	Id        int64
+	Size      int
+	CreatedAt time.Time
+	Client    *string
+	Statuses  map[string]int
+}
+
+// Finished reports the number of tasks of the batch that reached a final status.
+func (p *BatchProgress) Finished() int {
+	return p.Statuses[StatusDone] + p.Statuses[StatusError] + p.Statuses[StatusCancelled]
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// TaskEventsChannel is the Postgres notification channel carrying task events between instances.
+const TaskEventsChannel = "task_events"
+
+// TaskEvent is published whenever a task moves to another status.
+type TaskEvent struct {
+	TaskId         int64     `json:"taskId"`
+	Status         string    `json:"status"`
+	ResponseStatus *int64    `json:"responseStatus,omitempty"`
+	LastError      *string   `json:"lastError,omitempty"`
+	At             time.Time `json:"at"`
+	Client         *string   `json:"client,omitempty"`
+}
+
+type TaskEventFilter struct {
+	TaskId   int64
+	Statuses []string
+	// Client limits the events to the tasks of the client, nil matches the tasks of every client.
+	Client *string
+}
+
+func NewTaskEvent(task *Task) TaskEvent {
+	return TaskEvent{TaskId: task.Id, Status: task.Status, ResponseStatus: task.ResponseStatus, LastError: task.LastError, At: time.Now(), Client: task.Client}
+}
+
+func (f TaskEventFilter) Match(event TaskEvent) bool {
+	if f.TaskId != 0 && f.TaskId != event.TaskId {
+		return false
+	}
+	if f.Client != nil && (event.Client == nil || *event.Client != *f.Client) {
+		return false
+	}
+	return len(f.Statuses) == 0 || slices.Contains(f.Statuses, event.Status)
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+const (
+	SortById        = "id"
+	SortByCreatedAt = "createdAt"
+)
+
+type TaskFilter struct {
+	Statuses          []string
+	Method            string
+	Host              string
+	UrlPrefix         string
+	MinResponseStatus *int64
+	MaxResponseStatus *int64
+	CreatedFrom       *time.Time
+	CreatedTo         *time.Time
+	SortBy            string
+	SortDesc          bool
+	Limit             int
+	After             *TaskCursor
+	Client            *string
+}
+
+// TaskCursor points at the last task of a page, so the next page starts right after it.
+type TaskCursor struct {
+	Id        int64     `json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type TaskPage struct {
+	Tasks []Task
+	Next  *TaskCursor
+}
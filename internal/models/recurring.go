@@ -0,0 +1,98 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/robfig/cron/v3"
+	"maps"
+	"slices"
+	"time"
+)
+
+const MinRecurringInterval = time.Second
+
+// RecurringTask spawns a new execution of its task template on every tick of
+// either a standard five-field cron expression or a fixed interval.
+type RecurringTask struct {
+	Id         int64      `db:"id"`
+	Task       Task       `db:"-"`
+	Cron       *string    `db:"cron"`
+	IntervalMs *int64     `db:"interval_ms"`
+	Paused     bool       `db:"paused"`
+	NextRunAt  time.Time  `db:"next_run_at"`
+	LastRunAt  *time.Time `db:"last_run_at"`
+	CreatedAt  time.Time  `db:"created_at"`
+	Client     *string    `db:"client"`
+}
+
+type RecurringExecution struct {
+	TaskId         int64     `db:"id"`
+	Status         string    `db:"status"`
+	ResponseStatus *int64    `db:"response_status_code"`
+	Attempts       int       `db:"attempts"`
+	CreatedAt      time.Time `db:"created_at"`
+}
+
+// NextRun returns the first tick of the schedule strictly after the given time.
+func (r *RecurringTask) NextRun(after time.Time) (time.Time, error) {
+	switch {
+	case r.Cron != nil && r.IntervalMs != nil:
+		return time.Time{}, errors.New("cron and interval are mutually exclusive")
+	case r.Cron != nil:
+		schedule, err := cron.ParseStandard(*r.Cron)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid cron expression: %w", err)
+		}
+		return schedule.Next(after), nil
+	case r.IntervalMs != nil:
+		interval := time.Duration(*r.IntervalMs) * time.Millisecond
+		if interval < MinRecurringInterval {
+			return time.Time{}, fmt.Errorf("interval must be at least %s", MinRecurringInterval)
+		}
+		return after.Add(interval), nil
+	default:
+		return time.Time{}, errors.New("either cron or interval is required")
+	}
+}
+
+// HeaderValues stores the input headers of a task template as a JSONB object.
+type HeaderValues map[string]string
+
+func NewHeaderValues(headers []Header) HeaderValues {
+	values := make(HeaderValues, len(headers))
+	for _, header := range headers {
+		values[header.Name] = header.Value
+	}
+	return values
+}
+
+func (h HeaderValues) Headers() []Header {
+	headers := make([]Header, 0, len(h))
+	for _, name := range slices.Sorted(maps.Keys(h)) {
+		headers = append(headers, Header{Name: name, Value: h[name], Input: true})
+	}
+	return headers
+}
+
+func (h HeaderValues) Value() (driver.Value, error) {
+	if h == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(h)
+}
+
+func (h *HeaderValues) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*h = HeaderValues{}
+		return nil
+	case []byte:
+		return json.Unmarshal(v, h)
+	case string:
+		return json.Unmarshal([]byte(v), h)
+	default:
+		return errors.New("HeaderValues.Scan: unsupported type")
+	}
+}
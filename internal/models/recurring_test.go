@@ -0,0 +1,44 @@
+package models
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestRecurringTask_NextRun(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 10, 17, 10, 2, 30, 0, time.UTC)
+
+	cron := "*/5 * * * *"
+	next, err := (&RecurringTask{Cron: &cron}).NextRun(now)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2026, 10, 17, 10, 5, 0, 0, time.UTC), next)
+
+	interval := int64(30000)
+	next, err = (&RecurringTask{IntervalMs: &interval}).NextRun(now)
+	require.NoError(t, err)
+	require.Equal(t, now.Add(30*time.Second), next)
+}
+
+func TestRecurringTask_NextRunInvalid(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	invalidCron := "every minute"
+	shortInterval := int64(10)
+	cron := "* * * * *"
+
+	_, err := (&RecurringTask{}).NextRun(now)
+	require.Error(t, err)
+
+	_, err = (&RecurringTask{Cron: &invalidCron}).NextRun(now)
+	require.Error(t, err)
+
+	_, err = (&RecurringTask{IntervalMs: &shortInterval}).NextRun(now)
+	require.Error(t, err)
+
+	_, err = (&RecurringTask{Cron: &cron, IntervalMs: &shortInterval}).NextRun(now)
+	require.Error(t, err)
+}
@@ -0,0 +1,73 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"math/rand/v2"
+	"slices"
+	"time"
+)
+
+const (
+	ErrorClassTimeout    = "timeout"
+	ErrorClassConnection = "connection"
+	ErrorClassDNS        = "dns"
+	ErrorClassTLS        = "tls"
+	// ErrorClassCircuitOpen marks attempts short-circuited by the breaker of the target host.
+	ErrorClassCircuitOpen = "circuit_open"
+	ErrorClassOther       = "other"
+)
+
+type RetryPolicy struct {
+	MaxAttempts   int      `json:"maxAttempts" validate:"min=1,max=20"`
+	BackoffBaseMs int64    `json:"backoffBaseMs" validate:"min=0"`
+	BackoffMaxMs  int64    `json:"backoffMaxMs" validate:"min=0,gtefield=BackoffBaseMs"`
+	Jitter        float64  `json:"jitter" validate:"min=0,max=1"`
+	RetryOnStatus []int    `json:"retryOnStatus" validate:"dive,min=100,max=599"`
+	RetryOnErrors []string `json:"retryOnErrors" validate:"dive,oneof=timeout connection dns tls circuit_open"`
+}
+
+func (p *RetryPolicy) ShouldRetry(attempt int) bool {
+	return p != nil && attempt < p.MaxAttempts
+}
+
+func (p *RetryPolicy) RetryableStatus(code int) bool {
+	return p != nil && slices.Contains(p.RetryOnStatus, code)
+}
+
+func (p *RetryPolicy) RetryableError(class string) bool {
+	return p != nil && slices.Contains(p.RetryOnErrors, class)
+}
+
+// Backoff returns the delay before the attempt following the given one:
+// base * 2^(attempt-1) capped at max, reduced by a random share of up to Jitter.
+func (p *RetryPolicy) Backoff(attempt int) time.Duration {
+	delay := time.Duration(p.BackoffBaseMs) * time.Millisecond
+	maxDelay := time.Duration(p.BackoffMaxMs) * time.Millisecond
+	for i := 1; i < attempt && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	if p.Jitter > 0 {
+		delay -= time.Duration(float64(delay) * p.Jitter * rand.Float64())
+	}
+	return delay
+}
+
+func (p RetryPolicy) Value() (driver.Value, error) {
+	return json.Marshal(p)
+}
+
+func (p *RetryPolicy) Scan(src any) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, p)
+	case string:
+		return json.Unmarshal([]byte(v), p)
+	default:
+		return errors.New("RetryPolicy.Scan: unsupported type")
+	}
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	t.Parallel()
+
+	policy := &RetryPolicy{MaxAttempts: 10, BackoffBaseMs: 100, BackoffMaxMs: 1000}
+
+	require.Equal(t, 100*time.Millisecond, policy.Backoff(1))
+	require.Equal(t, 200*time.Millisecond, policy.Backoff(2))
+	require.Equal(t, 400*time.Millisecond, policy.Backoff(3))
+	require.Equal(t, 1000*time.Millisecond, policy.Backoff(5))
+	require.Equal(t, 1000*time.Millisecond, policy.Backoff(100))
+}
+
+func TestRetryPolicy_BackoffWithJitter(t *testing.T) {
+	t.Parallel()
+
+	policy := &RetryPolicy{MaxAttempts: 10, BackoffBaseMs: 1000, BackoffMaxMs: 1000, Jitter: 0.5}
+
+	for i := 0; i < 100; i++ {
+		delay := policy.Backoff(1)
+		require.GreaterOrEqual(t, delay, 500*time.Millisecond)
+		require.LessOrEqual(t, delay, 1000*time.Millisecond)
+	}
+}
+
+func TestRetryPolicy_ShouldRetry(t *testing.T) {
+	t.Parallel()
+
+	var noPolicy *RetryPolicy
+	require.False(t, noPolicy.ShouldRetry(1))
+	require.False(t, noPolicy.RetryableStatus(503))
+	require.False(t, noPolicy.RetryableError(ErrorClassTimeout))
+
+	policy := &RetryPolicy{MaxAttempts: 2, RetryOnStatus: []int{503}, RetryOnErrors: []string{ErrorClassTimeout}}
+	require.True(t, policy.ShouldRetry(1))
+	require.False(t, policy.ShouldRetry(2))
+	require.True(t, policy.RetryableStatus(503))
+	require.False(t, policy.RetryableStatus(500))
+	require.True(t, policy.RetryableError(ErrorClassTimeout))
+	require.False(t, policy.RetryableError(ErrorClassDNS))
+}
@@ -1,20 +1,103 @@
 package models
 
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+const (
+	BodyEncodingText   = "text"
+	BodyEncodingJSON   = "json"
+	BodyEncodingBase64 = "base64"
+)
+
 const (
 	StatusNew       = "new"
 	StatusError     = "error"
 	StatusInProcess = "in_process"
 	StatusDone      = "done"
+	StatusCancelled = "cancelled"
+	StatusScheduled = "scheduled"
 )
 
+const (
+	CallbackStatusPending   = "pending"
+	CallbackStatusDelivered = "delivered"
+	CallbackStatusFailed    = "failed"
+)
+
+var Statuses = []string{StatusNew, StatusScheduled, StatusInProcess, StatusDone, StatusError, StatusCancelled}
+
+var (
+	ErrInvalidStatusTransition = errors.New("invalid status transition")
+	ErrIdempotencyKeyConflict  = errors.New("idempotency key is already used by a different request")
+)
+
+// statusTransitions lists for every status the statuses a task may move to it from.
+var statusTransitions = map[string][]string{
+	StatusNew:       {StatusInProcess},
+	StatusInProcess: {StatusNew, StatusScheduled},
+	StatusDone:      {StatusInProcess},
+	StatusError:     {StatusInProcess},
+	StatusCancelled: {StatusNew, StatusScheduled, StatusInProcess},
+}
+
+func AllowedPreviousStatuses(status string) []string {
+	return statusTransitions[status]
+}
+
+// IsFinal reports whether a task never leaves the status.
+func IsFinal(status string) bool {
+	return status == StatusDone || status == StatusError || status == StatusCancelled
+}
+
 type Task struct {
-	Id             int64  `db:"id"`
-	Url            string `db:"url" validate:"required,url"`
-	Method         string `db:"method" validate:"required"`
-	Status         string `db:"status"`
-	ResponseStatus *int64 `db:"response_status_code"`
-	ResponseLength *int64 `db:"response_length"`
-	Headers        []Header
+	Id              int64        `db:"id"`
+	Url             string       `db:"url" validate:"required,url"`
+	Method          string       `db:"method" validate:"required"`
+	Body            string       `db:"body"`
+	BodyEncoding    string       `db:"body_encoding" validate:"omitempty,oneof=text json base64"`
+	Status          string       `db:"status"`
+	ResponseStatus  *int64       `db:"response_status_code"`
+	ResponseLength  *int64       `db:"response_length"`
+	RetryPolicy     *RetryPolicy `db:"retry_policy"`
+	Attempts        int          `db:"attempts"`
+	NextAttemptAt   *time.Time   `db:"next_attempt_at"`
+	LastError       *string      `db:"last_error"`
+	RunAt           *time.Time   `db:"run_at"`
+	CreatedAt       time.Time    `db:"created_at"`
+	IdempotencyKey  *string      `db:"idempotency_key"`
+	RequestHash     *string      `db:"request_hash"`
+	StartedAt       *time.Time   `db:"started_at"`
+	Client          *string      `db:"client"`
+	TlsProfile      *string      `db:"tls_profile"`
+	TraceId         *string      `db:"trace_id"`
+	SpanId          *string      `db:"span_id"`
+	TraceSampled    bool         `db:"trace_sampled"`
+	RecurringTaskId *int64       `db:"recurring_task_id"`
+	Headers         []Header
+	Response        *TaskResponse
+	Callback        *Callback
+}
+
+// Callback is the webhook notified once the task reaches done or error.
+type Callback struct {
+	Url       string  `db:"callback_url" validate:"required,url"`
+	Secret    string  `db:"callback_secret"`
+	Status    *string `db:"callback_status"`
+	Attempts  int     `db:"callback_attempts"`
+	LastError *string `db:"callback_last_error"`
+}
+
+type TaskResponse struct {
+	TaskId      int64  `db:"task_id"`
+	ContentType string `db:"content_type"`
+	Body        []byte `db:"body"`
+	Truncated   bool   `db:"truncated"`
 }
 
 type Header struct {
@@ -22,3 +105,16 @@ type Header struct {
 	Value string `db:"header_value" validate:"required"`
 	Input bool   `db:"header_input" validate:"required"`
 }
+
+func (c *Callback) Signature(payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(c.Secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (t *Task) DecodedBody() ([]byte, error) {
+	if t.BodyEncoding == BodyEncodingBase64 {
+		return base64.StdEncoding.DecodeString(t.Body)
+	}
+	return []byte(t.Body), nil
+}
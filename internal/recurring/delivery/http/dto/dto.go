@@ -0,0 +1,46 @@
+package dto
+
+import (
+	taskDto "http-task-executor/internal/tasks/delivery/http/dto"
+	"time"
+)
+
+type NewRecurringTaskRequest struct {
+	Url          string               `json:"url"`
+	Method       string               `json:"method"`
+	Headers      map[string]string    `json:"headers"`
+	Body         string               `json:"body"`
+	BodyEncoding string               `json:"bodyEncoding" enums:"text,json,base64"`
+	Retry        *taskDto.RetryPolicy `json:"retry"`
+	Cron         string               `json:"cron,omitempty" example:"*/5 * * * *"`
+	Interval     string               `json:"interval,omitempty" example:"30s"`
+	Callback     *taskDto.Callback    `json:"callback,omitempty"`
+	TlsProfile   *string              `json:"tlsProfile,omitempty" example:"partner-mtls"`
+}
+
+type NewRecurringTaskResponse struct {
+	Id int64 `json:"id"`
+}
+
+type RecurringTaskResponse struct {
+	ID          int64             `json:"id"`
+	Url         string            `json:"url"`
+	Method      string            `json:"method"`
+	Headers     map[string]string `json:"headers"`
+	Cron        *string           `json:"cron,omitempty"`
+	Interval    string            `json:"interval,omitempty"`
+	Paused      bool              `json:"paused"`
+	NextRunAt   time.Time         `json:"nextRunAt"`
+	LastRunAt   *time.Time        `json:"lastRunAt,omitempty"`
+	CreatedAt   time.Time         `json:"createdAt"`
+	CallbackUrl *string           `json:"callbackUrl,omitempty"`
+	TlsProfile  *string           `json:"tlsProfile,omitempty"`
+}
+
+type RecurringExecutionResponse struct {
+	TaskID         int64     `json:"taskId"`
+	Status         string    `json:"status"`
+	ResponseStatus *int64    `json:"httpStatusCode"`
+	Attempts       int       `json:"attempts"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
@@ -0,0 +1,246 @@
+package http
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"http-task-executor/internal/config"
+	"http-task-executor/internal/logger"
+	"http-task-executor/internal/recurring"
+	"http-task-executor/internal/recurring/delivery/http/dto"
+	"http-task-executor/internal/recurring/mapper"
+	httpErrors "http-task-executor/pkg/errors/http"
+	"net/http"
+	"strconv"
+)
+
+type RecurringTaskHandlers struct {
+	cfg     *config.Config
+	useCase recurring.UseCase
+	logger  logger.Logger
+}
+
+func NewRecurringTaskHandlers(cfg *config.Config, logger logger.Logger, useCase recurring.UseCase) *RecurringTaskHandlers {
+	return &RecurringTaskHandlers{cfg: cfg, logger: logger, useCase: useCase}
+}
+
+// Create godoc
+// @Summary Create recurring task
+// @Description Creates a schedule that spawns a new task execution on every cron or interval tick
+// @Tags RecurringTask
+// @Accept json
+// @Produce json
+// @Param request body dto.NewRecurringTaskRequest true "Recurring task create request"
+// @Success 200 {object} dto.NewRecurringTaskResponse
+// @Failure 413 {object} httpErrors.RestError "Request body exceeds the max payload size"
+// @Security ApiKeyAuth
+// @Router /recurring-task [post]
+func (h *RecurringTaskHandlers) Create() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		h.limitBody(w, r)
+		var request dto.NewRecurringTaskRequest
+		err := render.DecodeJSON(r.Body, &request)
+		if err != nil {
+			h.writeError(w, r, err)
+			return
+		}
+
+		h.logger.Infof("Request body decoded: %s %s", request.Method, request.Url)
+
+		recurringTask, err := mapper.MapRequestToRecurringTask(&request)
+		if err != nil {
+			h.writeError(w, r, err)
+			return
+		}
+		created, err := h.useCase.Create(r.Context(), &recurringTask)
+		if err != nil {
+			h.writeError(w, r, err)
+			return
+		}
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, mapper.MapIdToRecurringTaskResponse(created.Id))
+	}
+}
+
+// Get godoc
+// @Summary Get recurring task by id
+// @Description Get recurring task by id handler
+// @Tags RecurringTask
+// @Accept json
+// @Produce json
+// @Param id path int true "id"
+// @Success 200 {object} dto.RecurringTaskResponse
+// @Security ApiKeyAuth
+// @Router /recurring-task/{id} [get]
+func (h *RecurringTaskHandlers) Get() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := h.parseId(w, r)
+		if !ok {
+			return
+		}
+
+		recurringTask, err := h.useCase.GetById(r.Context(), id)
+		if err != nil {
+			h.writeError(w, r, err)
+			return
+		}
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, mapper.MapRecurringTaskToResponse(recurringTask))
+	}
+}
+
+// List godoc
+// @Summary List recurring tasks
+// @Description Lists every recurring task, paused ones included
+// @Tags RecurringTask
+// @Accept json
+// @Produce json
+// @Success 200 {array} dto.RecurringTaskResponse
+// @Security ApiKeyAuth
+// @Router /recurring-tasks [get]
+func (h *RecurringTaskHandlers) List() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		recurringTasks, err := h.useCase.List(r.Context())
+		if err != nil {
+			h.writeError(w, r, err)
+			return
+		}
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, mapper.MapRecurringTasksToResponse(recurringTasks))
+	}
+}
+
+// Pause godoc
+// @Summary Pause recurring task
+// @Description Stops spawning executions until the recurring task is resumed
+// @Tags RecurringTask
+// @Param id path int true "id"
+// @Success 204
+// @Security ApiKeyAuth
+// @Router /recurring-task/{id}/pause [post]
+func (h *RecurringTaskHandlers) Pause() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := h.parseId(w, r)
+		if !ok {
+			return
+		}
+
+		if err := h.useCase.Pause(r.Context(), id); err != nil {
+			h.writeError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// Resume godoc
+// @Summary Resume recurring task
+// @Description Resumes a paused recurring task from its next tick; missed ticks are not replayed
+// @Tags RecurringTask
+// @Param id path int true "id"
+// @Success 204
+// @Security ApiKeyAuth
+// @Router /recurring-task/{id}/resume [post]
+func (h *RecurringTaskHandlers) Resume() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := h.parseId(w, r)
+		if !ok {
+			return
+		}
+
+		if err := h.useCase.Resume(r.Context(), id); err != nil {
+			h.writeError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// Delete godoc
+// @Summary Delete recurring task
+// @Description Deletes the schedule; tasks it already spawned are kept
+// @Tags RecurringTask
+// @Param id path int true "id"
+// @Success 204
+// @Security ApiKeyAuth
+// @Router /recurring-task/{id} [delete]
+func (h *RecurringTaskHandlers) Delete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := h.parseId(w, r)
+		if !ok {
+			return
+		}
+
+		if err := h.useCase.Delete(r.Context(), id); err != nil {
+			h.writeError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// GetExecutions godoc
+// @Summary Get executions of recurring task
+// @Description Lists the tasks spawned by the recurring task, newest first
+// @Tags RecurringTask
+// @Accept json
+// @Produce json
+// @Param id path int true "id"
+// @Param limit query int false "Page size" default(50)
+// @Success 200 {array} dto.RecurringExecutionResponse
+// @Security ApiKeyAuth
+// @Router /recurring-task/{id}/executions [get]
+func (h *RecurringTaskHandlers) GetExecutions() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := h.parseId(w, r)
+		if !ok {
+			return
+		}
+
+		limit, err := mapper.MapQueryToLimit(r.URL.Query())
+		if err != nil {
+			h.writeError(w, r, err)
+			return
+		}
+
+		executions, err := h.useCase.GetExecutions(r.Context(), id, limit)
+		if err != nil {
+			h.writeError(w, r, err)
+			return
+		}
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, mapper.MapExecutionsToResponse(executions))
+	}
+}
+
+func (h *RecurringTaskHandlers) parseId(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id := chi.URLParam(r, "id")
+
+	idInt, err := strconv.Atoi(id)
+	if err != nil {
+		h.writeError(w, r, err)
+		return 0, false
+	}
+
+	if idInt <= 0 {
+		h.logger.Info("Id must be positive")
+
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, httpErrors.NewRestError(http.StatusBadRequest, "Invalid id", nil))
+		return 0, false
+	}
+	return int64(idInt), true
+}
+
+// limitBody caps the request body at the max payload size, decoding a larger body fails with a 413.
+func (h *RecurringTaskHandlers) limitBody(w http.ResponseWriter, r *http.Request) {
+	if h.cfg != nil && h.cfg.MaxPayloadSize > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, h.cfg.MaxPayloadSize)
+	}
+}
+
+func (h *RecurringTaskHandlers) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	h.logger.Error(err)
+	code, data := httpErrors.ErrorResponse(err)
+	render.Status(r, code)
+	render.JSON(w, r, data)
+}
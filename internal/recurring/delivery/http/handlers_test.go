@@ -0,0 +1,152 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"http-task-executor/internal/models"
+	"http-task-executor/internal/recurring/delivery/http/dto"
+	"http-task-executor/internal/recurring/mock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRecurringTaskHandlers_Create(t *testing.T) {
+	t.Parallel()
+	ctrx := gomock.NewController(t)
+	defer ctrx.Finish()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	mockUseCase := mock.NewMockUseCase(ctrx)
+
+	handlers := NewRecurringTaskHandlers(nil, sugar, mockUseCase)
+
+	t.Run("Create with cron", func(t *testing.T) {
+		input := `{"url": "http://test.com", "method": "GET", "cron": "*/5 * * * *"}`
+
+		request := httptest.NewRequest(http.MethodPost, "/recurring-task", bytes.NewReader([]byte(input)))
+		res := httptest.NewRecorder()
+
+		mockUseCase.EXPECT().Create(gomock.Any(), gomock.Cond(func(x *models.RecurringTask) bool {
+			return x.Cron != nil && *x.Cron == "*/5 * * * *" && x.IntervalMs == nil && x.Task.Url == "http://test.com"
+		})).Return(&models.RecurringTask{Id: 3}, nil)
+
+		handlers.Create().ServeHTTP(res, request)
+
+		var response dto.NewRecurringTaskResponse
+
+		require.Equal(t, http.StatusOK, res.Code)
+		require.NoError(t, json.Unmarshal(res.Body.Bytes(), &response))
+		require.Equal(t, int64(3), response.Id)
+	})
+
+	t.Run("Cron and interval together", func(t *testing.T) {
+		input := `{"url": "http://test.com", "method": "GET", "cron": "*/5 * * * *", "interval": "1m"}`
+
+		request := httptest.NewRequest(http.MethodPost, "/recurring-task", bytes.NewReader([]byte(input)))
+		res := httptest.NewRecorder()
+
+		handlers.Create().ServeHTTP(res, request)
+
+		require.Equal(t, http.StatusBadRequest, res.Code)
+	})
+}
+
+func TestRecurringTaskHandlers_Get(t *testing.T) {
+	t.Parallel()
+	ctrx := gomock.NewController(t)
+	defer ctrx.Finish()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	mockUseCase := mock.NewMockUseCase(ctrx)
+
+	handlers := NewRecurringTaskHandlers(nil, sugar, mockUseCase)
+
+	request := addChiURLParams(httptest.NewRequest(http.MethodGet, "/recurring-task/{id}", nil), map[string]string{"id": "1"})
+	res := httptest.NewRecorder()
+
+	interval := int64(90000)
+	mockUseCase.EXPECT().GetById(gomock.Any(), int64(1)).Return(&models.RecurringTask{
+		Id:         1,
+		Task:       models.Task{Url: "http://test.com", Method: "GET"},
+		IntervalMs: &interval,
+		NextRunAt:  time.Now(),
+	}, nil)
+
+	handlers.Get().ServeHTTP(res, request)
+
+	var response dto.RecurringTaskResponse
+
+	require.Equal(t, http.StatusOK, res.Code)
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), &response))
+	require.Equal(t, "1m30s", response.Interval)
+	require.Nil(t, response.Cron)
+}
+
+func TestRecurringTaskHandlers_PauseNotFound(t *testing.T) {
+	t.Parallel()
+	ctrx := gomock.NewController(t)
+	defer ctrx.Finish()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	mockUseCase := mock.NewMockUseCase(ctrx)
+
+	handlers := NewRecurringTaskHandlers(nil, sugar, mockUseCase)
+
+	request := addChiURLParams(httptest.NewRequest(http.MethodPost, "/recurring-task/{id}/pause", nil), map[string]string{"id": "1"})
+	res := httptest.NewRecorder()
+
+	mockUseCase.EXPECT().Pause(gomock.Any(), int64(1)).Return(sql.ErrNoRows)
+
+	handlers.Pause().ServeHTTP(res, request)
+
+	require.Equal(t, http.StatusNotFound, res.Code)
+}
+
+func TestRecurringTaskHandlers_GetExecutions(t *testing.T) {
+	t.Parallel()
+	ctrx := gomock.NewController(t)
+	defer ctrx.Finish()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	mockUseCase := mock.NewMockUseCase(ctrx)
+
+	handlers := NewRecurringTaskHandlers(nil, sugar, mockUseCase)
+
+	request := addChiURLParams(httptest.NewRequest(http.MethodGet, "/recurring-task/{id}/executions?limit=5", nil), map[string]string{"id": "1"})
+	res := httptest.NewRecorder()
+
+	mockUseCase.EXPECT().GetExecutions(gomock.Any(), int64(1), 5).Return([]models.RecurringExecution{
+		{TaskId: 10, Status: models.StatusDone},
+		{TaskId: 9, Status: models.StatusError},
+	}, nil)
+
+	handlers.GetExecutions().ServeHTTP(res, request)
+
+	var response []dto.RecurringExecutionResponse
+
+	require.Equal(t, http.StatusOK, res.Code)
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), &response))
+	require.Len(t, response, 2)
+	require.Equal(t, int64(10), response[0].TaskID)
+}
+
+func addChiURLParams(r *http.Request, params map[string]string) *http.Request {
+	ctx := chi.NewRouteContext()
+	for k, v := range params {
+		ctx.URLParams.Add(k, v)
+	}
+	return r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, ctx))
+}
@@ -0,0 +1,13 @@
+package http
+
+import "github.com/go-chi/chi/v5"
+
+func MapRecurringTasksRoutes(router chi.Router, handlers *RecurringTaskHandlers) {
+	router.Post("/recurring-task", handlers.Create())
+	router.Get("/recurring-task/{id}", handlers.Get())
+	router.Delete("/recurring-task/{id}", handlers.Delete())
+	router.Post("/recurring-task/{id}/pause", handlers.Pause())
+	router.Post("/recurring-task/{id}/resume", handlers.Resume())
+	router.Get("/recurring-task/{id}/executions", handlers.GetExecutions())
+	router.Get("/recurring-tasks", handlers.List())
+}
@@ -0,0 +1,108 @@
+package mapper
+
+import (
+	"errors"
+	"fmt"
+	"http-task-executor/internal/models"
+	"http-task-executor/internal/recurring/delivery/http/dto"
+	taskDto "http-task-executor/internal/tasks/delivery/http/dto"
+	taskMapper "http-task-executor/internal/tasks/mapper"
+	httpErrors "http-task-executor/pkg/errors/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+func MapRequestToRecurringTask(req *dto.NewRecurringTaskRequest) (models.RecurringTask, error) {
+	recurringTask := models.RecurringTask{}
+	task, err := taskMapper.MapRequestToTask(&taskDto.NewTaskRequest{
+		Url:          req.Url,
+		Method:       req.Method,
+		Headers:      req.Headers,
+		Body:         req.Body,
+		BodyEncoding: req.BodyEncoding,
+		Retry:        req.Retry,
+		Callback:     req.Callback,
+		TlsProfile:   req.TlsProfile,
+	})
+	if err != nil {
+		return recurringTask, err
+	}
+	recurringTask.Task = task
+
+	if req.Cron != "" && req.Interval != "" {
+		return recurringTask, httpErrors.NewBadRequestError(errors.New("cron and interval are mutually exclusive"))
+	}
+	if req.Cron != "" {
+		recurringTask.Cron = &req.Cron
+	}
+	if req.Interval != "" {
+		interval, err := time.ParseDuration(req.Interval)
+		if err != nil {
+			return recurringTask, httpErrors.NewBadRequestError(fmt.Errorf("invalid interval %s", req.Interval))
+		}
+		intervalMs := interval.Milliseconds()
+		recurringTask.IntervalMs = &intervalMs
+	}
+	return recurringTask, nil
+}
+
+func MapIdToRecurringTaskResponse(id int64) dto.NewRecurringTaskResponse {
+	return dto.NewRecurringTaskResponse{Id: id}
+}
+
+func MapRecurringTaskToResponse(recurringTask *models.RecurringTask) dto.RecurringTaskResponse {
+	response := dto.RecurringTaskResponse{
+		ID:         recurringTask.Id,
+		Url:        recurringTask.Task.Url,
+		Method:     recurringTask.Task.Method,
+		Headers:    models.NewHeaderValues(recurringTask.Task.Headers),
+		Cron:       recurringTask.Cron,
+		Paused:     recurringTask.Paused,
+		NextRunAt:  recurringTask.NextRunAt,
+		LastRunAt:  recurringTask.LastRunAt,
+		CreatedAt:  recurringTask.CreatedAt,
+		TlsProfile: recurringTask.Task.TlsProfile,
+	}
+	if recurringTask.Task.Callback != nil {
+		response.CallbackUrl = &recurringTask.Task.Callback.Url
+	}
+	if recurringTask.IntervalMs != nil {
+		response.Interval = (time.Duration(*recurringTask.IntervalMs) * time.Millisecond).String()
+	}
+	return response
+}
+
+func MapRecurringTasksToResponse(recurringTasks []models.RecurringTask) []dto.RecurringTaskResponse {
+	response := make([]dto.RecurringTaskResponse, 0, len(recurringTasks))
+	for i := range recurringTasks {
+		response = append(response, MapRecurringTaskToResponse(&recurringTasks[i]))
+	}
+	return response
+}
+
+func MapExecutionsToResponse(executions []models.RecurringExecution) []dto.RecurringExecutionResponse {
+	response := make([]dto.RecurringExecutionResponse, 0, len(executions))
+	for _, execution := range executions {
+		response = append(response, dto.RecurringExecutionResponse{
+			TaskID:         execution.TaskId,
+			Status:         execution.Status,
+			ResponseStatus: execution.ResponseStatus,
+			Attempts:       execution.Attempts,
+			CreatedAt:      execution.CreatedAt,
+		})
+	}
+	return response
+}
+
+func MapQueryToLimit(query url.Values) (int, error) {
+	value := query.Get("limit")
+	if value == "" {
+		return 0, nil
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, httpErrors.NewBadRequestError(errors.New("invalid limit"))
+	}
+	return limit, nil
+}
@@ -0,0 +1,20 @@
+//go:generate mockgen -source postgres_repository.go -destination mock/postgres_repository.go -package mock
+
+package recurring
+
+import (
+	"context"
+	"http-task-executor/internal/models"
+	"time"
+)
+
+type Repository interface {
+	Create(ctx context.Context, recurringTask *models.RecurringTask) (*models.RecurringTask, error)
+	GetById(ctx context.Context, id int64) (*models.RecurringTask, error)
+	List(ctx context.Context, client *string) ([]models.RecurringTask, error)
+	Pause(ctx context.Context, id int64) error
+	Resume(ctx context.Context, id int64, nextRunAt time.Time) error
+	Delete(ctx context.Context, id int64) error
+	GetExecutions(ctx context.Context, id int64, limit int) ([]models.RecurringExecution, error)
+	ClaimDue(ctx context.Context, now time.Time, limit int) ([]models.RecurringTask, error)
+}
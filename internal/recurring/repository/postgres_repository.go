@@ -0,0 +1,290 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"http-task-executor/internal/logger"
+	"http-task-executor/internal/models"
+	"time"
+)
+
+type RecurringTaskRepository struct {
+	db  *sqlx.DB
+	log logger.Logger
+}
+
+func NewRepository(db *sqlx.DB, log logger.Logger) *RecurringTaskRepository {
+	return &RecurringTaskRepository{db: db, log: log}
+}
+
+func (r *RecurringTaskRepository) Create(ctx context.Context, recurringTask *models.RecurringTask) (*models.RecurringTask, error) {
+	prepareContext, err := r.db.PrepareContext(ctx, `INSERT INTO recurring_task (url, method, headers, body, body_encoding, retry_policy, cron, interval_ms, next_run_at, client, callback_url, callback_secret, tls_profile)
+									VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+									RETURNING id, created_at`)
+	if err != nil {
+		return nil, errors.Wrap(err, "RecurringTaskRepository.Create.PrepareContext")
+	}
+
+	task := recurringTask.Task
+	var callbackUrl, callbackSecret *string
+	if task.Callback != nil {
+		callbackUrl, callbackSecret = &task.Callback.Url, &task.Callback.Secret
+	}
+	err = prepareContext.QueryRowContext(ctx, task.Url, task.Method, models.NewHeaderValues(task.Headers), task.Body, task.BodyEncoding,
+		task.RetryPolicy, recurringTask.Cron, recurringTask.IntervalMs, recurringTask.NextRunAt, recurringTask.Client,
+		callbackUrl, callbackSecret, task.TlsProfile).Scan(&recurringTask.Id, &recurringTask.CreatedAt)
+	if err != nil {
+		return nil, errors.Wrap(err, "RecurringTaskRepository.Create.QueryRowContext")
+	}
+	return recurringTask, nil
+}
+
+func (r *RecurringTaskRepository) GetById(ctx context.Context, id int64) (*models.RecurringTask, error) {
+	prepareContext, err := r.db.PrepareContext(ctx, `SELECT id, url, method, headers, body, body_encoding, retry_policy, cron, interval_ms, paused, next_run_at, last_run_at, created_at, client, callback_url, callback_secret, tls_profile
+									FROM recurring_task
+									WHERE id = $1`)
+	if err != nil {
+		return nil, errors.Wrap(err, "RecurringTaskRepository.GetById.PrepareContext")
+	}
+
+	recurringTask, err := scanRecurringTask(prepareContext.QueryRowContext(ctx, id))
+	if err != nil {
+		return nil, errors.Wrap(err, "RecurringTaskRepository.GetById.Scan")
+	}
+	return recurringTask, nil
+}
+
+// List returns the recurring tasks of the client, every recurring task when client is nil.
+func (r *RecurringTaskRepository) List(ctx context.Context, client *string) ([]models.RecurringTask, error) {
+	prepareContext, err := r.db.PrepareContext(ctx, `SELECT id, url, method, headers, body, body_encoding, retry_policy, cron, interval_ms, paused, next_run_at, last_run_at, created_at, client, callback_url, callback_secret, tls_profile
+									FROM recurring_task
+									WHERE $1::VARCHAR IS NULL OR client = $1
+									ORDER BY id`)
+	if err != nil {
+		return nil, errors.Wrap(err, "RecurringTaskRepository.List.PrepareContext")
+	}
+	rows, err := prepareContext.QueryContext(ctx, client)
+	if err != nil {
+		return nil, errors.Wrap(err, "RecurringTaskRepository.List.QueryContext")
+	}
+
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.log.Errorf("RecurringTaskRepository.List.rows.Close(): %v", err)
+		}
+	}(rows)
+
+	result := make([]models.RecurringTask, 0)
+	for rows.Next() {
+		recurringTask, err := scanRecurringTask(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "RecurringTaskRepository.List.Scan")
+		}
+		result = append(result, *recurringTask)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "RecurringTaskRepository.List.rows.Err")
+	}
+	return result, nil
+}
+
+func (r *RecurringTaskRepository) Pause(ctx context.Context, id int64) error {
+	prepareContext, err := r.db.PrepareContext(ctx, "UPDATE recurring_task SET paused = true WHERE id = $1")
+	if err != nil {
+		return errors.Wrap(err, "RecurringTaskRepository.Pause.PrepareContext")
+	}
+	return r.execAffectingOne(ctx, prepareContext, "RecurringTaskRepository.Pause", id)
+}
+
+func (r *RecurringTaskRepository) Resume(ctx context.Context, id int64, nextRunAt time.Time) error {
+	prepareContext, err := r.db.PrepareContext(ctx, "UPDATE recurring_task SET paused = false, next_run_at = $1 WHERE id = $2")
+	if err != nil {
+		return errors.Wrap(err, "RecurringTaskRepository.Resume.PrepareContext")
+	}
+	return r.execAffectingOne(ctx, prepareContext, "RecurringTaskRepository.Resume", nextRunAt, id)
+}
+
+func (r *RecurringTaskRepository) Delete(ctx context.Context, id int64) error {
+	prepareContext, err := r.db.PrepareContext(ctx, "DELETE FROM recurring_task WHERE id = $1")
+	if err != nil {
+		return errors.Wrap(err, "RecurringTaskRepository.Delete.PrepareContext")
+	}
+	return r.execAffectingOne(ctx, prepareContext, "RecurringTaskRepository.Delete", id)
+}
+
+func (r *RecurringTaskRepository) GetExecutions(ctx context.Context, id int64, limit int) ([]models.RecurringExecution, error) {
+	existsContext, err := r.db.PrepareContext(ctx, "SELECT EXISTS(SELECT 1 FROM recurring_task WHERE id = $1)")
+	if err != nil {
+		return nil, errors.Wrap(err, "RecurringTaskRepository.GetExecutions.Exists.PrepareContext")
+	}
+	var exists bool
+	err = existsContext.QueryRowContext(ctx, id).Scan(&exists)
+	if err != nil {
+		return nil, errors.Wrap(err, "RecurringTaskRepository.GetExecutions.Exists.QueryRowContext")
+	}
+	if !exists {
+		return nil, sql.ErrNoRows
+	}
+
+	prepareContext, err := r.db.PrepareContext(ctx, `SELECT id, status, response_status_code, attempts, created_at
+									FROM task
+									WHERE recurring_task_id = $1
+									ORDER BY id DESC
+									LIMIT $2`)
+	if err != nil {
+		return nil, errors.Wrap(err, "RecurringTaskRepository.GetExecutions.PrepareContext")
+	}
+	rows, err := prepareContext.QueryContext(ctx, id, limit)
+	if err != nil {
+		return nil, errors.Wrap(err, "RecurringTaskRepository.GetExecutions.QueryContext")
+	}
+
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.log.Errorf("RecurringTaskRepository.GetExecutions.rows.Close(): %v", err)
+		}
+	}(rows)
+
+	executions := make([]models.RecurringExecution, 0)
+	for rows.Next() {
+		execution := models.RecurringExecution{}
+		err = rows.Scan(&execution.TaskId, &execution.Status, &execution.ResponseStatus, &execution.Attempts, &execution.CreatedAt)
+		if err != nil {
+			return nil, errors.Wrap(err, "RecurringTaskRepository.GetExecutions.Scan")
+		}
+		executions = append(executions, execution)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "RecurringTaskRepository.GetExecutions.rows.Err")
+	}
+	return executions, nil
+}
+
+// ClaimDue returns every active recurring task whose next run is due, moving its next run
+// forward, so the caller creates the tasks for this tick. Missed ticks are skipped rather than replayed.
+func (r *RecurringTaskRepository) ClaimDue(ctx context.Context, now time.Time, limit int) ([]models.RecurringTask, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "RecurringTaskRepository.ClaimDue.BeginTx")
+	}
+
+	due, err := r.lockDue(ctx, tx, now, limit)
+	if err != nil {
+		err1 := tx.Rollback()
+		if err1 != nil {
+			return nil, errors.Wrap(err1, "RecurringTaskRepository.ClaimDue.lockDue.Rollback")
+		}
+		return nil, errors.Wrap(err, "RecurringTaskRepository.ClaimDue.lockDue")
+	}
+
+	for _, recurringTask := range due {
+		paused := false
+		nextRunAt, err := recurringTask.NextRun(now)
+		if err != nil {
+			r.log.Errorf("RecurringTaskRepository.ClaimDue: pausing recurring task %v: %v", recurringTask.Id, err)
+			paused = true
+			nextRunAt = recurringTask.NextRunAt
+		}
+		err = advance(ctx, tx, recurringTask.Id, nextRunAt, now, paused)
+		if err != nil {
+			err1 := tx.Rollback()
+			if err1 != nil {
+				return nil, errors.Wrap(err1, "RecurringTaskRepository.ClaimDue.advance.Rollback")
+			}
+			return nil, errors.Wrap(err, "RecurringTaskRepository.ClaimDue.advance")
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, errors.Wrap(err, "RecurringTaskRepository.ClaimDue.Commit")
+	}
+	return due, nil
+}
+
+func (r *RecurringTaskRepository) lockDue(ctx context.Context, tx *sql.Tx, now time.Time, limit int) ([]models.RecurringTask, error) {
+	prepare, err := tx.PrepareContext(ctx, `SELECT id, url, method, headers, body, body_encoding, retry_policy, cron, interval_ms, paused, next_run_at, last_run_at, created_at, client, callback_url, callback_secret, tls_profile
+									FROM recurring_task
+									WHERE NOT paused AND next_run_at <= $1
+									ORDER BY next_run_at
+									LIMIT $2
+									FOR UPDATE SKIP LOCKED`)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := prepare.QueryContext(ctx, now, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.log.Errorf("RecurringTaskRepository.lockDue.rows.Close(): %v", err)
+		}
+	}(rows)
+
+	due := make([]models.RecurringTask, 0)
+	for rows.Next() {
+		recurringTask, err := scanRecurringTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		due = append(due, *recurringTask)
+	}
+	return due, rows.Err()
+}
+
+func (r *RecurringTaskRepository) execAffectingOne(ctx context.Context, stmt *sql.Stmt, op string, args ...interface{}) error {
+	result, err := stmt.ExecContext(ctx, args...)
+	if err != nil {
+		return errors.Wrap(err, op+".ExecContext")
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, op+".RowsAffected")
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func advance(ctx context.Context, tx *sql.Tx, id int64, nextRunAt time.Time, lastRunAt time.Time, paused bool) error {
+	prepare, err := tx.PrepareContext(ctx, "UPDATE recurring_task SET next_run_at = $1, last_run_at = $2, paused = $3 WHERE id = $4")
+	if err != nil {
+		return err
+	}
+	_, err = prepare.ExecContext(ctx, nextRunAt, lastRunAt, paused, id)
+	return err
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanRecurringTask(row scanner) (*models.RecurringTask, error) {
+	recurringTask := &models.RecurringTask{}
+	headers := models.HeaderValues{}
+	var callbackUrl, callbackSecret *string
+	err := row.Scan(&recurringTask.Id, &recurringTask.Task.Url, &recurringTask.Task.Method, &headers, &recurringTask.Task.Body,
+		&recurringTask.Task.BodyEncoding, &recurringTask.Task.RetryPolicy, &recurringTask.Cron, &recurringTask.IntervalMs,
+		&recurringTask.Paused, &recurringTask.NextRunAt, &recurringTask.LastRunAt, &recurringTask.CreatedAt, &recurringTask.Client,
+		&callbackUrl, &callbackSecret, &recurringTask.Task.TlsProfile)
+	if err != nil {
+		return nil, err
+	}
+	recurringTask.Task.Headers = headers.Headers()
+	if callbackUrl != nil {
+		recurringTask.Task.Callback = &models.Callback{Url: *callbackUrl}
+		if callbackSecret != nil {
+			recurringTask.Task.Callback.Secret = *callbackSecret
+		}
+	}
+	return recurringTask, nil
+}
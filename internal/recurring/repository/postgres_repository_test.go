@@ -0,0 +1,270 @@
+package repository
+
+import (
+	"context"
+	dbSql "database/sql"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"http-task-executor/internal/models"
+	"testing"
+	"time"
+)
+
+var columns = []string{"id", "url", "method", "headers", "body", "body_encoding", "retry_policy", "cron", "interval_ms", "paused", "next_run_at", "last_run_at", "created_at", "client", "callback_url", "callback_secret", "tls_profile"}
+
+func TestRecurringTaskRepo_Create(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+
+	sqlxDb := sqlx.NewDb(db, "sqlmock")
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	repo := NewRepository(sqlxDb, sugar)
+
+	sql := `INSERT INTO recurring_task (url, method, headers, body, body_encoding, retry_policy, cron, interval_ms, next_run_at, client, callback_url, callback_secret, tls_profile)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+			RETURNING id, created_at`
+
+	cron := "*/5 * * * *"
+	nextRunAt := time.Now().Add(time.Minute)
+	createdAt := time.Now()
+	client := "acme"
+	tlsProfile := "partner-mtls"
+	recurringTask := &models.RecurringTask{
+		Task: models.Task{
+			Url:        "https://www.google.com",
+			Method:     "GET",
+			Headers:    []models.Header{{Name: "TEST_NAME", Value: "TEST_VALUE", Input: true}},
+			Callback:   &models.Callback{Url: "https://example.com/hook", Secret: "secret"},
+			TlsProfile: &tlsProfile,
+		},
+		Cron:      &cron,
+		NextRunAt: nextRunAt,
+		Client:    &client,
+	}
+
+	mock.ExpectPrepare(sql)
+	mock.ExpectQuery(sql).WithArgs("https://www.google.com", "GET", models.HeaderValues{"TEST_NAME": "TEST_VALUE"}, "", "", nil, &cron, nil, nextRunAt, &client, "https://example.com/hook", "secret", &tlsProfile).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(1, createdAt))
+
+	created, err := repo.Create(context.Background(), recurringTask)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), created.Id)
+	assert.Equal(t, createdAt, created.CreatedAt)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRecurringTaskRepo_GetById(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+
+	sqlxDb := sqlx.NewDb(db, "sqlmock")
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	repo := NewRepository(sqlxDb, sugar)
+
+	sql := `SELECT id, url, method, headers, body, body_encoding, retry_policy, cron, interval_ms, paused, next_run_at, last_run_at, created_at, client, callback_url, callback_secret, tls_profile
+			FROM recurring_task
+			WHERE id = $1`
+
+	t.Run("Found", func(t *testing.T) {
+		now := time.Now()
+		mock.ExpectPrepare(sql)
+		mock.ExpectQuery(sql).WithArgs(int64(1)).WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(1, "https://www.google.com", "GET", []byte(`{"B":"2","A":"1"}`), "", "", nil, nil, 30000, true, now, nil, now, "acme", "https://example.com/hook", "secret", "partner-mtls"))
+
+		recurringTask, err := repo.GetById(context.Background(), 1)
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(30000), *recurringTask.IntervalMs)
+		assert.Nil(t, recurringTask.Cron)
+		assert.True(t, recurringTask.Paused)
+		assert.Equal(t, "acme", *recurringTask.Client)
+		assert.Equal(t, &models.Callback{Url: "https://example.com/hook", Secret: "secret"}, recurringTask.Task.Callback)
+		assert.Equal(t, "partner-mtls", *recurringTask.Task.TlsProfile)
+		assert.Equal(t, []models.Header{{Name: "A", Value: "1", Input: true}, {Name: "B", Value: "2", Input: true}}, recurringTask.Task.Headers)
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		mock.ExpectPrepare(sql)
+		mock.ExpectQuery(sql).WithArgs(int64(2)).WillReturnRows(sqlmock.NewRows(columns))
+
+		_, err := repo.GetById(context.Background(), 2)
+
+		require.ErrorIs(t, err, dbSql.ErrNoRows)
+	})
+}
+
+func TestRecurringTaskRepo_List(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+
+	sqlxDb := sqlx.NewDb(db, "sqlmock")
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	repo := NewRepository(sqlxDb, sugar)
+
+	sql := `SELECT id, url, method, headers, body, body_encoding, retry_policy, cron, interval_ms, paused, next_run_at, last_run_at, created_at, client, callback_url, callback_secret, tls_profile
+			FROM recurring_task
+			WHERE $1::VARCHAR IS NULL OR client = $1
+			ORDER BY id`
+
+	now := time.Now()
+	client := "acme"
+	mock.ExpectPrepare(sql)
+	mock.ExpectQuery(sql).WithArgs(&client).WillReturnRows(sqlmock.NewRows(columns).
+		AddRow(1, "https://www.google.com", "GET", []byte(`{}`), "", "", nil, nil, 30000, false, now, nil, now, "acme", nil, nil, nil))
+
+	recurringTasks, err := repo.List(context.Background(), &client)
+
+	require.NoError(t, err)
+	require.Len(t, recurringTasks, 1)
+	assert.Equal(t, "acme", *recurringTasks[0].Client)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRecurringTaskRepo_PauseResumeDelete(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+
+	sqlxDb := sqlx.NewDb(db, "sqlmock")
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	repo := NewRepository(sqlxDb, sugar)
+
+	t.Run("Pause", func(t *testing.T) {
+		sql := "UPDATE recurring_task SET paused = true WHERE id = $1"
+		mock.ExpectPrepare(sql)
+		mock.ExpectExec(sql).WithArgs(int64(1)).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		require.NoError(t, repo.Pause(context.Background(), 1))
+	})
+
+	t.Run("Resume", func(t *testing.T) {
+		nextRunAt := time.Now()
+		sql := "UPDATE recurring_task SET paused = false, next_run_at = $1 WHERE id = $2"
+		mock.ExpectPrepare(sql)
+		mock.ExpectExec(sql).WithArgs(nextRunAt, int64(1)).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		require.NoError(t, repo.Resume(context.Background(), 1, nextRunAt))
+	})
+
+	t.Run("Delete missing", func(t *testing.T) {
+		sql := "DELETE FROM recurring_task WHERE id = $1"
+		mock.ExpectPrepare(sql)
+		mock.ExpectExec(sql).WithArgs(int64(1)).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		require.ErrorIs(t, repo.Delete(context.Background(), 1), dbSql.ErrNoRows)
+	})
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRecurringTaskRepo_GetExecutions(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+
+	sqlxDb := sqlx.NewDb(db, "sqlmock")
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	repo := NewRepository(sqlxDb, sugar)
+
+	existsSql := "SELECT EXISTS(SELECT 1 FROM recurring_task WHERE id = $1)"
+	sql := `SELECT id, status, response_status_code, attempts, created_at
+			FROM task
+			WHERE recurring_task_id = $1
+			ORDER BY id DESC
+			LIMIT $2`
+
+	t.Run("Executions", func(t *testing.T) {
+		now := time.Now()
+		mock.ExpectPrepare(existsSql)
+		mock.ExpectQuery(existsSql).WithArgs(int64(1)).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		mock.ExpectPrepare(sql)
+		mock.ExpectQuery(sql).WithArgs(int64(1), 10).WillReturnRows(sqlmock.NewRows([]string{"id", "status", "response_status_code", "attempts", "created_at"}).
+			AddRow(7, models.StatusDone, 200, 1, now).
+			AddRow(5, models.StatusNew, nil, 0, now))
+
+		executions, err := repo.GetExecutions(context.Background(), 1, 10)
+
+		require.NoError(t, err)
+		require.Len(t, executions, 2)
+		assert.Equal(t, int64(7), executions[0].TaskId)
+		assert.Equal(t, int64(200), *executions[0].ResponseStatus)
+		assert.Nil(t, executions[1].ResponseStatus)
+	})
+
+	t.Run("Recurring task not found", func(t *testing.T) {
+		mock.ExpectPrepare(existsSql)
+		mock.ExpectQuery(existsSql).WithArgs(int64(2)).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+		_, err := repo.GetExecutions(context.Background(), 2, 10)
+
+		require.ErrorIs(t, err, dbSql.ErrNoRows)
+	})
+}
+
+func TestRecurringTaskRepo_ClaimDue(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+
+	sqlxDb := sqlx.NewDb(db, "sqlmock")
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	repo := NewRepository(sqlxDb, sugar)
+
+	dueSql := `SELECT id, url, method, headers, body, body_encoding, retry_policy, cron, interval_ms, paused, next_run_at, last_run_at, created_at, client, callback_url, callback_secret, tls_profile
+			FROM recurring_task
+			WHERE NOT paused AND next_run_at <= $1
+			ORDER BY next_run_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED`
+	advanceSql := "UPDATE recurring_task SET next_run_at = $1, last_run_at = $2, paused = $3 WHERE id = $4"
+
+	now := time.Date(2026, 10, 17, 10, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare(dueSql)
+	mock.ExpectQuery(dueSql).WithArgs(now, 10).WillReturnRows(sqlmock.NewRows(columns).
+		AddRow(1, "https://www.google.com", "GET", []byte(`{"TEST_NAME":"TEST_VALUE"}`), "", "", nil, nil, 60000, false, now, nil, now, "acme", "https://example.com/hook", "secret", "partner-mtls").
+		AddRow(2, "https://www.google.com", "POST", []byte(`{}`), "", "", nil, "broken", nil, false, now, nil, now, nil, nil, nil, nil))
+	mock.ExpectPrepare(advanceSql)
+	mock.ExpectExec(advanceSql).WithArgs(now.Add(time.Minute), now, false, int64(1)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectPrepare(advanceSql)
+	mock.ExpectExec(advanceSql).WithArgs(now, now, true, int64(2)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	due, err := repo.ClaimDue(context.Background(), now, 10)
+
+	require.NoError(t, err)
+	require.Len(t, due, 2)
+	assert.Equal(t, "acme", *due[0].Client)
+	assert.Equal(t, &models.Callback{Url: "https://example.com/hook", Secret: "secret"}, due[0].Task.Callback)
+	assert.Equal(t, "partner-mtls", *due[0].Task.TlsProfile)
+	assert.Equal(t, []models.Header{{Name: "TEST_NAME", Value: "TEST_VALUE", Input: true}}, due[0].Task.Headers)
+	assert.Nil(t, due[1].Task.Callback)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
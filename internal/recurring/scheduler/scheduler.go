@@ -0,0 +1,98 @@
+package scheduler
+
+import (
+	"context"
+	"http-task-executor/internal/auth"
+	"http-task-executor/internal/config"
+	"http-task-executor/internal/logger"
+	"http-task-executor/internal/models"
+	"http-task-executor/internal/recurring"
+	"http-task-executor/internal/tasks"
+	"sync"
+	"time"
+)
+
+// Scheduler periodically turns due recurring tasks into regular tasks. They are created
+// through the task use case on behalf of the owner of the recurring task, the same way
+// as tasks created through the API.
+type Scheduler struct {
+	log          logger.Logger
+	repo         recurring.Repository
+	tasks        tasks.UseCase
+	pollInterval time.Duration
+	batchSize    int
+	wg           sync.WaitGroup
+}
+
+func NewScheduler(log logger.Logger, repo recurring.Repository, tasks tasks.UseCase, cfg config.SchedulerConfig) *Scheduler {
+	pollInterval := cfg.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = time.Second
+	}
+	batchSize := cfg.BatchSize
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+	return &Scheduler{log: log, repo: repo, tasks: tasks, pollInterval: pollInterval, batchSize: batchSize}
+}
+
+func (s *Scheduler) Start(ctx context.Context) {
+	s.log.Infof("Starting recurring task scheduler with poll interval %s", s.pollInterval)
+	s.wg.Add(1)
+	go s.run(ctx)
+}
+
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
+
+func (s *Scheduler) run(ctx context.Context) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		s.tick(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) tick(ctx context.Context) {
+	for {
+		due, err := s.repo.ClaimDue(ctx, time.Now(), s.batchSize)
+		if err != nil {
+			if ctx.Err() == nil {
+				s.log.Errorf("Scheduler.tick.ClaimDue : %v", err)
+			}
+			return
+		}
+		for i := range due {
+			s.spawn(ctx, &due[i])
+		}
+		if len(due) < s.batchSize {
+			return
+		}
+	}
+}
+
+// spawn creates the task of a tick. The tick is already claimed, a task which can't be created is
+// logged and skipped like a missed tick.
+func (s *Scheduler) spawn(ctx context.Context, recurringTask *models.RecurringTask) {
+	task := recurringTask.Task
+	task.Status = models.StatusNew
+	task.RecurringTaskId = &recurringTask.Id
+	if recurringTask.Client != nil {
+		ctx = auth.WithClient(ctx, *recurringTask.Client)
+	}
+
+	_, err := s.tasks.Create(ctx, &task)
+	if err != nil {
+		s.log.Errorf("Scheduler.spawn.Create: recurring task %v : %v", recurringTask.Id, err)
+	}
+}
@@ -0,0 +1,75 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"http-task-executor/internal/auth"
+	"http-task-executor/internal/config"
+	"http-task-executor/internal/models"
+	"http-task-executor/internal/recurring/mock"
+	taskMock "http-task-executor/internal/tasks/mock"
+	"testing"
+	"time"
+)
+
+func TestScheduler_CreatesDueTasks(t *testing.T) {
+	t.Parallel()
+	ctrx := gomock.NewController(t)
+	defer ctrx.Finish()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	mockRepo := mock.NewMockRepository(ctrx)
+	mockUseCase := taskMock.NewMockUseCase(ctrx)
+
+	scheduler := NewScheduler(sugar, mockRepo, mockUseCase, config.SchedulerConfig{PollInterval: time.Hour, BatchSize: 2})
+
+	client := "acme"
+	tlsProfile := "partner-mtls"
+	template := models.Task{Url: "https://www.google.com", Method: "GET", Callback: &models.Callback{Url: "https://example.com/hook", Secret: "secret"}, TlsProfile: &tlsProfile}
+	gomock.InOrder(
+		mockRepo.EXPECT().ClaimDue(gomock.Any(), gomock.Any(), 2).Return([]models.RecurringTask{{Id: 1, Task: template, Client: &client}, {Id: 2, Task: template}}, nil),
+		mockRepo.EXPECT().ClaimDue(gomock.Any(), gomock.Any(), 2).Return([]models.RecurringTask{{Id: 3, Task: template}}, nil),
+	)
+
+	mockUseCase.EXPECT().Create(gomock.Cond(func(ctx context.Context) bool {
+		owner := auth.ClientFromContext(ctx)
+		return owner != nil && *owner == client
+	}), gomock.Cond(func(task *models.Task) bool {
+		return *task.RecurringTaskId == 1 && task.Status == models.StatusNew && task.Callback.Url == "https://example.com/hook" && *task.TlsProfile == tlsProfile
+	})).Return(&models.Task{Id: 10}, nil).Times(1)
+	mockUseCase.EXPECT().Create(gomock.Cond(func(ctx context.Context) bool {
+		return auth.ClientFromContext(ctx) == nil
+	}), gomock.Cond(func(task *models.Task) bool {
+		return *task.RecurringTaskId != 1
+	})).Return(nil, errors.New("egress denied")).Times(1)
+	mockUseCase.EXPECT().Create(gomock.Any(), gomock.Cond(func(task *models.Task) bool {
+		return *task.RecurringTaskId == 3
+	})).Return(&models.Task{Id: 11}, nil).Times(1)
+
+	scheduler.tick(context.Background())
+}
+
+func TestScheduler_StopsOnError(t *testing.T) {
+	t.Parallel()
+	ctrx := gomock.NewController(t)
+	defer ctrx.Finish()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	mockRepo := mock.NewMockRepository(ctrx)
+	mockUseCase := taskMock.NewMockUseCase(ctrx)
+
+	scheduler := NewScheduler(sugar, mockRepo, mockUseCase, config.SchedulerConfig{PollInterval: time.Hour, BatchSize: 2})
+
+	mockRepo.EXPECT().ClaimDue(gomock.Any(), gomock.Any(), 2).Return(nil, errors.New("error")).Times(1)
+	mockUseCase.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	scheduler.Start(ctx)
+	cancel()
+	scheduler.Wait()
+}
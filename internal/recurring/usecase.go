@@ -0,0 +1,17 @@
+//go:generate mockgen -source usecase.go -destination mock/usecase.go -package mock
+package recurring
+
+import (
+	"context"
+	"http-task-executor/internal/models"
+)
+
+type UseCase interface {
+	Create(ctx context.Context, recurringTask *models.RecurringTask) (*models.RecurringTask, error)
+	GetById(ctx context.Context, id int64) (*models.RecurringTask, error)
+	List(ctx context.Context) ([]models.RecurringTask, error)
+	Pause(ctx context.Context, id int64) error
+	Resume(ctx context.Context, id int64) error
+	Delete(ctx context.Context, id int64) error
+	GetExecutions(ctx context.Context, id int64, limit int) ([]models.RecurringExecution, error)
+}
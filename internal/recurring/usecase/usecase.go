@@ -0,0 +1,126 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/pkg/errors"
+	"http-task-executor/internal/auth"
+	"http-task-executor/internal/logger"
+	"http-task-executor/internal/models"
+	"http-task-executor/internal/recurring"
+	"http-task-executor/internal/tasks"
+	httpErrors "http-task-executor/pkg/errors/http"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultExecutionsLimit = 50
+	maxExecutionsLimit     = 500
+)
+
+type RecurringTaskUseCase struct {
+	log   logger.Logger
+	repo  recurring.Repository
+	tasks tasks.UseCase
+}
+
+func NewRecurringTaskUseCase(log logger.Logger, repo recurring.Repository, tasks tasks.UseCase) *RecurringTaskUseCase {
+	return &RecurringTaskUseCase{log: log, repo: repo, tasks: tasks}
+}
+
+func (r *RecurringTaskUseCase) Create(ctx context.Context, recurringTask *models.RecurringTask) (*models.RecurringTask, error) {
+	err := r.tasks.Validate(ctx, &recurringTask.Task)
+	if err != nil {
+		return nil, err
+	}
+
+	nextRunAt, err := recurringTask.NextRun(time.Now())
+	if err != nil {
+		return nil, httpErrors.NewRestError(http.StatusBadRequest, err.Error(), err)
+	}
+	recurringTask.NextRunAt = nextRunAt
+	recurringTask.Client = auth.ClientFromContext(ctx)
+
+	return r.repo.Create(ctx, recurringTask)
+}
+
+func (r *RecurringTaskUseCase) GetById(ctx context.Context, id int64) (*models.RecurringTask, error) {
+	if id <= 0 {
+		return nil, httpErrors.NewBadRequestError(errors.New("invalid id"))
+	}
+	recurringTask, err := r.repo.GetById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !auth.Owns(auth.ClientFromContext(ctx), recurringTask.Client) {
+		return nil, sql.ErrNoRows
+	}
+	return recurringTask, nil
+}
+
+func (r *RecurringTaskUseCase) List(ctx context.Context) ([]models.RecurringTask, error) {
+	return r.repo.List(ctx, auth.ClientFromContext(ctx))
+}
+
+func (r *RecurringTaskUseCase) Pause(ctx context.Context, id int64) error {
+	if id <= 0 {
+		return httpErrors.NewBadRequestError(errors.New("invalid id"))
+	}
+	err := r.authorize(ctx, id)
+	if err != nil {
+		return err
+	}
+	return r.repo.Pause(ctx, id)
+}
+
+func (r *RecurringTaskUseCase) Resume(ctx context.Context, id int64) error {
+	recurringTask, err := r.GetById(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	nextRunAt, err := recurringTask.NextRun(time.Now())
+	if err != nil {
+		return err
+	}
+	return r.repo.Resume(ctx, id, nextRunAt)
+}
+
+func (r *RecurringTaskUseCase) Delete(ctx context.Context, id int64) error {
+	if id <= 0 {
+		return httpErrors.NewBadRequestError(errors.New("invalid id"))
+	}
+	err := r.authorize(ctx, id)
+	if err != nil {
+		return err
+	}
+	return r.repo.Delete(ctx, id)
+}
+
+func (r *RecurringTaskUseCase) GetExecutions(ctx context.Context, id int64, limit int) ([]models.RecurringExecution, error) {
+	if id <= 0 {
+		return nil, httpErrors.NewBadRequestError(errors.New("invalid id"))
+	}
+	if limit == 0 {
+		limit = defaultExecutionsLimit
+	}
+	if limit < 0 || limit > maxExecutionsLimit {
+		return nil, httpErrors.NewBadRequestError(fmt.Errorf("limit must be between 1 and %d", maxExecutionsLimit))
+	}
+	err := r.authorize(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return r.repo.GetExecutions(ctx, id, limit)
+}
+
+// authorize hides the recurring tasks of other clients behind not found, the same way tasks are hidden.
+func (r *RecurringTaskUseCase) authorize(ctx context.Context, id int64) error {
+	if auth.ClientFromContext(ctx) == nil {
+		return nil
+	}
+	_, err := r.GetById(ctx, id)
+	return err
+}
@@ -0,0 +1,180 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"http-task-executor/internal/auth"
+	"http-task-executor/internal/models"
+	"http-task-executor/internal/recurring/mock"
+	taskMock "http-task-executor/internal/tasks/mock"
+	errorsHttp "http-task-executor/pkg/errors/http"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRecurringTaskUseCase_Create(t *testing.T) {
+	t.Parallel()
+	ctrx := gomock.NewController(t)
+	defer ctrx.Finish()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	mockRepo := mock.NewMockRepository(ctrx)
+	mockTasks := taskMock.NewMockUseCase(ctrx)
+
+	useCase := NewRecurringTaskUseCase(sugar, mockRepo, mockTasks)
+
+	ctx := context.Background()
+
+	t.Run("Create with interval", func(t *testing.T) {
+		interval := int64(60000)
+		recurringTask := &models.RecurringTask{
+			Task:       models.Task{Method: "GET", Url: "https://www.google.com", Status: models.StatusNew},
+			IntervalMs: &interval,
+		}
+
+		mockTasks.EXPECT().Validate(ctx, &recurringTask.Task).Return(nil)
+		mockRepo.EXPECT().Create(ctx, gomock.Cond(func(x *models.RecurringTask) bool {
+			return x.NextRunAt.After(time.Now().Add(59 * time.Second))
+		})).Return(recurringTask, nil)
+
+		created, err := useCase.Create(ctx, recurringTask)
+
+		require.NoError(t, err)
+		require.NotNil(t, created)
+	})
+
+	t.Run("Invalid cron", func(t *testing.T) {
+		cron := "every minute"
+		recurringTask := &models.RecurringTask{
+			Task: models.Task{Method: "GET", Url: "https://www.google.com", Status: models.StatusNew},
+			Cron: &cron,
+		}
+
+		mockTasks.EXPECT().Validate(ctx, &recurringTask.Task).Return(nil)
+		mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
+
+		_, err := useCase.Create(ctx, recurringTask)
+
+		require.Error(t, err)
+		require.Equal(t, http.StatusBadRequest, err.(errorsHttp.RestError).ErrStatus)
+	})
+
+	t.Run("Invalid task template", func(t *testing.T) {
+		recurringTask := &models.RecurringTask{Task: models.Task{Method: "GET"}}
+
+		mockTasks.EXPECT().Validate(ctx, &recurringTask.Task).Return(errorsHttp.NewBadRequestError(nil))
+		mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
+
+		_, err := useCase.Create(ctx, recurringTask)
+
+		require.Error(t, err)
+	})
+}
+
+func TestRecurringTaskUseCase_Resume(t *testing.T) {
+	t.Parallel()
+	ctrx := gomock.NewController(t)
+	defer ctrx.Finish()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	mockRepo := mock.NewMockRepository(ctrx)
+	mockTasks := taskMock.NewMockUseCase(ctrx)
+
+	useCase := NewRecurringTaskUseCase(sugar, mockRepo, mockTasks)
+
+	ctx := context.Background()
+	cron := "0 * * * *"
+
+	mockRepo.EXPECT().GetById(ctx, int64(1)).Return(&models.RecurringTask{Id: 1, Cron: &cron, Paused: true}, nil)
+	mockRepo.EXPECT().Resume(ctx, int64(1), gomock.Cond(func(x time.Time) bool {
+		return x.After(time.Now()) && x.Minute() == 0
+	})).Return(nil)
+
+	require.NoError(t, useCase.Resume(ctx, 1))
+}
+
+func TestRecurringTaskUseCase_GetExecutions(t *testing.T) {
+	t.Parallel()
+	ctrx := gomock.NewController(t)
+	defer ctrx.Finish()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	mockRepo := mock.NewMockRepository(ctrx)
+	mockTasks := taskMock.NewMockUseCase(ctrx)
+
+	useCase := NewRecurringTaskUseCase(sugar, mockRepo, mockTasks)
+
+	ctx := context.Background()
+
+	mockRepo.EXPECT().GetExecutions(ctx, int64(1), defaultExecutionsLimit).Return([]models.RecurringExecution{}, nil)
+
+	executions, err := useCase.GetExecutions(ctx, 1, 0)
+	require.NoError(t, err)
+	require.Empty(t, executions)
+
+	_, err = useCase.GetExecutions(ctx, 1, maxExecutionsLimit+1)
+	require.Error(t, err)
+	require.Equal(t, http.StatusBadRequest, err.(errorsHttp.RestError).ErrStatus)
+}
+
+func TestRecurringTaskUseCase_Ownership(t *testing.T) {
+	t.Parallel()
+	ctrx := gomock.NewController(t)
+	defer ctrx.Finish()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	mockRepo := mock.NewMockRepository(ctrx)
+	mockTasks := taskMock.NewMockUseCase(ctrx)
+
+	useCase := NewRecurringTaskUseCase(sugar, mockRepo, mockTasks)
+
+	ctx := auth.WithClient(context.Background(), "acme")
+	owner := "acme"
+	other := "other"
+
+	t.Run("Create stores the client", func(t *testing.T) {
+		interval := int64(60000)
+		recurringTask := &models.RecurringTask{Task: models.Task{Method: "GET", Url: "https://www.google.com"}, IntervalMs: &interval}
+
+		mockTasks.EXPECT().Validate(ctx, &recurringTask.Task).Return(nil)
+		mockRepo.EXPECT().Create(ctx, gomock.Cond(func(x *models.RecurringTask) bool {
+			return x.Client != nil && *x.Client == owner
+		})).Return(recurringTask, nil)
+
+		_, err := useCase.Create(ctx, recurringTask)
+		require.NoError(t, err)
+	})
+
+	t.Run("List is scoped to the client", func(t *testing.T) {
+		mockRepo.EXPECT().List(ctx, &owner).Return([]models.RecurringTask{}, nil)
+
+		_, err := useCase.List(ctx)
+		require.NoError(t, err)
+	})
+
+	t.Run("Other client's recurring task is not found", func(t *testing.T) {
+		mockRepo.EXPECT().GetById(ctx, int64(3)).Return(&models.RecurringTask{Id: 3, Client: &other}, nil).Times(2)
+		mockRepo.EXPECT().Delete(gomock.Any(), gomock.Any()).Times(0)
+		mockRepo.EXPECT().GetExecutions(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+		require.ErrorIs(t, useCase.Delete(ctx, 3), sql.ErrNoRows)
+		_, err := useCase.GetExecutions(ctx, 3, 10)
+		require.ErrorIs(t, err, sql.ErrNoRows)
+	})
+
+	t.Run("Own recurring task is paused", func(t *testing.T) {
+		mockRepo.EXPECT().GetById(ctx, int64(4)).Return(&models.RecurringTask{Id: 4, Client: &owner}, nil)
+		mockRepo.EXPECT().Pause(ctx, int64(4)).Return(nil)
+
+		require.NoError(t, useCase.Pause(ctx, 4))
+	})
+}
@@ -0,0 +1,175 @@
+package callback
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"http-task-executor/internal/config"
+	"http-task-executor/internal/logger"
+	"http-task-executor/internal/models"
+	"http-task-executor/internal/tasks"
+	"http-task-executor/internal/tasks/mapper"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	SignatureHeader = "X-Signature-256"
+	TaskIdHeader    = "X-Task-Id"
+)
+
+// Dispatcher delivers the final state of tasks to their callback URLs. Deliveries
+// are claimed from the database by a fixed set of workers, so a failed or interrupted
+// delivery is retried with backoff until the configured number of attempts is exhausted.
+type Dispatcher struct {
+	log            logger.Logger
+	workers        int
+	repo           tasks.Repository
+	clientProvider tasks.ClientProvider
+	pollInterval   time.Duration
+	timeout        time.Duration
+	policy         *models.RetryPolicy
+	wg             sync.WaitGroup
+}
+
+func NewDispatcher(log logger.Logger, repo tasks.Repository, clientProvider tasks.ClientProvider, cfg config.CallbackConfig) *Dispatcher {
+	workers := cfg.Workers
+	if workers <= 0 {
+		workers = 1
+	}
+	pollInterval := cfg.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = time.Second
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	return &Dispatcher{
+		log:            log,
+		workers:        workers,
+		repo:           repo,
+		clientProvider: clientProvider,
+		pollInterval:   pollInterval,
+		timeout:        timeout,
+		policy: &models.RetryPolicy{
+			MaxAttempts:   cfg.MaxAttempts,
+			BackoffBaseMs: cfg.BackoffBase.Milliseconds(),
+			BackoffMaxMs:  cfg.BackoffMax.Milliseconds(),
+			Jitter:        0.2,
+		},
+	}
+}
+
+func (d *Dispatcher) Start(ctx context.Context) {
+	d.log.Infof("Starting callback dispatcher with %d workers and poll interval %s", d.workers, d.pollInterval)
+	for range d.workers {
+		d.wg.Add(1)
+		go d.run(ctx)
+	}
+}
+
+func (d *Dispatcher) Wait() {
+	d.wg.Wait()
+}
+
+func (d *Dispatcher) run(ctx context.Context) {
+	defer d.wg.Done()
+
+	ticker := time.NewTicker(d.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+
+		// The lease keeps other replicas away from the delivery while it is in flight.
+		task, err := d.repo.ClaimCallback(ctx, time.Now().Add(2*d.timeout))
+		switch {
+		case err == nil:
+			d.Deliver(ctx, task)
+			continue
+		case errors.Is(err, sql.ErrNoRows):
+		case ctx.Err() != nil:
+			return
+		default:
+			d.log.Errorf("Dispatcher.run.ClaimCallback : %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *Dispatcher) Deliver(ctx context.Context, task *models.Task) {
+	err := d.post(ctx, task)
+	if err == nil {
+		d.log.Infof("Dispatcher.Deliver: callback of task %v delivered", task.Id)
+		d.updateStatus(task.Id, models.CallbackStatusDelivered, nil, nil)
+		return
+	}
+
+	reason := err.Error()
+	if d.policy.ShouldRetry(task.Callback.Attempts) {
+		nextAttemptAt := time.Now().Add(d.policy.Backoff(task.Callback.Attempts))
+		d.log.Infof("Dispatcher.Deliver: callback of task %v attempt %d failed (%v), next attempt at %s", task.Id, task.Callback.Attempts, err, nextAttemptAt)
+		d.updateStatus(task.Id, models.CallbackStatusPending, &nextAttemptAt, &reason)
+		return
+	}
+	d.log.Errorf("Dispatcher.Deliver: callback of task %v failed after %d attempts: %v", task.Id, task.Callback.Attempts, err)
+	d.updateStatus(task.Id, models.CallbackStatusFailed, nil, &reason)
+}
+
+func (d *Dispatcher) post(ctx context.Context, task *models.Task) error {
+	payload, err := json.Marshal(mapper.MapTaskToGetResponse(task))
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, d.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, task.Callback.Url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(TaskIdHeader, strconv.FormatInt(task.Id, 10))
+	if task.Callback.Secret != "" {
+		req.Header.Set(SignatureHeader, task.Callback.Signature(payload))
+	}
+
+	resp, err := d.clientProvider.Client().Do(req)
+	if err != nil {
+		return err
+	}
+	defer func(Body io.ReadCloser) {
+		_, _ = io.Copy(io.Discard, Body)
+		err := Body.Close()
+		if err != nil {
+			d.log.Errorf("Dispatcher.post.Body.Close : %v", err)
+		}
+	}(resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (d *Dispatcher) updateStatus(id int64, status string, nextAttemptAt *time.Time, lastError *string) {
+	err := d.repo.UpdateCallbackStatus(context.Background(), id, status, nextAttemptAt, lastError)
+	if err != nil {
+		d.log.Errorf("Dispatcher.updateStatus.UpdateCallbackStatus : %v", err)
+	}
+}
@@ -0,0 +1,176 @@
+package callback
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"http-task-executor/internal/config"
+	"http-task-executor/internal/models"
+	"http-task-executor/internal/tasks/delivery/http/dto"
+	"http-task-executor/internal/tasks/mock"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+var callbackConfig = config.CallbackConfig{
+	PollInterval: time.Hour,
+	Timeout:      time.Second,
+	MaxAttempts:  3,
+	BackoffBase:  time.Second,
+	BackoffMax:   time.Minute,
+}
+
+type clientProvider struct{}
+
+func (c *clientProvider) Client() *http.Client {
+	return &http.Client{}
+}
+
+func (c *clientProvider) ProfileClient(name string) (*http.Client, error) {
+	return c.Client(), nil
+}
+
+func TestDispatcher_DeliverSignedPayload(t *testing.T) {
+	t.Parallel()
+	ctrx := gomock.NewController(t)
+	defer ctrx.Finish()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	mockTasksRepo := mock.NewMockRepository(ctrx)
+
+	received := make(chan *http.Request, 1)
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		received <- r
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	dispatcher := NewDispatcher(sugar, mockTasksRepo, &clientProvider{}, callbackConfig)
+
+	code := int64(200)
+	task := &models.Task{
+		Id:             1515,
+		Status:         models.StatusDone,
+		ResponseStatus: &code,
+		Callback:       &models.Callback{Url: server.URL, Secret: "secret", Attempts: 1},
+	}
+
+	mockTasksRepo.EXPECT().UpdateCallbackStatus(gomock.Any(), task.Id, models.CallbackStatusDelivered, nil, nil).Return(nil).Times(1)
+
+	dispatcher.Deliver(context.Background(), task)
+
+	request := <-received
+	require.Equal(t, http.MethodPost, request.Method)
+	require.Equal(t, "1515", request.Header.Get(TaskIdHeader))
+	require.Equal(t, task.Callback.Signature(body), request.Header.Get(SignatureHeader))
+
+	var payload dto.GetTaskResponse
+	require.NoError(t, json.Unmarshal(body, &payload))
+	require.Equal(t, task.Id, payload.ID)
+	require.Equal(t, models.StatusDone, payload.Status)
+}
+
+func TestDispatcher_DeliverFailure(t *testing.T) {
+	t.Parallel()
+	ctrx := gomock.NewController(t)
+	defer ctrx.Finish()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	mockTasksRepo := mock.NewMockRepository(ctrx)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	dispatcher := NewDispatcher(sugar, mockTasksRepo, &clientProvider{}, callbackConfig)
+
+	t.Run("Retried with backoff", func(t *testing.T) {
+		task := &models.Task{Id: 1, Status: models.StatusError, Callback: &models.Callback{Url: server.URL, Attempts: 1}}
+
+		mockTasksRepo.EXPECT().UpdateCallbackStatus(gomock.Any(), task.Id, models.CallbackStatusPending,
+			gomock.Cond(func(x *time.Time) bool { return x != nil && x.After(time.Now()) }),
+			gomock.Cond(func(x *string) bool { return x != nil && *x == "unexpected status code 500" })).Return(nil).Times(1)
+
+		dispatcher.Deliver(context.Background(), task)
+	})
+
+	t.Run("Failed after last attempt", func(t *testing.T) {
+		task := &models.Task{Id: 2, Status: models.StatusError, Callback: &models.Callback{Url: server.URL, Attempts: 3}}
+
+		mockTasksRepo.EXPECT().UpdateCallbackStatus(gomock.Any(), task.Id, models.CallbackStatusFailed, nil, gomock.Not(gomock.Nil())).Return(nil).Times(1)
+
+		dispatcher.Deliver(context.Background(), task)
+	})
+}
+
+func TestDispatcher_ConcurrentDeliveries(t *testing.T) {
+	t.Parallel()
+	ctrx := gomock.NewController(t)
+	defer ctrx.Finish()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	mockTasksRepo := mock.NewMockRepository(ctrx)
+
+	// Each callback is answered only once both have arrived, which a single worker never gets to.
+	var arrived sync.WaitGroup
+	arrived.Add(2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		arrived.Done()
+		arrived.Wait()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	cfg := callbackConfig
+	cfg.Workers = 2
+	cfg.Timeout = 5 * time.Second
+	dispatcher := NewDispatcher(sugar, mockTasksRepo, &clientProvider{}, cfg)
+
+	claims := make(chan *models.Task, 2)
+	claims <- &models.Task{Id: 1, Status: models.StatusDone, Callback: &models.Callback{Url: server.URL, Attempts: 1}}
+	claims <- &models.Task{Id: 2, Status: models.StatusDone, Callback: &models.Callback{Url: server.URL, Attempts: 1}}
+
+	mockTasksRepo.EXPECT().ClaimCallback(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, leaseUntil time.Time) (*models.Task, error) {
+		select {
+		case task := <-claims:
+			return task, nil
+		default:
+			return nil, sql.ErrNoRows
+		}
+	}).AnyTimes()
+
+	delivered := make(chan int64, 2)
+	mockTasksRepo.EXPECT().UpdateCallbackStatus(gomock.Any(), gomock.Any(), models.CallbackStatusDelivered, nil, nil).DoAndReturn(
+		func(ctx context.Context, id int64, status string, nextAttemptAt *time.Time, lastError *string) error {
+			delivered <- id
+			return nil
+		}).Times(2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	dispatcher.Start(ctx)
+
+	for range 2 {
+		select {
+		case <-delivered:
+		case <-time.After(3 * time.Second):
+			t.Fatal("callbacks were not delivered concurrently")
+		}
+	}
+
+	cancel()
+	dispatcher.Wait()
+}
@@ -1,19 +1,113 @@
 package dto
 
+import "time"
+
 type NewTaskRequest struct {
-	Url     string            `json:"url"`
-	Method  string            `json:"method"`
-	Headers map[string]string `json:"headers"`
+	Url          string            `json:"url"`
+	Method       string            `json:"method"`
+	Headers      map[string]string `json:"headers"`
+	Body         string            `json:"body"`
+	BodyEncoding string            `json:"bodyEncoding" enums:"text,json,base64"`
+	Retry        *RetryPolicy      `json:"retry"`
+	RunAt        *time.Time        `json:"runAt,omitempty"`
+	Delay        string            `json:"delay,omitempty" example:"15m"`
+	Callback     *Callback         `json:"callback,omitempty"`
+	TlsProfile   *string           `json:"tlsProfile,omitempty" example:"partner-mtls"`
+}
+
+type Callback struct {
+	Url    string `json:"url" example:"https://example.com/hooks/task"`
+	Secret string `json:"secret,omitempty"`
+}
+
+type RetryPolicy struct {
+	MaxAttempts   int      `json:"maxAttempts" example:"3"`
+	BackoffBaseMs int64    `json:"backoffBaseMs" example:"1000"`
+	BackoffMaxMs  int64    `json:"backoffMaxMs" example:"60000"`
+	Jitter        float64  `json:"jitter" example:"0.2"`
+	RetryOnStatus []int    `json:"retryOnStatus" example:"502,503"`
+	RetryOnErrors []string `json:"retryOnErrors" enums:"timeout,connection,dns,tls,circuit_open"`
 }
 
 type NewTaskResponse struct {
 	Id int64 `json:"id"`
 }
 
+type NewBatchResponse struct {
+	Ids     []int64 `json:"ids"`
+	BatchId *int64  `json:"batchId,omitempty"`
+}
+
+type BatchProgressResponse struct {
+	ID        int64          `json:"id"`
+	Total     int            `json:"total"`
+	Finished  int            `json:"finished"`
+	Completed bool           `json:"completed"`
+	Statuses  map[string]int `json:"statuses"`
+	CreatedAt time.Time      `json:"createdAt"`
+}
+
 type GetTaskResponse struct {
 	ID             int64             `json:"id"`
 	Status         string            `json:"status"`
 	ResponseStatus *int64            `json:"httpStatusCode"`
 	ResponseLength *int64            `json:"length"`
 	Headers        map[string]string `json:"headers"`
+	Attempts       int               `json:"attempts"`
+	NextAttemptAt  *time.Time        `json:"nextAttemptAt,omitempty"`
+	LastError      *string           `json:"lastError,omitempty"`
+	RunAt          *time.Time        `json:"runAt,omitempty"`
+	Callback       *CallbackResponse `json:"callback,omitempty"`
+	TraceId        *string           `json:"traceId,omitempty"`
+	TlsProfile     *string           `json:"tlsProfile,omitempty"`
+}
+
+type CallbackResponse struct {
+	Url       string  `json:"url"`
+	Status    *string `json:"status,omitempty" enums:"pending,delivered,failed"`
+	Attempts  int     `json:"attempts"`
+	LastError *string `json:"lastError,omitempty"`
+}
+
+type CancelTaskResponse struct {
+	ID             int64  `json:"id"`
+	Status         string `json:"status"`
+	PreviousStatus string `json:"previousStatus"`
+}
+
+type TaskEventResponse struct {
+	ID             int64     `json:"id"`
+	Status         string    `json:"status"`
+	ResponseStatus *int64    `json:"httpStatusCode,omitempty"`
+	LastError      *string   `json:"lastError,omitempty"`
+	At             time.Time `json:"at"`
+}
+
+type TaskAttemptResponse struct {
+	Attempt        int       `json:"attempt"`
+	StartedAt      time.Time `json:"startedAt"`
+	FinishedAt     time.Time `json:"finishedAt"`
+	DurationMs     int64     `json:"durationMs"`
+	ResponseStatus *int64    `json:"httpStatusCode"`
+	ResponseLength *int64    `json:"length"`
+	Error          *string   `json:"error,omitempty"`
+}
+
+type TaskSummaryResponse struct {
+	ID             int64      `json:"id"`
+	Url            string     `json:"url"`
+	Method         string     `json:"method"`
+	Status         string     `json:"status"`
+	ResponseStatus *int64     `json:"httpStatusCode"`
+	ResponseLength *int64     `json:"length"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  *time.Time `json:"nextAttemptAt,omitempty"`
+	LastError      *string    `json:"lastError,omitempty"`
+	RunAt          *time.Time `json:"runAt,omitempty"`
+	CreatedAt      time.Time  `json:"createdAt"`
+}
+
+type TaskListResponse struct {
+	Tasks      []TaskSummaryResponse `json:"tasks"`
+	NextCursor string                `json:"nextCursor,omitempty"`
 }
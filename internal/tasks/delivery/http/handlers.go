@@ -1,59 +1,87 @@
 package http
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
 	"http-task-executor/internal/config"
 	"http-task-executor/internal/logger"
+	"http-task-executor/internal/models"
 	"http-task-executor/internal/tasks"
 	"http-task-executor/internal/tasks/delivery/http/dto"
 	"http-task-executor/internal/tasks/mapper"
 	httpErrors "http-task-executor/pkg/errors/http"
 	"net/http"
 	"strconv"
+	"sync"
+	"time"
 )
 
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// sseKeepAlive is how often an idle event stream gets a comment, so proxies don't close it.
+const sseKeepAlive = 15 * time.Second
+
 type TaskHandlers struct {
-	cfg     *config.Config
-	useCase tasks.UseCase
-	logger  logger.Logger
+	cfg       *config.Config
+	useCase   tasks.UseCase
+	logger    logger.Logger
+	closed    chan struct{}
+	closeOnce sync.Once
 }
 
 func NewTaskHandlers(cfg *config.Config, logger logger.Logger, useCase tasks.UseCase) *TaskHandlers {
-	return &TaskHandlers{cfg: cfg, logger: logger, useCase: useCase}
+	return &TaskHandlers{cfg: cfg, logger: logger, useCase: useCase, closed: make(chan struct{})}
+}
+
+// Close ends the open event streams, which would otherwise keep the server from shutting down.
+func (h *TaskHandlers) Close() {
+	h.closeOnce.Do(func() {
+		close(h.closed)
+	})
 }
 
 // Create godoc
 // @Summary Create task and execute request to 3rd service
-// @Description Create task and execute request to 3rd service
+// @Description Create task and execute request to 3rd service. A request repeated with the same Idempotency-Key returns the task created by the first one
 // @Tags Task
 // @Accept json
 // @Produce json
 // @Param request body dto.NewTaskRequest true "Task create request"
+// @Param Idempotency-Key header string false "Key deduplicating retries of the request"
 // @Success 201 {object} dto.NewTaskResponse
+// @Failure 409 {object} httpErrors.RestError "Idempotency key is used by a different request"
+// @Failure 413 {object} httpErrors.RestError "Request body exceeds the max payload size"
+// @Security ApiKeyAuth
 // @Router /task [post]
 func (h *TaskHandlers) Create() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		h.limitBody(w, r)
 		var newTaskRequest dto.NewTaskRequest
 		err := render.DecodeJSON(r.Body, &newTaskRequest)
 
 		if err != nil {
-			h.logger.Error(err)
-			code, data := httpErrors.ErrorResponse(err)
-			render.Status(r, code)
-			render.JSON(w, r, data)
+			h.writeError(w, r, err)
 			return
 		}
 
-		h.logger.Infof("Request body decoded %v", newTaskRequest)
+		h.logger.Infof("Request body decoded: %s %s", newTaskRequest.Method, newTaskRequest.Url)
 
-		task := mapper.MapRequestToTask(&newTaskRequest)
+		task, err := mapper.MapRequestToTask(&newTaskRequest)
+		if err != nil {
+			h.writeError(w, r, err)
+			return
+		}
+		err = mapper.MapIdempotencyKey(r.Header.Get(IdempotencyKeyHeader), &newTaskRequest, &task)
+		if err != nil {
+			h.writeError(w, r, err)
+			return
+		}
 		create, err := h.useCase.Create(r.Context(), &task)
 		if err != nil {
-			h.logger.Error(err)
-			code, data := httpErrors.ErrorResponse(err)
-			render.Status(r, code)
-			render.JSON(w, r, data)
+			h.writeError(w, r, err)
 			return
 		}
 		render.Status(r, http.StatusOK)
@@ -61,6 +89,79 @@ func (h *TaskHandlers) Create() http.HandlerFunc {
 	}
 }
 
+// CreateBatch godoc
+// @Summary Create tasks in batch
+// @Description Validates every task of the batch and creates all of them in a single transaction, or none when any is invalid. Pass track=true to create a batch whose aggregate progress can be queried
+// @Tags Task
+// @Accept json
+// @Produce json
+// @Param request body []dto.NewTaskRequest true "Task create requests"
+// @Param track query bool false "Create a batch to track progress"
+// @Success 200 {object} dto.NewBatchResponse
+// @Failure 400 {object} httpErrors.BatchError
+// @Failure 413 {object} httpErrors.RestError "Request body exceeds the max payload size"
+// @Security ApiKeyAuth
+// @Router /tasks/batch [post]
+func (h *TaskHandlers) CreateBatch() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		track, err := mapper.MapQueryToBatchTracking(r.URL.Query())
+		if err != nil {
+			h.writeError(w, r, err)
+			return
+		}
+
+		h.limitBody(w, r)
+		var request []dto.NewTaskRequest
+		err = render.DecodeJSON(r.Body, &request)
+		if err != nil {
+			h.writeError(w, r, err)
+			return
+		}
+
+		h.logger.Infof("Batch of %d tasks decoded", len(request))
+
+		batchTasks, err := mapper.MapBatchRequestToTasks(request)
+		if err != nil {
+			h.writeError(w, r, err)
+			return
+		}
+		batch, err := h.useCase.CreateBatch(r.Context(), batchTasks, track)
+		if err != nil {
+			h.writeError(w, r, err)
+			return
+		}
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, mapper.MapBatchToResponse(batch))
+	}
+}
+
+// GetBatch godoc
+// @Summary Get batch progress
+// @Description Returns the number of tasks of the batch in every status
+// @Tags Task
+// @Accept json
+// @Produce json
+// @Param id path int true "id"
+// @Success 200 {object} dto.BatchProgressResponse
+// @Security ApiKeyAuth
+// @Router /tasks/batch/{id} [get]
+func (h *TaskHandlers) GetBatch() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := h.parseId(w, r)
+		if !ok {
+			return
+		}
+
+		progress, err := h.useCase.GetBatchProgress(r.Context(), id)
+		if err != nil {
+			h.writeError(w, r, err)
+			return
+		}
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, mapper.MapBatchProgressToResponse(progress))
+	}
+}
+
 // Get godoc
 // @Summary Get task by id
 // @Description Get task by id handler
@@ -69,40 +170,313 @@ func (h *TaskHandlers) Create() http.HandlerFunc {
 // @Produce json
 // @Param id path int true "id"
 // @Success 200 {object} dto.GetTaskResponse
+// @Security ApiKeyAuth
 // @Router /task/{id} [get]
 func (h *TaskHandlers) Get() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		id := chi.URLParam(r, "id")
+		id, ok := h.parseId(w, r)
+		if !ok {
+			return
+		}
 
-		idInt, err := strconv.Atoi(id)
+		responseTask, err := h.useCase.GetByIdWithOutputHeaders(r.Context(), id)
 		if err != nil {
-			h.logger.Error(err)
-			code, data := httpErrors.ErrorResponse(err)
-			render.Status(r, code)
-			render.JSON(w, r, data)
+			h.writeError(w, r, err)
 			return
 		}
-		h.logger.Infof("Request path decoded %v", idInt)
 
-		if idInt <= 0 {
-			h.logger.Info("Id must be positive")
+		response := mapper.MapTaskToGetResponse(responseTask)
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, response)
+	}
+}
 
-			render.Status(r, http.StatusBadRequest)
-			render.JSON(w, r, httpErrors.NewRestError(http.StatusBadRequest, "Invalid id", nil))
+// List godoc
+// @Summary List tasks
+// @Description List tasks with filtering, sorting and cursor pagination. Pass nextCursor of the previous page as cursor to get the next one
+// @Tags Task
+// @Accept json
+// @Produce json
+// @Param status query []string false "Task statuses" collectionFormat(csv)
+// @Param method query string false "HTTP method"
+// @Param host query string false "Target URL host"
+// @Param urlPrefix query string false "Target URL prefix"
+// @Param minCode query int false "Minimal response status code"
+// @Param maxCode query int false "Maximal response status code"
+// @Param createdFrom query string false "Created at or after (RFC3339)"
+// @Param createdTo query string false "Created before (RFC3339)"
+// @Param sort query string false "Sort field" Enums(createdAt, id) default(createdAt)
+// @Param order query string false "Sort order" Enums(asc, desc) default(desc)
+// @Param limit query int false "Page size" default(50) maximum(500)
+// @Param cursor query string false "Cursor of the page"
+// @Success 200 {object} dto.TaskListResponse
+// @Security ApiKeyAuth
+// @Router /tasks [get]
+func (h *TaskHandlers) List() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter, err := mapper.MapQueryToTaskFilter(r.URL.Query())
+		if err != nil {
+			h.writeError(w, r, err)
 			return
 		}
 
-		responseTask, err := h.useCase.GetByIdWithOutputHeaders(r.Context(), int64(idInt))
+		page, err := h.useCase.List(r.Context(), filter)
 		if err != nil {
-			h.logger.Error(err)
-			code, data := httpErrors.ErrorResponse(err)
-			render.Status(r, code)
-			render.JSON(w, r, data)
+			h.writeError(w, r, err)
 			return
 		}
 
-		response := mapper.MapTaskToGetResponse(responseTask)
 		render.Status(r, http.StatusOK)
-		render.JSON(w, r, response)
+		render.JSON(w, r, mapper.MapTaskPageToResponse(page))
+	}
+}
+
+// Events godoc
+// @Summary Stream status changes of task
+// @Description Streams the current status of the task followed by every status change as Server-Sent Events. The stream ends once the task reaches done, error or cancelled
+// @Tags Task
+// @Produce text/event-stream
+// @Param id path int true "id"
+// @Success 200 {object} dto.TaskEventResponse
+// @Security ApiKeyAuth
+// @Router /task/{id}/events [get]
+func (h *TaskHandlers) Events() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := h.parseId(w, r)
+		if !ok {
+			return
+		}
+
+		events, unsubscribe, err := h.useCase.Subscribe(r.Context(), models.TaskEventFilter{TaskId: id})
+		if err != nil {
+			h.writeError(w, r, err)
+			return
+		}
+		defer unsubscribe()
+
+		task, err := h.useCase.GetByIdWithOutputHeaders(r.Context(), id)
+		if err != nil {
+			h.writeError(w, r, err)
+			return
+		}
+
+		current := models.NewTaskEvent(task)
+		h.stream(w, r, &current, events)
 	}
 }
+
+// StreamEvents godoc
+// @Summary Stream status changes of all tasks
+// @Description Streams status changes of all tasks as Server-Sent Events
+// @Tags Task
+// @Produce text/event-stream
+// @Param status query []string false "Task statuses" collectionFormat(csv)
+// @Success 200 {object} dto.TaskEventResponse
+// @Security ApiKeyAuth
+// @Router /tasks/events [get]
+func (h *TaskHandlers) StreamEvents() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		events, unsubscribe, err := h.useCase.Subscribe(r.Context(), mapper.MapQueryToEventFilter(r.URL.Query()))
+		if err != nil {
+			h.writeError(w, r, err)
+			return
+		}
+		defer unsubscribe()
+
+		h.stream(w, r, nil, events)
+	}
+}
+
+// GetResponse godoc
+// @Summary Get response body of executed task
+// @Description Returns the captured response body of the 3rd service with its original Content-Type
+// @Tags Task
+// @Produce octet-stream
+// @Param id path int true "id"
+// @Success 200 {file} file
+// @Header 200 {boolean} X-Response-Truncated "Set when the stored body was truncated"
+// @Security ApiKeyAuth
+// @Router /task/{id}/response [get]
+func (h *TaskHandlers) GetResponse() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := h.parseId(w, r)
+		if !ok {
+			return
+		}
+
+		response, err := h.useCase.GetResponse(r.Context(), id)
+		if err != nil {
+			h.writeError(w, r, err)
+			return
+		}
+
+		if response.ContentType != "" {
+			w.Header().Set("Content-Type", response.ContentType)
+		} else {
+			w.Header().Set("Content-Type", "application/octet-stream")
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(response.Body)))
+		if response.Truncated {
+			w.Header().Set("X-Response-Truncated", "true")
+		}
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write(response.Body); err != nil {
+			h.logger.Errorf("TaskHandlers.GetResponse.Write : %v", err)
+		}
+	}
+}
+
+// GetAttempts godoc
+// @Summary Get execution attempts of task
+// @Description Lists every attempt made to execute the task, oldest first
+// @Tags Task
+// @Accept json
+// @Produce json
+// @Param id path int true "id"
+// @Success 200 {array} dto.TaskAttemptResponse
+// @Security ApiKeyAuth
+// @Router /task/{id}/attempts [get]
+func (h *TaskHandlers) GetAttempts() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := h.parseId(w, r)
+		if !ok {
+			return
+		}
+
+		attempts, err := h.useCase.GetAttempts(r.Context(), id)
+		if err != nil {
+			h.writeError(w, r, err)
+			return
+		}
+
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, mapper.MapAttemptsToResponse(attempts))
+	}
+}
+
+// Cancel godoc
+// @Summary Cancel task
+// @Description Cancels a task that is new or in process; an in-flight request is aborted
+// @Tags Task
+// @Accept json
+// @Produce json
+// @Param id path int true "id"
+// @Success 200 {object} dto.CancelTaskResponse
+// @Security ApiKeyAuth
+// @Router /task/{id}/cancel [post]
+func (h *TaskHandlers) Cancel() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := h.parseId(w, r)
+		if !ok {
+			return
+		}
+
+		previousStatus, err := h.useCase.Cancel(r.Context(), id)
+		if err != nil {
+			h.writeError(w, r, err)
+			return
+		}
+
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, mapper.MapCancelledTaskToResponse(id, previousStatus))
+	}
+}
+
+func (h *TaskHandlers) parseId(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id := chi.URLParam(r, "id")
+
+	idInt, err := strconv.Atoi(id)
+	if err != nil {
+		h.writeError(w, r, err)
+		return 0, false
+	}
+	h.logger.Infof("Request path decoded %v", idInt)
+
+	if idInt <= 0 {
+		h.logger.Info("Id must be positive")
+
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, httpErrors.NewRestError(http.StatusBadRequest, "Invalid id", nil))
+		return 0, false
+	}
+	return int64(idInt), true
+}
+
+// stream writes the events as Server-Sent Events until the client goes away. A stream
+// started with the current state of a task ends once the task reaches a final status.
+func (h *TaskHandlers) stream(w http.ResponseWriter, r *http.Request, current *models.TaskEvent, events <-chan models.TaskEvent) {
+	controller := http.NewResponseController(w)
+	err := controller.SetWriteDeadline(time.Time{})
+	if err != nil && !errors.Is(err, http.ErrNotSupported) {
+		h.logger.Errorf("TaskHandlers.stream.SetWriteDeadline : %v", err)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	send := func(event models.TaskEvent) bool {
+		data, err := json.Marshal(mapper.MapEventToResponse(event))
+		if err != nil {
+			h.logger.Errorf("TaskHandlers.stream.Marshal : %v", err)
+			return false
+		}
+		_, err = fmt.Fprintf(w, "event: status\ndata: %s\n\n", data)
+		if err == nil {
+			err = controller.Flush()
+		}
+		if err != nil {
+			h.logger.Infof("TaskHandlers.stream: client is gone: %v", err)
+			return false
+		}
+		return true
+	}
+
+	if current != nil {
+		if !send(*current) || models.IsFinal(current.Status) {
+			return
+		}
+	}
+
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-h.closed:
+			return
+		case event, ok := <-events:
+			if !ok || !send(event) {
+				return
+			}
+			if current != nil && models.IsFinal(event.Status) {
+				return
+			}
+		case <-keepAlive.C:
+			_, err := fmt.Fprint(w, ": keep-alive\n\n")
+			if err == nil {
+				err = controller.Flush()
+			}
+			if err != nil {
+				return
+			}
+		}
+	}
+}
+
+// limitBody caps the request body at the max payload size, decoding a larger body fails with a 413.
+func (h *TaskHandlers) limitBody(w http.ResponseWriter, r *http.Request) {
+	if h.cfg != nil && h.cfg.MaxPayloadSize > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, h.cfg.MaxPayloadSize)
+	}
+}
+
+func (h *TaskHandlers) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	h.logger.Error(err)
+	code, data := httpErrors.ErrorResponse(err)
+	render.Status(r, code)
+	render.JSON(w, r, data)
+}
@@ -3,6 +3,7 @@ package http
 import (
 	"bytes"
 	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -11,12 +12,16 @@ import (
 	"go.uber.org/mock/gomock"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"http-task-executor/internal/config"
 	"http-task-executor/internal/models"
 	"http-task-executor/internal/tasks/delivery/http/dto"
 	"http-task-executor/internal/tasks/mock"
+	httpErrors "http-task-executor/pkg/errors/http"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestTaskHandlers_Create(t *testing.T) {
@@ -89,6 +94,185 @@ func TestTaskHandlers_CreateWithHeaders(t *testing.T) {
 	require.Equal(t, resTask.Id, response.Id)
 }
 
+func TestTaskHandlers_CreateDelayed(t *testing.T) {
+	t.Parallel()
+	ctrx := gomock.NewController(t)
+	defer ctrx.Finish()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	mockUseCase := mock.NewMockUseCase(ctrx)
+
+	handlers := NewTaskHandlers(nil, sugar, mockUseCase)
+
+	t.Run("Delay schedules task", func(t *testing.T) {
+		input := `{"url": "http://test.com", "method": "GET", "delay": "15m"}`
+
+		request := httptest.NewRequest(http.MethodPost, "/task", bytes.NewReader([]byte(input)))
+		res := httptest.NewRecorder()
+
+		mockUseCase.EXPECT().Create(gomock.Any(), gomock.Cond(func(x *models.Task) bool {
+			return x.Status == models.StatusScheduled && x.RunAt != nil && x.RunAt.After(time.Now().Add(14*time.Minute))
+		})).Return(&models.Task{Id: int64(1)}, nil)
+
+		handlers.Create().ServeHTTP(res, request)
+
+		require.Equal(t, http.StatusOK, res.Code)
+	})
+
+	t.Run("RunAt in the past runs immediately", func(t *testing.T) {
+		input := `{"url": "http://test.com", "method": "GET", "runAt": "2020-01-01T00:00:00Z"}`
+
+		request := httptest.NewRequest(http.MethodPost, "/task", bytes.NewReader([]byte(input)))
+		res := httptest.NewRecorder()
+
+		mockUseCase.EXPECT().Create(gomock.Any(), gomock.Cond(func(x *models.Task) bool {
+			return x.Status == models.StatusNew && x.RunAt == nil
+		})).Return(&models.Task{Id: int64(2)}, nil)
+
+		handlers.Create().ServeHTTP(res, request)
+
+		require.Equal(t, http.StatusOK, res.Code)
+	})
+
+	t.Run("RunAt and delay together", func(t *testing.T) {
+		input := `{"url": "http://test.com", "method": "GET", "runAt": "2030-01-01T00:00:00Z", "delay": "15m"}`
+
+		request := httptest.NewRequest(http.MethodPost, "/task", bytes.NewReader([]byte(input)))
+		res := httptest.NewRecorder()
+
+		handlers.Create().ServeHTTP(res, request)
+
+		require.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("Invalid delay", func(t *testing.T) {
+		input := `{"url": "http://test.com", "method": "GET", "delay": "soon"}`
+
+		request := httptest.NewRequest(http.MethodPost, "/task", bytes.NewReader([]byte(input)))
+		res := httptest.NewRecorder()
+
+		handlers.Create().ServeHTTP(res, request)
+
+		require.Equal(t, http.StatusBadRequest, res.Code)
+	})
+}
+
+func TestTaskHandlers_CreateWithIdempotencyKey(t *testing.T) {
+	t.Parallel()
+	ctrx := gomock.NewController(t)
+	defer ctrx.Finish()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	mockUseCase := mock.NewMockUseCase(ctrx)
+
+	handlers := NewTaskHandlers(nil, sugar, mockUseCase)
+
+	hashes := make([]string, 0)
+	mockUseCase.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, task *models.Task) (*models.Task, error) {
+		require.Equal(t, "key", *task.IdempotencyKey)
+		hashes = append(hashes, *task.RequestHash)
+		return &models.Task{Id: 1}, nil
+	}).Times(3)
+
+	for _, input := range []string{
+		`{"url": "http://test.com", "method": "POST", "headers": {"A": "1", "B": "2"}}`,
+		`{"method":"POST","headers":{"B":"2","A":"1"},"url":"http://test.com"}`,
+		`{"url": "http://test.com", "method": "PUT"}`,
+	} {
+		request := httptest.NewRequest(http.MethodPost, "/task", bytes.NewReader([]byte(input)))
+		request.Header.Set(IdempotencyKeyHeader, "key")
+		res := httptest.NewRecorder()
+
+		handlers.Create().ServeHTTP(res, request)
+
+		require.Equal(t, http.StatusOK, res.Code)
+	}
+
+	require.Equal(t, hashes[0], hashes[1])
+	require.NotEqual(t, hashes[0], hashes[2])
+}
+
+func TestTaskHandlers_CreateBatch(t *testing.T) {
+	t.Parallel()
+	ctrx := gomock.NewController(t)
+	defer ctrx.Finish()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	mockUseCase := mock.NewMockUseCase(ctrx)
+
+	handlers := NewTaskHandlers(nil, sugar, mockUseCase)
+
+	t.Run("Created with batch id", func(t *testing.T) {
+		input := `[{"url": "http://test.com", "method": "GET"}, {"url": "http://test.com", "method": "POST", "delay": "1m"}]`
+
+		request := httptest.NewRequest(http.MethodPost, "/tasks/batch?track=true", bytes.NewReader([]byte(input)))
+		res := httptest.NewRecorder()
+
+		batchId := int64(7)
+		mockUseCase.EXPECT().CreateBatch(gomock.Any(), gomock.Cond(func(x []models.Task) bool {
+			return len(x) == 2 && x[0].Status == models.StatusNew && x[1].Status == models.StatusScheduled
+		}), true).Return(&models.Batch{Id: &batchId, TaskIds: []int64{1, 2}}, nil)
+
+		handlers.CreateBatch().ServeHTTP(res, request)
+
+		var response dto.NewBatchResponse
+
+		require.Equal(t, http.StatusOK, res.Code)
+		require.NoError(t, json.Unmarshal(res.Body.Bytes(), &response))
+		require.Equal(t, []int64{1, 2}, response.Ids)
+		require.Equal(t, batchId, *response.BatchId)
+	})
+
+	t.Run("Invalid items reported by index", func(t *testing.T) {
+		input := `[{"url": "http://test.com", "method": "GET"}, {"url": "http://test.com", "method": "GET", "delay": "soon"}]`
+
+		request := httptest.NewRequest(http.MethodPost, "/tasks/batch", bytes.NewReader([]byte(input)))
+		res := httptest.NewRecorder()
+
+		handlers.CreateBatch().ServeHTTP(res, request)
+
+		var response httpErrors.BatchError
+
+		require.Equal(t, http.StatusBadRequest, res.Code)
+		require.NoError(t, json.Unmarshal(res.Body.Bytes(), &response))
+		require.Equal(t, []httpErrors.ItemError{{Index: 1, Error: "invalid delay soon"}}, response.Items)
+	})
+}
+
+func TestTaskHandlers_GetBatch(t *testing.T) {
+	t.Parallel()
+	ctrx := gomock.NewController(t)
+	defer ctrx.Finish()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	mockUseCase := mock.NewMockUseCase(ctrx)
+
+	handlers := NewTaskHandlers(nil, sugar, mockUseCase)
+
+	request := addChiURLParams(httptest.NewRequest(http.MethodGet, "/tasks/batch/{id}", nil), map[string]string{"id": "7"})
+	res := httptest.NewRecorder()
+
+	mockUseCase.EXPECT().GetBatchProgress(gomock.Any(), int64(7)).Return(&models.BatchProgress{
+		Id:       7,
+		Size:     3,
+		Statuses: map[string]int{models.StatusDone: 2, models.StatusInProcess: 1},
+	}, nil)
+
+	handlers.GetBatch().ServeHTTP(res, request)
+
+	var response dto.BatchProgressResponse
+
+	require.Equal(t, http.StatusOK, res.Code)
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), &response))
+	require.Equal(t, 3, response.Total)
+	require.Equal(t, 2, response.Finished)
+	require.False(t, response.Completed)
+}
+
 func TestTaskHandlers_CreateWithErrorInUC(t *testing.T) {
 	t.Parallel()
 	ctrx := gomock.NewController(t)
@@ -169,6 +353,42 @@ func TestTaskHandlers_CreateWithUnmarshalTypeError(t *testing.T) {
 	require.NotEmpty(t, body)
 }
 
+func TestTaskHandlers_CreateWithTooLargeBody(t *testing.T) {
+	t.Parallel()
+	ctrx := gomock.NewController(t)
+	defer ctrx.Finish()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	mockUseCase := mock.NewMockUseCase(ctrx)
+
+	handlers := NewTaskHandlers(&config.Config{MaxPayloadSize: 64}, sugar, mockUseCase)
+
+	input := fmt.Sprintf(`{"url": "%s", "method": "%s", "body": "%s"}`, "http://test.com", "POST", strings.Repeat("a", 64))
+
+	t.Run("Create", func(t *testing.T) {
+		request := httptest.NewRequest(http.MethodPost, "/task", bytes.NewReader([]byte(input)))
+		request.Header.Add("Content-Type", "application/json")
+
+		res := httptest.NewRecorder()
+
+		handlers.Create().ServeHTTP(res, request)
+
+		require.Equal(t, http.StatusRequestEntityTooLarge, res.Code)
+	})
+
+	t.Run("CreateBatch", func(t *testing.T) {
+		request := httptest.NewRequest(http.MethodPost, "/tasks/batch", bytes.NewReader([]byte("["+input+"]")))
+		request.Header.Add("Content-Type", "application/json")
+
+		res := httptest.NewRecorder()
+
+		handlers.CreateBatch().ServeHTTP(res, request)
+
+		require.Equal(t, http.StatusRequestEntityTooLarge, res.Code)
+	})
+}
+
 func TestTaskHandlers_Get(t *testing.T) {
 	t.Parallel()
 	ctrx := gomock.NewController(t)
@@ -212,6 +432,167 @@ func TestTaskHandlers_Get(t *testing.T) {
 	require.Equal(t, resTask.ResponseLength, response.ResponseLength)
 }
 
+func TestTaskHandlers_GetWithCallback(t *testing.T) {
+	t.Parallel()
+	ctrx := gomock.NewController(t)
+	defer ctrx.Finish()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	mockUseCase := mock.NewMockUseCase(ctrx)
+
+	handlers := NewTaskHandlers(nil, sugar, mockUseCase)
+
+	request := addChiURLParams(httptest.NewRequest(http.MethodGet, "/task/{id}", nil), map[string]string{"id": "1"})
+	res := httptest.NewRecorder()
+
+	callbackStatus := models.CallbackStatusDelivered
+	resTask := &models.Task{Id: 1, Status: models.StatusDone, Callback: &models.Callback{
+		Url:      "https://example.com/hook",
+		Secret:   "secret",
+		Status:   &callbackStatus,
+		Attempts: 1,
+	}}
+
+	mockUseCase.EXPECT().GetByIdWithOutputHeaders(gomock.Any(), int64(1)).Return(resTask, nil)
+
+	handlers.Get().ServeHTTP(res, request)
+
+	var response dto.GetTaskResponse
+
+	require.Equal(t, http.StatusOK, res.Code)
+	require.NotContains(t, res.Body.String(), "secret")
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), &response))
+	require.NotNil(t, response.Callback)
+	require.Equal(t, "https://example.com/hook", response.Callback.Url)
+	require.Equal(t, models.CallbackStatusDelivered, *response.Callback.Status)
+}
+
+func TestTaskHandlers_Events(t *testing.T) {
+	t.Parallel()
+	ctrx := gomock.NewController(t)
+	defer ctrx.Finish()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	mockUseCase := mock.NewMockUseCase(ctrx)
+
+	handlers := NewTaskHandlers(nil, sugar, mockUseCase)
+
+	t.Run("Streams until final status", func(t *testing.T) {
+		request := addChiURLParams(httptest.NewRequest(http.MethodGet, "/task/{id}/events", nil), map[string]string{"id": "1"})
+		res := httptest.NewRecorder()
+
+		events := make(chan models.TaskEvent, 2)
+		events <- models.TaskEvent{TaskId: 1, Status: models.StatusInProcess}
+		events <- models.TaskEvent{TaskId: 1, Status: models.StatusDone}
+		unsubscribed := false
+
+		mockUseCase.EXPECT().Subscribe(gomock.Any(), models.TaskEventFilter{TaskId: 1}).Return(events, func() { unsubscribed = true }, nil)
+		mockUseCase.EXPECT().GetByIdWithOutputHeaders(gomock.Any(), int64(1)).Return(&models.Task{Id: 1, Status: models.StatusNew}, nil)
+
+		handlers.Events().ServeHTTP(res, request)
+
+		require.Equal(t, http.StatusOK, res.Code)
+		require.Equal(t, "text/event-stream", res.Header().Get("Content-Type"))
+		body := res.Body.String()
+		require.Equal(t, 3, strings.Count(body, "event: status\n"))
+		require.Contains(t, body, `"status":"new"`)
+		require.Contains(t, body, `"status":"in_process"`)
+		require.Contains(t, body, `"status":"done"`)
+		require.True(t, unsubscribed)
+	})
+
+	t.Run("Finished task", func(t *testing.T) {
+		request := addChiURLParams(httptest.NewRequest(http.MethodGet, "/task/{id}/events", nil), map[string]string{"id": "2"})
+		res := httptest.NewRecorder()
+
+		mockUseCase.EXPECT().Subscribe(gomock.Any(), models.TaskEventFilter{TaskId: 2}).Return(make(chan models.TaskEvent), func() {}, nil)
+		mockUseCase.EXPECT().GetByIdWithOutputHeaders(gomock.Any(), int64(2)).Return(&models.Task{Id: 2, Status: models.StatusError}, nil)
+
+		handlers.Events().ServeHTTP(res, request)
+
+		require.Equal(t, http.StatusOK, res.Code)
+		require.Equal(t, 1, strings.Count(res.Body.String(), "event: status\n"))
+	})
+
+	t.Run("Task not found", func(t *testing.T) {
+		request := addChiURLParams(httptest.NewRequest(http.MethodGet, "/task/{id}/events", nil), map[string]string{"id": "3"})
+		res := httptest.NewRecorder()
+
+		mockUseCase.EXPECT().Subscribe(gomock.Any(), models.TaskEventFilter{TaskId: 3}).Return(make(chan models.TaskEvent), func() {}, nil)
+		mockUseCase.EXPECT().GetByIdWithOutputHeaders(gomock.Any(), int64(3)).Return(nil, sql.ErrNoRows)
+
+		handlers.Events().ServeHTTP(res, request)
+
+		require.Equal(t, http.StatusNotFound, res.Code)
+	})
+}
+
+func TestTaskHandlers_StreamEvents(t *testing.T) {
+	t.Parallel()
+	ctrx := gomock.NewController(t)
+	defer ctrx.Finish()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	mockUseCase := mock.NewMockUseCase(ctrx)
+
+	handlers := NewTaskHandlers(nil, sugar, mockUseCase)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	request := httptest.NewRequest(http.MethodGet, "/tasks/events?status=done,error", nil).WithContext(ctx)
+	res := httptest.NewRecorder()
+
+	events := make(chan models.TaskEvent)
+	mockUseCase.EXPECT().Subscribe(gomock.Any(), models.TaskEventFilter{Statuses: []string{models.StatusDone, models.StatusError}}).Return(events, func() {}, nil)
+
+	done := make(chan struct{})
+	go func() {
+		handlers.StreamEvents().ServeHTTP(res, request)
+		close(done)
+	}()
+
+	events <- models.TaskEvent{TaskId: 1, Status: models.StatusDone}
+	events <- models.TaskEvent{TaskId: 2, Status: models.StatusError}
+	cancel()
+	<-done
+
+	require.Equal(t, http.StatusOK, res.Code)
+	require.Equal(t, 2, strings.Count(res.Body.String(), "event: status\n"))
+}
+
+func TestTaskHandlers_StreamEventsClosed(t *testing.T) {
+	t.Parallel()
+	ctrx := gomock.NewController(t)
+	defer ctrx.Finish()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	mockUseCase := mock.NewMockUseCase(ctrx)
+
+	handlers := NewTaskHandlers(nil, sugar, mockUseCase)
+
+	request := httptest.NewRequest(http.MethodGet, "/tasks/events", nil)
+	res := httptest.NewRecorder()
+
+	unsubscribed := false
+	mockUseCase.EXPECT().Subscribe(gomock.Any(), models.TaskEventFilter{}).Return(make(chan models.TaskEvent), func() { unsubscribed = true }, nil)
+
+	done := make(chan struct{})
+	go func() {
+		handlers.StreamEvents().ServeHTTP(res, request)
+		close(done)
+	}()
+
+	handlers.Close()
+	handlers.Close()
+	<-done
+
+	require.Equal(t, http.StatusOK, res.Code)
+	require.True(t, unsubscribed)
+}
+
 func TestTaskHandlers_GetStringId(t *testing.T) {
 	t.Parallel()
 	ctrx := gomock.NewController(t)
@@ -270,6 +651,235 @@ func TestTaskHandlers_GetNegativeId(t *testing.T) {
 	require.NotEmpty(t, body)
 }
 
+func TestTaskHandlers_GetResponse(t *testing.T) {
+	t.Parallel()
+	ctrx := gomock.NewController(t)
+	defer ctrx.Finish()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	mockUseCase := mock.NewMockUseCase(ctrx)
+
+	handlers := NewTaskHandlers(nil, sugar, mockUseCase)
+
+	request := httptest.NewRequest(http.MethodGet, "/task/{id}/response", nil)
+
+	params := make(map[string]string)
+	params["id"] = "1"
+	request = addChiURLParams(request, params)
+
+	res := httptest.NewRecorder()
+
+	response := &models.TaskResponse{TaskId: 1, ContentType: "application/xml", Body: []byte("<ok/>"), Truncated: true}
+
+	mockUseCase.EXPECT().GetResponse(gomock.Any(), int64(1)).Return(response, nil)
+
+	handlers.GetResponse().ServeHTTP(res, request)
+
+	require.Equal(t, http.StatusOK, res.Code)
+	require.Equal(t, "application/xml", res.Header().Get("Content-Type"))
+	require.Equal(t, "true", res.Header().Get("X-Response-Truncated"))
+	require.Equal(t, "<ok/>", res.Body.String())
+}
+
+func TestTaskHandlers_GetResponseNotFound(t *testing.T) {
+	t.Parallel()
+	ctrx := gomock.NewController(t)
+	defer ctrx.Finish()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	mockUseCase := mock.NewMockUseCase(ctrx)
+
+	handlers := NewTaskHandlers(nil, sugar, mockUseCase)
+
+	request := httptest.NewRequest(http.MethodGet, "/task/{id}/response", nil)
+
+	params := make(map[string]string)
+	params["id"] = "1"
+	request = addChiURLParams(request, params)
+
+	res := httptest.NewRecorder()
+
+	mockUseCase.EXPECT().GetResponse(gomock.Any(), int64(1)).Return(nil, sql.ErrNoRows)
+
+	handlers.GetResponse().ServeHTTP(res, request)
+
+	require.Equal(t, http.StatusNotFound, res.Code)
+}
+
+func TestTaskHandlers_GetAttempts(t *testing.T) {
+	t.Parallel()
+	ctrx := gomock.NewController(t)
+	defer ctrx.Finish()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	mockUseCase := mock.NewMockUseCase(ctrx)
+
+	handlers := NewTaskHandlers(nil, sugar, mockUseCase)
+
+	request := httptest.NewRequest(http.MethodGet, "/task/{id}/attempts", nil)
+
+	params := make(map[string]string)
+	params["id"] = "1"
+	request = addChiURLParams(request, params)
+
+	res := httptest.NewRecorder()
+
+	errText := "connection refused"
+	status := int64(200)
+	attempts := []models.TaskAttempt{
+		{TaskId: 1, Attempt: 1, DurationMs: 15, Error: &errText},
+		{TaskId: 1, Attempt: 2, DurationMs: 20, ResponseStatus: &status},
+	}
+
+	mockUseCase.EXPECT().GetAttempts(gomock.Any(), int64(1)).Return(attempts, nil)
+
+	handlers.GetAttempts().ServeHTTP(res, request)
+
+	var response []dto.TaskAttemptResponse
+
+	require.Equal(t, http.StatusOK, res.Code)
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), &response))
+	require.Len(t, response, 2)
+	require.Equal(t, errText, *response[0].Error)
+	require.Equal(t, status, *response[1].ResponseStatus)
+}
+
+func TestTaskHandlers_Cancel(t *testing.T) {
+	t.Parallel()
+	ctrx := gomock.NewController(t)
+	defer ctrx.Finish()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	mockUseCase := mock.NewMockUseCase(ctrx)
+
+	handlers := NewTaskHandlers(nil, sugar, mockUseCase)
+
+	request := httptest.NewRequest(http.MethodPost, "/task/{id}/cancel", nil)
+
+	params := make(map[string]string)
+	params["id"] = "1"
+	request = addChiURLParams(request, params)
+
+	res := httptest.NewRecorder()
+
+	mockUseCase.EXPECT().Cancel(gomock.Any(), int64(1)).Return(models.StatusInProcess, nil)
+
+	handlers.Cancel().ServeHTTP(res, request)
+
+	var response dto.CancelTaskResponse
+
+	require.Equal(t, http.StatusOK, res.Code)
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), &response))
+	require.Equal(t, dto.CancelTaskResponse{ID: 1, Status: models.StatusCancelled, PreviousStatus: models.StatusInProcess}, response)
+}
+
+func TestTaskHandlers_CancelFinishedTask(t *testing.T) {
+	t.Parallel()
+	ctrx := gomock.NewController(t)
+	defer ctrx.Finish()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	mockUseCase := mock.NewMockUseCase(ctrx)
+
+	handlers := NewTaskHandlers(nil, sugar, mockUseCase)
+
+	request := httptest.NewRequest(http.MethodPost, "/task/{id}/cancel", nil)
+
+	params := make(map[string]string)
+	params["id"] = "1"
+	request = addChiURLParams(request, params)
+
+	res := httptest.NewRecorder()
+
+	mockUseCase.EXPECT().Cancel(gomock.Any(), int64(1)).
+		Return("", httpErrors.NewRestError(http.StatusConflict, "task is done: invalid status transition", nil))
+
+	handlers.Cancel().ServeHTTP(res, request)
+
+	require.Equal(t, http.StatusConflict, res.Code)
+}
+
+func TestTaskHandlers_List(t *testing.T) {
+	t.Parallel()
+	ctrx := gomock.NewController(t)
+	defer ctrx.Finish()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	mockUseCase := mock.NewMockUseCase(ctrx)
+
+	handlers := NewTaskHandlers(nil, sugar, mockUseCase)
+
+	request := httptest.NewRequest(http.MethodGet, "/tasks?status=done,error&method=GET&host=www.google.com&minCode=200&maxCode=299&sort=id&order=asc&limit=1", nil)
+
+	res := httptest.NewRecorder()
+
+	page := &models.TaskPage{
+		Tasks: []models.Task{{Id: 1, Url: "https://www.google.com", Method: "GET", Status: models.StatusDone}},
+		Next:  &models.TaskCursor{Id: 1},
+	}
+
+	mockUseCase.EXPECT().List(gomock.Any(), gomock.Cond(func(x models.TaskFilter) bool {
+		return len(x.Statuses) == 2 &&
+			x.Statuses[0] == models.StatusDone &&
+			x.Statuses[1] == models.StatusError &&
+			x.Method == "GET" &&
+			x.Host == "www.google.com" &&
+			*x.MinResponseStatus == 200 &&
+			*x.MaxResponseStatus == 299 &&
+			x.SortBy == models.SortById &&
+			!x.SortDesc &&
+			x.Limit == 1 &&
+			x.After == nil
+	})).Return(page, nil)
+
+	handlers.List().ServeHTTP(res, request)
+
+	var response dto.TaskListResponse
+
+	require.Equal(t, http.StatusOK, res.Code)
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), &response))
+	require.Len(t, response.Tasks, 1)
+	require.NotEmpty(t, response.NextCursor)
+
+	request = httptest.NewRequest(http.MethodGet, "/tasks?cursor="+response.NextCursor, nil)
+	res = httptest.NewRecorder()
+
+	mockUseCase.EXPECT().List(gomock.Any(), gomock.Cond(func(x models.TaskFilter) bool {
+		return x.After != nil && x.After.Id == 1 && x.SortDesc
+	})).Return(&models.TaskPage{}, nil)
+
+	handlers.List().ServeHTTP(res, request)
+
+	require.Equal(t, http.StatusOK, res.Code)
+}
+
+func TestTaskHandlers_ListWithInvalidQuery(t *testing.T) {
+	t.Parallel()
+	ctrx := gomock.NewController(t)
+	defer ctrx.Finish()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	mockUseCase := mock.NewMockUseCase(ctrx)
+
+	handlers := NewTaskHandlers(nil, sugar, mockUseCase)
+
+	for _, query := range []string{"minCode=abc", "createdFrom=yesterday", "cursor=bm90IGpzb24", "order=up"} {
+		request := httptest.NewRequest(http.MethodGet, "/tasks?"+query, nil)
+		res := httptest.NewRecorder()
+
+		handlers.List().ServeHTTP(res, request)
+
+		require.Equal(t, http.StatusBadRequest, res.Code, query)
+	}
+}
+
 func addChiURLParams(r *http.Request, params map[string]string) *http.Request {
 	ctx := chi.NewRouteContext()
 	for k, v := range params {
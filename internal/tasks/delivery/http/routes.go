@@ -5,4 +5,16 @@ import "github.com/go-chi/chi/v5"
 func MapTasksRoutes(router chi.Router, handlers *TaskHandlers) {
 	router.Post("/task", handlers.Create())
 	router.Get("/task/{id}", handlers.Get())
+	router.Get("/task/{id}/response", handlers.GetResponse())
+	router.Get("/task/{id}/attempts", handlers.GetAttempts())
+	router.Post("/task/{id}/cancel", handlers.Cancel())
+	router.Get("/tasks", handlers.List())
+	router.Post("/tasks/batch", handlers.CreateBatch())
+	router.Get("/tasks/batch/{id}", handlers.GetBatch())
+}
+
+// MapTaskEventsRoutes maps the long-lived event streams, which must not be subject to request timeouts.
+func MapTaskEventsRoutes(router chi.Router, handlers *TaskHandlers) {
+	router.Get("/task/{id}/events", handlers.Events())
+	router.Get("/tasks/events", handlers.StreamEvents())
 }
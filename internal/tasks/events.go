@@ -0,0 +1,13 @@
+//go:generate mockgen -source events.go -destination mock/events.go -package mock
+package tasks
+
+import "http-task-executor/internal/models"
+
+type EventPublisher interface {
+	Publish(event models.TaskEvent)
+}
+
+type EventBus interface {
+	EventPublisher
+	Subscribe(filter models.TaskEventFilter) (<-chan models.TaskEvent, func())
+}
@@ -0,0 +1,65 @@
+package events
+
+import (
+	"http-task-executor/internal/logger"
+	"http-task-executor/internal/models"
+	"sync"
+)
+
+const defaultBufferSize = 64
+
+type subscriber struct {
+	filter models.TaskEventFilter
+	ch     chan models.TaskEvent
+}
+
+// Bus fans task events out to the subscribers of this instance. Publishing never
+// blocks: a subscriber that falls behind by more than its buffer misses events.
+type Bus struct {
+	log         logger.Logger
+	bufferSize  int
+	mu          sync.RWMutex
+	subscribers map[*subscriber]struct{}
+}
+
+func NewBus(log logger.Logger, bufferSize int) *Bus {
+	if bufferSize <= 0 {
+		bufferSize = defaultBufferSize
+	}
+	return &Bus{log: log, bufferSize: bufferSize, subscribers: make(map[*subscriber]struct{})}
+}
+
+func (b *Bus) Publish(event models.TaskEvent) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for s := range b.subscribers {
+		if !s.filter.Match(event) {
+			continue
+		}
+		select {
+		case s.ch <- event:
+		default:
+			b.log.Infof("Bus.Publish: subscriber is full, dropping event of task %v", event.TaskId)
+		}
+	}
+}
+
+// Subscribe returns the channel of events matching the filter and the function
+// cancelling the subscription, which closes the channel.
+func (b *Bus) Subscribe(filter models.TaskEventFilter) (<-chan models.TaskEvent, func()) {
+	s := &subscriber{filter: filter, ch: make(chan models.TaskEvent, b.bufferSize)}
+
+	b.mu.Lock()
+	b.subscribers[s] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	return s.ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers, s)
+			b.mu.Unlock()
+			close(s.ch)
+		})
+	}
+}
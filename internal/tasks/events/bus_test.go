@@ -0,0 +1,71 @@
+package events
+
+import (
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"http-task-executor/internal/models"
+	"testing"
+)
+
+func TestBus_PublishMatchingSubscribers(t *testing.T) {
+	t.Parallel()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	bus := NewBus(sugar, 1)
+
+	all, unsubscribeAll := bus.Subscribe(models.TaskEventFilter{})
+	defer unsubscribeAll()
+	task, unsubscribeTask := bus.Subscribe(models.TaskEventFilter{TaskId: 2})
+	defer unsubscribeTask()
+	finished, unsubscribeFinished := bus.Subscribe(models.TaskEventFilter{Statuses: []string{models.StatusDone}})
+	defer unsubscribeFinished()
+
+	bus.Publish(models.TaskEvent{TaskId: 1, Status: models.StatusDone})
+	// Dropped for the subscriber of all tasks, whose buffer is full.
+	bus.Publish(models.TaskEvent{TaskId: 2, Status: models.StatusInProcess})
+
+	require.Equal(t, models.TaskEvent{TaskId: 1, Status: models.StatusDone}, <-all)
+	require.Equal(t, models.TaskEvent{TaskId: 2, Status: models.StatusInProcess}, <-task)
+	require.Equal(t, models.TaskEvent{TaskId: 1, Status: models.StatusDone}, <-finished)
+	require.Empty(t, all)
+	require.Empty(t, finished)
+}
+
+func TestBus_Unsubscribe(t *testing.T) {
+	t.Parallel()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	bus := NewBus(sugar, 0)
+
+	events, unsubscribe := bus.Subscribe(models.TaskEventFilter{})
+	unsubscribe()
+	unsubscribe()
+
+	bus.Publish(models.TaskEvent{TaskId: 1, Status: models.StatusDone})
+
+	_, ok := <-events
+	require.False(t, ok)
+}
+
+func TestBus_PublishToClient(t *testing.T) {
+	t.Parallel()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	bus := NewBus(sugar, 2)
+
+	acme := "acme"
+	other := "other"
+	events, unsubscribe := bus.Subscribe(models.TaskEventFilter{Client: &acme})
+	defer unsubscribe()
+
+	bus.Publish(models.TaskEvent{TaskId: 1, Status: models.StatusDone, Client: &other})
+	bus.Publish(models.TaskEvent{TaskId: 2, Status: models.StatusDone})
+	bus.Publish(models.TaskEvent{TaskId: 3, Status: models.StatusDone, Client: &acme})
+
+	require.Equal(t, int64(3), (<-events).TaskId)
+	require.Empty(t, events)
+}
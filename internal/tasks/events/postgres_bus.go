@@ -0,0 +1,116 @@
+package events
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/jmoiron/sqlx"
+	"http-task-executor/internal/logger"
+	"http-task-executor/internal/models"
+	"http-task-executor/internal/tasks"
+	"sync"
+	"time"
+)
+
+const reconnectInterval = time.Second
+
+// PostgresBus propagates task events between instances. Events are published with
+// NOTIFY and every instance LISTENs for them to fan them out to its local subscribers,
+// so a subscriber receives events of tasks processed by any instance.
+type PostgresBus struct {
+	log   logger.Logger
+	db    *sqlx.DB
+	repo  tasks.Repository
+	local *Bus
+	wg    sync.WaitGroup
+}
+
+func NewPostgresBus(log logger.Logger, db *sqlx.DB, repo tasks.Repository, local *Bus) *PostgresBus {
+	return &PostgresBus{log: log, db: db, repo: repo, local: local}
+}
+
+// Publish notifies all instances, this one included. When the notification can't be
+// sent the event is still delivered to local subscribers.
+func (b *PostgresBus) Publish(event models.TaskEvent) {
+	err := b.repo.NotifyEvent(context.Background(), event)
+	if err != nil {
+		b.log.Errorf("PostgresBus.Publish.NotifyEvent : %v", err)
+		b.local.Publish(event)
+	}
+}
+
+func (b *PostgresBus) Subscribe(filter models.TaskEventFilter) (<-chan models.TaskEvent, func()) {
+	return b.local.Subscribe(filter)
+}
+
+func (b *PostgresBus) Start(ctx context.Context) {
+	b.log.Infof("Listening for task events on channel %s", models.TaskEventsChannel)
+	b.wg.Add(1)
+	go b.run(ctx)
+}
+
+func (b *PostgresBus) Wait() {
+	b.wg.Wait()
+}
+
+func (b *PostgresBus) run(ctx context.Context) {
+	defer b.wg.Done()
+
+	for {
+		err := b.listen(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		b.log.Errorf("PostgresBus.run.listen : %v, reconnecting in %s", err, reconnectInterval)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(reconnectInterval):
+		}
+	}
+}
+
+func (b *PostgresBus) listen(ctx context.Context) error {
+	conn, err := b.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err := conn.Close()
+		if err != nil {
+			b.log.Errorf("PostgresBus.listen.conn.Close : %v", err)
+		}
+	}()
+
+	return conn.Raw(func(driverConn any) error {
+		pgxConn, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return errors.New("listening for notifications requires the pgx driver")
+		}
+		_, err := pgxConn.Conn().Exec(ctx, "LISTEN "+models.TaskEventsChannel)
+		if err != nil {
+			return err
+		}
+		for {
+			notification, err := pgxConn.Conn().WaitForNotification(ctx)
+			if err != nil {
+				// The connection is still subscribed to the channel, keep it out of the pool.
+				return errors.Join(err, driver.ErrBadConn)
+			}
+			b.handle(notification.Payload)
+		}
+	})
+}
+
+func (b *PostgresBus) handle(payload string) {
+	var event models.TaskEvent
+	err := json.Unmarshal([]byte(payload), &event)
+	if err != nil {
+		b.log.Errorf("PostgresBus.handle.Unmarshal : %v", err)
+		return
+	}
+	b.local.Publish(event)
+}
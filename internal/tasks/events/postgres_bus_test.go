@@ -0,0 +1,62 @@
+package events
+
+import (
+	"errors"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"http-task-executor/internal/models"
+	"http-task-executor/internal/tasks/mock"
+	"testing"
+)
+
+func TestPostgresBus_Publish(t *testing.T) {
+	t.Parallel()
+	ctrx := gomock.NewController(t)
+	defer ctrx.Finish()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	mockTasksRepo := mock.NewMockRepository(ctrx)
+
+	bus := NewPostgresBus(sugar, nil, mockTasksRepo, NewBus(sugar, 0))
+	received, unsubscribe := bus.Subscribe(models.TaskEventFilter{})
+	defer unsubscribe()
+
+	t.Run("Delivered through notification", func(t *testing.T) {
+		event := models.TaskEvent{TaskId: 1, Status: models.StatusDone}
+		mockTasksRepo.EXPECT().NotifyEvent(gomock.Any(), event).Return(nil).Times(1)
+
+		bus.Publish(event)
+
+		require.Empty(t, received)
+	})
+
+	t.Run("Delivered locally when notification fails", func(t *testing.T) {
+		event := models.TaskEvent{TaskId: 2, Status: models.StatusDone}
+		mockTasksRepo.EXPECT().NotifyEvent(gomock.Any(), event).Return(errors.New("connection refused")).Times(1)
+
+		bus.Publish(event)
+
+		require.Equal(t, event, <-received)
+	})
+}
+
+func TestPostgresBus_Handle(t *testing.T) {
+	t.Parallel()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	bus := NewPostgresBus(sugar, nil, nil, NewBus(sugar, 0))
+	received, unsubscribe := bus.Subscribe(models.TaskEventFilter{})
+	defer unsubscribe()
+
+	bus.handle("not json")
+	bus.handle(`{"taskId":3,"status":"cancelled","at":"2026-10-17T10:00:00Z"}`)
+
+	event := <-received
+	require.Equal(t, int64(3), event.TaskId)
+	require.Equal(t, models.StatusCancelled, event.Status)
+	require.Empty(t, received)
+}
@@ -9,8 +9,11 @@ import (
 
 type Executor interface {
 	ExecuteTask(task models.Task)
+	Cancel(id int64) bool
 }
 
 type ClientProvider interface {
 	Client() *http.Client
+	// ProfileClient returns the client presenting the named TLS profile.
+	ProfileClient(name string) (*http.Client, error)
 }
@@ -0,0 +1,180 @@
+package executor
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"http-task-executor/internal/config"
+	"http-task-executor/internal/egress"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+)
+
+var ErrUnknownTLSProfile = errors.New("unknown tls profile")
+
+var tlsVersions = map[string]uint16{
+	"":    tls.VersionTLS12,
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+var proxyPorts = map[string]string{
+	"http":    "80",
+	"https":   "443",
+	"socks5":  "1080",
+	"socks5h": "1080",
+}
+
+// ClientProvider shares one client, so connections to the targets are kept alive and reused,
+// and one more per TLS profile. Connections and redirects are checked against the egress policy.
+type ClientProvider struct {
+	client   *http.Client
+	profiles map[string]*http.Client
+}
+
+func NewClientProvider(cfg config.HttpClientConfig, policy *egress.Policy) (*ClientProvider, error) {
+	client, err := newClient(cfg, cfg.TLS, policy)
+	if err != nil {
+		return nil, err
+	}
+
+	profiles := make(map[string]*http.Client, len(cfg.TLSProfiles))
+	for name, profile := range cfg.TLSProfiles {
+		profiles[name], err = newClient(cfg, profile, policy)
+		if err != nil {
+			return nil, fmt.Errorf("tls profile %s: %w", name, err)
+		}
+	}
+	return &ClientProvider{client: client, profiles: profiles}, nil
+}
+
+func (c *ClientProvider) Client() *http.Client {
+	return c.client
+}
+
+func (c *ClientProvider) ProfileClient(name string) (*http.Client, error) {
+	client, ok := c.profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("%w %s", ErrUnknownTLSProfile, name)
+	}
+	return client, nil
+}
+
+func newClient(cfg config.HttpClientConfig, tlsCfg config.TLSConfig, policy *egress.Policy) (*http.Client, error) {
+	transport, err := newTransport(cfg, tlsCfg, policy)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{Transport: transport, CheckRedirect: policy.CheckRedirect}, nil
+}
+
+func newTransport(cfg config.HttpClientConfig, tlsCfg config.TLSConfig, policy *egress.Policy) (http.RoundTripper, error) {
+	tlsConfig, err := newTLSConfig(tlsCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := &net.Dialer{Timeout: cfg.DialTimeout, KeepAlive: cfg.KeepAlive, Control: policy.Control}
+	transport := &http.Transport{
+		DialContext:           dialer.DialContext,
+		TLSClientConfig:       tlsConfig,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          cfg.MaxIdleConns,
+		MaxIdleConnsPerHost:   cfg.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       cfg.MaxConnsPerHost,
+		IdleConnTimeout:       cfg.IdleConnTimeout,
+		TLSHandshakeTimeout:   cfg.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: cfg.ResponseHeaderTimeout,
+		ExpectContinueTimeout: cfg.ExpectContinueTimeout,
+	}
+	if cfg.Proxy == "" {
+		return transport, nil
+	}
+
+	proxyUrl, err := url.Parse(cfg.Proxy)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy %s: %w", cfg.Proxy, err)
+	}
+	port, ok := proxyPorts[proxyUrl.Scheme]
+	if !ok {
+		return nil, fmt.Errorf("invalid proxy %s: unsupported scheme %s", cfg.Proxy, proxyUrl.Scheme)
+	}
+	if proxyUrl.Port() != "" {
+		port = proxyUrl.Port()
+	}
+	proxyAddr := net.JoinHostPort(proxyUrl.Hostname(), port)
+
+	transport.Proxy = func(req *http.Request) (*url.URL, error) {
+		host := strings.TrimSuffix(strings.ToLower(req.URL.Hostname()), ".")
+		if slices.ContainsFunc(cfg.NoProxy, func(pattern string) bool { return egress.MatchHost(strings.ToLower(pattern), host) }) {
+			return nil, nil
+		}
+		return proxyUrl, nil
+	}
+	// The proxy is trusted and usually internal, so only direct connections go through the policy.
+	proxyDialer := &net.Dialer{Timeout: cfg.DialTimeout, KeepAlive: cfg.KeepAlive}
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if addr == proxyAddr {
+			return proxyDialer.DialContext(ctx, network, addr)
+		}
+		return dialer.DialContext(ctx, network, addr)
+	}
+	return &proxiedTransport{next: transport, policy: policy}, nil
+}
+
+// proxiedTransport checks the target of every request itself, as the proxy resolves and dials it.
+type proxiedTransport struct {
+	next   http.RoundTripper
+	policy *egress.Policy
+}
+
+func (t *proxiedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	err := t.policy.CheckURL(req.Context(), req.URL.String())
+	if err != nil {
+		return nil, err
+	}
+	return t.next.RoundTrip(req)
+}
+
+func newTLSConfig(cfg config.TLSConfig) (*tls.Config, error) {
+	minVersion, ok := tlsVersions[cfg.MinVersion]
+	if !ok {
+		return nil, fmt.Errorf("invalid tls min version %s", cfg.MinVersion)
+	}
+	tlsConfig := &tls.Config{MinVersion: minVersion, ServerName: cfg.ServerName, InsecureSkipVerify: cfg.InsecureSkipVerify}
+
+	if cfg.CAFile != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			return nil, err
+		}
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		if cfg.CertFile == "" || cfg.KeyFile == "" {
+			return nil, errors.New("tls client certificate needs both cert_file and key_file")
+		}
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
@@ -0,0 +1,305 @@
+package executor
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"github.com/stretchr/testify/require"
+	"http-task-executor/internal/config"
+	"http-task-executor/internal/egress"
+	"http-task-executor/internal/models"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestClientProvider_Egress(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	t.Run("Denied address is not dialed", func(t *testing.T) {
+		policy, err := egress.NewPolicy(config.EgressConfig{})
+		require.NoError(t, err)
+
+		_, err = providerClient(t, clientConfig(), policy).Get(server.URL)
+		require.ErrorIs(t, err, egress.ErrDenied)
+		require.Equal(t, models.ErrorClassOther, classifyError(err))
+	})
+
+	t.Run("Allowed address", func(t *testing.T) {
+		policy, err := egress.NewPolicy(config.EgressConfig{AllowCIDRs: []string{"127.0.0.0/8"}})
+		require.NoError(t, err)
+
+		resp, err := providerClient(t, clientConfig(), policy).Get(server.URL)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+}
+
+func TestClientProvider_Proxy(t *testing.T) {
+	t.Parallel()
+
+	proxied := make(chan string, 1)
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied <- r.URL.String()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer proxy.Close()
+
+	direct := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer direct.Close()
+
+	// Private addresses are denied, but the proxy itself is trusted.
+	policy, err := egress.NewPolicy(config.EgressConfig{AllowCIDRs: []string{"127.0.0.2/32"}})
+	require.NoError(t, err)
+
+	cfg := clientConfig()
+	cfg.Proxy = proxy.URL
+	cfg.NoProxy = []string{"*.direct.test", "127.0.0.1"}
+	client := providerClient(t, cfg, policy)
+
+	t.Run("Request goes through the proxy", func(t *testing.T) {
+		resp, err := client.Get("http://93.184.215.14/path")
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		require.Equal(t, "http://93.184.215.14/path", <-proxied)
+	})
+
+	t.Run("Denied target is not sent to the proxy", func(t *testing.T) {
+		_, err := client.Get("http://169.254.169.254/latest/meta-data/")
+		require.ErrorIs(t, err, egress.ErrDenied)
+		require.Empty(t, proxied)
+	})
+
+	t.Run("No proxy host is dialed directly through the policy", func(t *testing.T) {
+		_, err := client.Get(direct.URL)
+		require.ErrorIs(t, err, egress.ErrDenied)
+		require.Empty(t, proxied)
+	})
+}
+
+func TestClientProvider_TLS(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	ca, caKey := newCertificate(t, nil, nil, "test-ca")
+	serverCert, serverKey := newCertificate(t, ca, caKey, "127.0.0.1")
+	clientCert, clientKey := newCertificate(t, ca, caKey, "task-executor")
+	caFile := writePem(t, dir, "ca.pem", "CERTIFICATE", ca.Raw)
+	certFile := writePem(t, dir, "client.pem", "CERTIFICATE", clientCert.Raw)
+	keyFile := writePem(t, dir, "client-key.pem", "EC PRIVATE KEY", marshalKey(t, clientKey))
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{serverCert.Raw}, PrivateKey: serverKey}},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	t.Run("Custom CA and client certificate", func(t *testing.T) {
+		cfg := clientConfig()
+		cfg.TLS = config.TLSConfig{CAFile: caFile, CertFile: certFile, KeyFile: keyFile, MinVersion: "1.3"}
+
+		resp, err := providerClient(t, cfg, nil).Get(server.URL)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, uint16(tls.VersionTLS13), resp.TLS.Version)
+	})
+
+	t.Run("Unknown authority", func(t *testing.T) {
+		cfg := clientConfig()
+		cfg.TLS.CertFile = certFile
+		cfg.TLS.KeyFile = keyFile
+
+		_, err := providerClient(t, cfg, nil).Get(server.URL)
+		require.Error(t, err)
+		require.Equal(t, models.ErrorClassTLS, classifyError(err))
+	})
+
+	t.Run("Missing client certificate", func(t *testing.T) {
+		cfg := clientConfig()
+		cfg.TLS.CAFile = caFile
+
+		_, err := providerClient(t, cfg, nil).Get(server.URL)
+		require.Error(t, err)
+	})
+
+	t.Run("Insecure skip verify", func(t *testing.T) {
+		cfg := clientConfig()
+		cfg.TLS = config.TLSConfig{CertFile: certFile, KeyFile: keyFile, MinVersion: "1.2", InsecureSkipVerify: true}
+
+		resp, err := providerClient(t, cfg, nil).Get(server.URL)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+	})
+}
+
+func TestClientProvider_TLSProfiles(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	ca, caKey := newCertificate(t, nil, nil, "test-ca")
+	serverCert, serverKey := newCertificate(t, ca, caKey, "partner.internal")
+	clientCert, clientKey := newCertificate(t, ca, caKey, "task-executor")
+	caFile := writePem(t, dir, "ca.pem", "CERTIFICATE", ca.Raw)
+	certFile := writePem(t, dir, "client.pem", "CERTIFICATE", clientCert.Raw)
+	keyFile := writePem(t, dir, "client-key.pem", "EC PRIVATE KEY", marshalKey(t, clientKey))
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{serverCert.Raw}, PrivateKey: serverKey}},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	cfg := clientConfig()
+	cfg.TLSProfiles = map[string]config.TLSConfig{
+		"partner-mtls":   {CAFile: caFile, CertFile: certFile, KeyFile: keyFile, ServerName: "partner.internal"},
+		"partner-server": {CAFile: caFile, ServerName: "partner.internal"},
+	}
+	provider, err := NewClientProvider(cfg, nil)
+	require.NoError(t, err)
+
+	t.Run("Profile presents its client certificate", func(t *testing.T) {
+		client, err := provider.ProfileClient("partner-mtls")
+		require.NoError(t, err)
+
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("Profiles don't share certificates", func(t *testing.T) {
+		client, err := provider.ProfileClient("partner-server")
+		require.NoError(t, err)
+
+		_, err = client.Get(server.URL)
+		require.Error(t, err)
+
+		_, err = provider.Client().Get(server.URL)
+		require.Error(t, err)
+	})
+
+	t.Run("Unknown profile", func(t *testing.T) {
+		_, err := provider.ProfileClient("other")
+		require.ErrorIs(t, err, ErrUnknownTLSProfile)
+	})
+
+	t.Run("Invalid profile", func(t *testing.T) {
+		cfg := clientConfig()
+		cfg.TLSProfiles = map[string]config.TLSConfig{"broken": {CertFile: certFile}}
+
+		_, err := NewClientProvider(cfg, nil)
+		require.ErrorContains(t, err, "tls profile broken")
+	})
+}
+
+func TestNewClientProvider_InvalidConfig(t *testing.T) {
+	t.Parallel()
+
+	invalid := map[string]func(cfg *config.HttpClientConfig){
+		"Min version":      func(cfg *config.HttpClientConfig) { cfg.TLS.MinVersion = "1.4" },
+		"Missing CA file":  func(cfg *config.HttpClientConfig) { cfg.TLS.CAFile = "/nonexistent/ca.pem" },
+		"Cert without key": func(cfg *config.HttpClientConfig) { cfg.TLS.CertFile = "/nonexistent/client.pem" },
+		"Proxy scheme":     func(cfg *config.HttpClientConfig) { cfg.Proxy = "ftp://proxy:21" },
+	}
+	for name, apply := range invalid {
+		t.Run(name, func(t *testing.T) {
+			cfg := clientConfig()
+			apply(&cfg)
+			_, err := NewClientProvider(cfg, nil)
+			require.Error(t, err)
+		})
+	}
+}
+
+func clientConfig() config.HttpClientConfig {
+	return config.HttpClientConfig{
+		TLS:                 config.TLSConfig{MinVersion: "1.2"},
+		MaxIdleConns:        10,
+		MaxIdleConnsPerHost: 2,
+		IdleConnTimeout:     time.Second,
+		DialTimeout:         time.Second,
+		TLSHandshakeTimeout: time.Second,
+	}
+}
+
+func providerClient(t *testing.T, cfg config.HttpClientConfig, policy *egress.Policy) *http.Client {
+	provider, err := NewClientProvider(cfg, policy)
+	require.NoError(t, err)
+	return provider.Client()
+}
+
+// newCertificate issues a certificate for the name signed by the parent, or a self-signed CA without one.
+func newCertificate(t *testing.T, parent *x509.Certificate, parentKey *ecdsa.PrivateKey, name string) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	if ip := net.ParseIP(name); ip != nil {
+		template.IPAddresses = []net.IP{ip}
+	} else {
+		template.DNSNames = []string{name}
+	}
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert, key
+}
+
+func marshalKey(t *testing.T, key *ecdsa.PrivateKey) []byte {
+	der, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return der
+}
+
+func writePem(t *testing.T, dir string, name string, blockType string, der []byte) string {
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600))
+	return path
+}
@@ -1,48 +1,170 @@
 package executor
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"http-task-executor/internal/breaker"
 	"http-task-executor/internal/logger"
+	"http-task-executor/internal/metrics"
 	"http-task-executor/internal/models"
 	"http-task-executor/internal/tasks"
+	"http-task-executor/internal/tracing"
 	"io"
 	"net/http"
+	"net/url"
 	"strings"
+	"sync"
 	"time"
 )
 
+var (
+	errTaskCancelled = errors.New("task cancelled")
+	errInterrupted   = errors.New("executor interrupted")
+)
+
 type Executor struct {
-	log            logger.Logger
-	repo           tasks.Repository
-	timeout        time.Duration
-	clientProvider tasks.ClientProvider
+	log                 logger.Logger
+	repo                tasks.Repository
+	timeout             time.Duration
+	maxResponseBodySize int64
+	heartbeatInterval   time.Duration
+	clientProvider      tasks.ClientProvider
+	events              tasks.EventBus
+	limiter             *HostLimiter
+	breakers            *breaker.Breakers
+	mu                  sync.Mutex
+	running             map[int64]context.CancelCauseFunc
+	interrupted         bool
+	wg                  sync.WaitGroup
+}
+
+func NewExecutor(log logger.Logger, repo tasks.Repository, clientProvider tasks.ClientProvider, events tasks.EventBus, limiter *HostLimiter, breakers *breaker.Breakers, timeout time.Duration, maxResponseBodySize int64, heartbeatInterval time.Duration) *Executor {
+	return &Executor{log: log, repo: repo, clientProvider: clientProvider, events: events, limiter: limiter, breakers: breakers, timeout: timeout, maxResponseBodySize: maxResponseBodySize, heartbeatInterval: heartbeatInterval, running: make(map[int64]context.CancelCauseFunc)}
 }
 
-type ClientProvider struct {
+// Start watches cancellations made through any instance, aborting the tasks running on this executor,
+// and keeps extending the execution lease of the running tasks, so the reaper leaves them alone.
+func (e *Executor) Start(ctx context.Context) {
+	if e.heartbeatInterval > 0 {
+		e.wg.Add(1)
+		go e.heartbeat(ctx)
+	}
+
+	cancellations, unsubscribe := e.events.Subscribe(models.TaskEventFilter{Statuses: []string{models.StatusCancelled}})
+	e.wg.Add(1)
+	go func() {
+		defer e.wg.Done()
+		defer unsubscribe()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event := <-cancellations:
+				if e.Cancel(event.TaskId) {
+					e.log.Infof("executor.Start: task %v cancelled", event.TaskId)
+				}
+			}
+		}
+	}()
 }
 
-func (c *ClientProvider) Client() *http.Client {
-	return &http.Client{}
+func (e *Executor) heartbeat(ctx context.Context) {
+	defer e.wg.Done()
+
+	ticker := time.NewTicker(e.heartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		e.mu.Lock()
+		ids := make([]int64, 0, len(e.running))
+		for id := range e.running {
+			ids = append(ids, id)
+		}
+		e.mu.Unlock()
+
+		err := e.repo.Heartbeat(ctx, ids)
+		if err != nil && ctx.Err() == nil {
+			e.log.Errorf("executor.heartbeat.Heartbeat : %v", err)
+		}
+	}
 }
 
-func NewExecutor(log logger.Logger, repo tasks.Repository, clientProvider tasks.ClientProvider, timeout time.Duration) *Executor {
-	return &Executor{log: log, repo: repo, clientProvider: clientProvider, timeout: timeout}
+func (e *Executor) Wait() {
+	e.wg.Wait()
+}
+
+// Cancel aborts the in-flight execution of the task, reporting whether it was running on this executor.
+func (e *Executor) Cancel(id int64) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	cancel, ok := e.running[id]
+	if ok {
+		cancel(errTaskCancelled)
+	}
+	return ok
 }
 
 func (e *Executor) ExecuteTask(task models.Task) {
-	ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
+	parent, cancelCause := context.WithCancelCause(context.Background())
+	parent, span := tracing.Start(tracing.WithParent(parent, task.TraceId, task.SpanId, task.TraceSampled), "executor.ExecuteTask",
+		trace.WithAttributes(attribute.Int64("task.id", task.Id)))
+	defer span.End()
+	e.register(task.Id, cancelCause)
+	defer e.unregister(task.Id)
 
+	e.log.Infof("executor.ExecuteTask: task %v with method %s and url %s", task.Id, task.Method, task.Url)
+	e.events.Publish(models.NewTaskEvent(&task))
+
+	// An open breaker short-circuits the task before it waits for the limits of its host.
+	host := hostname(task.Url)
+	report, err := e.breakers.Allow(host)
+	if err != nil {
+		attempt := &models.TaskAttempt{TaskId: task.Id, Attempt: task.Attempts, StartedAt: time.Now()}
+		attempt.Fail(err)
+		e.saveAttempt(attempt)
+		e.log.Infof("executor.ExecuteTask: task %v short-circuited : %v", task.Id, err)
+		e.handleFailure(parent, &task, err)
+		return
+	}
+	result := breaker.Ignored
+	defer func() { report(result) }()
+
+	release, ok := e.acquire(parent, &task, host)
+	if !ok {
+		return
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(parent, e.timeout)
 	defer cancel()
+	metrics.InFlightExecutions.Inc()
+	defer metrics.InFlightExecutions.Dec()
+
+	attempt := &models.TaskAttempt{TaskId: task.Id, Attempt: task.Attempts, StartedAt: time.Now()}
+	defer e.saveAttempt(attempt)
 
-	err := e.repo.UpdateStatus(ctx, task.Id, models.StatusInProcess)
+	body, err := task.DecodedBody()
 	if err != nil {
-		e.log.Errorf("executor.ExecuteTask.UpdateStatus : %v", err)
+		attempt.Fail(err)
+		e.setErrorStatus(&task, err)
+		e.log.Errorf("executor.ExecuteTask.DecodedBody : %v", err)
 		return
 	}
-	e.log.Infof("executor.ExecuteTask: task %v with method %s and url %s", task.Id, task.Method, task.Url)
-	req, err := http.NewRequestWithContext(ctx, strings.ToUpper(task.Method), task.Url, nil)
+	req, err := http.NewRequestWithContext(ctx, strings.ToUpper(task.Method), task.Url, bytes.NewReader(body))
 	if err != nil {
-		e.setErrorStatus(task.Id)
+		attempt.Fail(err)
+		e.setErrorStatus(&task, err)
 		e.log.Errorf("executor.ExecuteTask.NewRequestWithContext : %v", err)
 		return
 	}
@@ -51,16 +173,32 @@ func (e *Executor) ExecuteTask(task models.Task) {
 			req.Header.Add(v.Name, v.Value)
 		}
 	}
+	if task.BodyEncoding == models.BodyEncodingJSON && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
 
-	client := e.clientProvider.Client()
+	client, err := e.client(&task)
+	if err != nil {
+		attempt.Fail(err)
+		e.setErrorStatus(&task, err)
+		e.log.Errorf("executor.ExecuteTask.Client : %v", err)
+		return
+	}
 
-	resp, err := client.Do(req)
+	start := time.Now()
+	resp, err := e.do(ctx, client, req)
+	result = outcome(ctx, resp, err)
+	metrics.OutboundRequestDuration.WithLabelValues(req.Method, req.URL.Hostname()).Observe(time.Since(start).Seconds())
 	if err != nil {
-		e.setErrorStatus(task.Id)
+		attempt.Fail(err)
 		e.log.Errorf("executor.ExecuteTask.DoRequest : %v", err)
+		e.handleFailure(ctx, &task, err)
 		return
 	}
 
+	code := int64(resp.StatusCode)
+	attempt.ResponseStatus = &code
+
 	defer func(Body io.ReadCloser) {
 		err := Body.Close()
 		if err != nil {
@@ -68,18 +206,36 @@ func (e *Executor) ExecuteTask(task models.Task) {
 		}
 	}(resp.Body)
 
-	contentLength, err := io.Copy(io.Discard, resp.Body)
+	responseBody, err := io.ReadAll(io.LimitReader(resp.Body, e.maxResponseBodySize))
 	if err != nil {
-		e.setErrorStatus(task.Id)
+		attempt.Fail(err)
+		e.log.Errorf("executor.ExecuteTask.DoRequest.ReadAll : %v", err)
+		e.handleFailure(ctx, &task, err)
+		return
+	}
+
+	rest, err := io.Copy(io.Discard, resp.Body)
+	if err != nil {
+		attempt.Fail(err)
 		e.log.Errorf("executor.ExecuteTask.DoRequest.Copy : %v", err)
+		e.handleFailure(ctx, &task, err)
 		return
 	}
+	contentLength := int64(len(responseBody)) + rest
+	attempt.ResponseLength = &contentLength
+	metrics.OutboundResponseSize.WithLabelValues(req.Method, req.URL.Hostname()).Observe(float64(contentLength))
 
 	e.log.Infof("executor.ExecuteTask: task %v with method %s and url %s executed successfully with code %v", task.Id, task.Method, req.URL, resp.StatusCode)
 
+	if task.RetryPolicy.RetryableStatus(resp.StatusCode) && task.RetryPolicy.ShouldRetry(task.Attempts) {
+		err = fmt.Errorf("unexpected status code %d", resp.StatusCode)
+		attempt.Fail(err)
+		e.scheduleRetry(&task, err)
+		return
+	}
+
 	task.ResponseLength = &contentLength
 	task.Status = models.StatusDone
-	code := int64(resp.StatusCode)
 	task.ResponseStatus = &code
 	outputHeaders := make([]models.Header, 0)
 	for k, v := range resp.Header {
@@ -88,16 +244,203 @@ func (e *Executor) ExecuteTask(task models.Task) {
 	}
 
 	task.Headers = append(task.Headers, outputHeaders...)
+	task.Response = &models.TaskResponse{
+		TaskId:      task.Id,
+		ContentType: resp.Header.Get("Content-Type"),
+		Body:        responseBody,
+		Truncated:   rest > 0,
+	}
 	err = e.repo.UpdateResult(ctx, &task)
 	if err != nil {
-		e.setErrorStatus(task.Id)
+		attempt.Fail(err)
+		if cancelled(ctx) {
+			e.log.Infof("executor.ExecuteTask: task %v was cancelled", task.Id)
+			return
+		}
+		if interrupted(ctx) {
+			e.requeue(&task, "was interrupted", nil)
+			return
+		}
+		e.setErrorStatus(&task, err)
 		e.log.Errorf("executor.ExecuteTask.UpdateResult : %v", err)
+		return
 	}
+	metrics.TasksFinished.WithLabelValues(models.StatusDone).Inc()
+	e.events.Publish(models.NewTaskEvent(&task))
 }
 
-func (e *Executor) setErrorStatus(id int64) {
-	err := e.repo.UpdateStatus(context.Background(), id, models.StatusError)
+// acquire waits for the limits of the target host, so the execution timeout only covers the request.
+// A task still waiting when it is interrupted goes back to the queue untouched. A task whose host is
+// over its limits goes back to the queue until the host is expected to admit it, freeing the worker.
+func (e *Executor) acquire(ctx context.Context, task *models.Task, host string) (func(), bool) {
+	if host == "" {
+		return func() {}, true
+	}
+
+	start := time.Now()
+	release, err := e.limiter.Acquire(ctx, host)
+	metrics.OutboundLimiterWait.WithLabelValues(host).Observe(time.Since(start).Seconds())
+	if err == nil {
+		return release, true
+	}
+
+	switch {
+	case cancelled(ctx):
+		e.log.Infof("executor.ExecuteTask: task %v was cancelled", task.Id)
+	case interrupted(ctx):
+		e.requeue(task, "was interrupted", nil)
+	default:
+		retryAfter := time.Duration(0)
+		var limited *LimitedError
+		if errors.As(err, &limited) {
+			retryAfter = limited.RetryAfter
+		}
+		nextAttemptAt := time.Now().Add(retryAfter)
+		reason := fmt.Sprintf("waited %s for %s (%v), next attempt at %s", time.Since(start).Round(time.Millisecond), host, err, nextAttemptAt.Format(time.RFC3339))
+		e.requeue(task, reason, &nextAttemptAt)
+	}
+	return nil, false
+}
+
+func (e *Executor) client(task *models.Task) (*http.Client, error) {
+	if task.TlsProfile == nil {
+		return e.clientProvider.Client(), nil
+	}
+	return e.clientProvider.ProfileClient(*task.TlsProfile)
+}
+
+// do sends the request inside a client span and propagates the trace to the target.
+func (e *Executor) do(ctx context.Context, client *http.Client, req *http.Request) (*http.Response, error) {
+	ctx, span := tracing.Start(ctx, "HTTP "+req.Method, trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.HTTPRequestMethodKey.String(req.Method), semconv.URLFull(req.URL.String())))
+	defer span.End()
+
+	req = req.WithContext(ctx)
+	tracing.Inject(ctx, req.Header)
+	resp, err := client.Do(req)
 	if err != nil {
-		e.log.Errorf("executor.ExecuteTask.setErrorStatus.UpdateStatus : %v", err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+	return resp, nil
+}
+
+func (e *Executor) handleFailure(ctx context.Context, task *models.Task, reason error) {
+	if cancelled(ctx) {
+		e.log.Infof("executor.ExecuteTask: task %v was cancelled", task.Id)
+		return
+	}
+	if interrupted(ctx) {
+		e.requeue(task, "was interrupted", nil)
+		return
+	}
+	if task.RetryPolicy.RetryableError(classifyError(reason)) && task.RetryPolicy.ShouldRetry(task.Attempts) {
+		e.scheduleRetry(task, reason)
+		return
 	}
+	e.setErrorStatus(task, reason)
+}
+
+func (e *Executor) scheduleRetry(task *models.Task, reason error) {
+	nextAttemptAt := time.Now().Add(task.RetryPolicy.Backoff(task.Attempts))
+	e.log.Infof("executor.ExecuteTask: task %v attempt %d failed (%v), next attempt at %s", task.Id, task.Attempts, reason, nextAttemptAt)
+	lastError := reason.Error()
+	err := e.repo.ScheduleRetry(context.Background(), task.Id, nextAttemptAt, lastError)
+	if err != nil {
+		e.log.Errorf("executor.ExecuteTask.scheduleRetry.ScheduleRetry : %v", err)
+		return
+	}
+	e.events.Publish(models.TaskEvent{TaskId: task.Id, Status: models.StatusNew, LastError: &lastError, At: time.Now(), Client: task.Client})
+}
+
+// requeue returns the task to new without counting the attempt, it isn't claimed again before nextAttemptAt when set.
+func (e *Executor) requeue(task *models.Task, reason string, nextAttemptAt *time.Time) {
+	e.log.Infof("executor.ExecuteTask: task %v %s, returning it to %s", task.Id, reason, models.StatusNew)
+	err := e.repo.Requeue(context.Background(), task.Id, nextAttemptAt)
+	if err != nil {
+		e.log.Errorf("executor.ExecuteTask.requeue.Requeue : %v", err)
+		return
+	}
+	e.events.Publish(models.TaskEvent{TaskId: task.Id, Status: models.StatusNew, At: time.Now(), Client: task.Client})
+}
+
+func (e *Executor) saveAttempt(attempt *models.TaskAttempt) {
+	attempt.FinishedAt = time.Now()
+	attempt.DurationMs = attempt.FinishedAt.Sub(attempt.StartedAt).Milliseconds()
+	err := e.repo.CreateAttempt(context.Background(), attempt)
+	if err != nil {
+		e.log.Errorf("executor.ExecuteTask.saveAttempt.CreateAttempt : %v", err)
+	}
+}
+
+func (e *Executor) setErrorStatus(task *models.Task, reason error) {
+	lastError := reason.Error()
+	err := e.repo.UpdateError(context.Background(), task.Id, lastError)
+	if err != nil {
+		e.log.Errorf("executor.ExecuteTask.setErrorStatus.UpdateError : %v", err)
+		return
+	}
+	metrics.TasksFinished.WithLabelValues(models.StatusError).Inc()
+	e.events.Publish(models.TaskEvent{TaskId: task.Id, Status: models.StatusError, LastError: &lastError, At: time.Now(), Client: task.Client})
+}
+
+// Interrupt aborts every in-flight execution and any started afterwards, returning their tasks
+// to new so another instance picks them up. It reports how many executions were aborted.
+func (e *Executor) Interrupt() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.interrupted = true
+	for _, cancel := range e.running {
+		cancel(errInterrupted)
+	}
+	return len(e.running)
+}
+
+func (e *Executor) register(id int64, cancel context.CancelCauseFunc) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.running[id] = cancel
+	if e.interrupted {
+		cancel(errInterrupted)
+	}
+}
+
+func (e *Executor) unregister(id int64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if cancel, ok := e.running[id]; ok {
+		cancel(nil)
+		delete(e.running, id)
+	}
+}
+
+// outcome tells the breaker whether the host failed the call, aborted calls saying nothing about it.
+func outcome(ctx context.Context, resp *http.Response, err error) breaker.Outcome {
+	switch {
+	case cancelled(ctx), interrupted(ctx):
+		return breaker.Ignored
+	case err != nil, resp.StatusCode >= http.StatusInternalServerError:
+		return breaker.Failure
+	default:
+		return breaker.Success
+	}
+}
+
+// hostname returns the host of the task url, empty when it can't be parsed.
+func hostname(raw string) string {
+	target, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	return target.Hostname()
+}
+
+func cancelled(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), errTaskCancelled)
+}
+
+func interrupted(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), errInterrupted)
 }
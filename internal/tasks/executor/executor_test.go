@@ -2,21 +2,33 @@ package executor
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
+	"fmt"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
 	"go.uber.org/mock/gomock"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"http-task-executor/internal/breaker"
+	"http-task-executor/internal/config"
 	"http-task-executor/internal/models"
+	"http-task-executor/internal/tasks/events"
 	"http-task-executor/internal/tasks/mock"
 	"io"
+	"net"
 	"net/http"
 	"strings"
+	"syscall"
 	"testing"
 	"time"
 )
 
-const duration = 3 * time.Second
+const (
+	duration            = 3 * time.Second
+	maxResponseBodySize = 1024
+)
 
 func TestExecutor_ExecuteTask(t *testing.T) {
 	t.Parallel()
@@ -26,6 +38,7 @@ func TestExecutor_ExecuteTask(t *testing.T) {
 	sugar := zap.New(zapcore.NewNopCore()).Sugar()
 
 	mockTasksRepo := mock.NewMockRepository(ctrx)
+	mockTasksRepo.EXPECT().CreateAttempt(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
 
 	closer := io.NopCloser(strings.NewReader(`{"message": "ok"}`))
 	mockResp := &http.Response{
@@ -44,7 +57,7 @@ func TestExecutor_ExecuteTask(t *testing.T) {
 
 	provider := newMockClientProvider(mockTransport)
 
-	executor := NewExecutor(sugar, mockTasksRepo, provider, duration)
+	executor := NewExecutor(sugar, mockTasksRepo, provider, events.NewBus(sugar, 0), nil, nil, duration, maxResponseBodySize, 0)
 
 	task := models.Task{
 		Method: "GET",
@@ -54,8 +67,6 @@ func TestExecutor_ExecuteTask(t *testing.T) {
 
 	_, cancel := context.WithTimeout(context.Background(), duration)
 
-	mockTasksRepo.EXPECT().UpdateStatus(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(1)
-
 	status := int64(200)
 
 	require.NoError(t, err)
@@ -83,6 +94,37 @@ func TestExecutor_ExecuteTask(t *testing.T) {
 	defer cancel()
 }
 
+func TestExecutor_ExecuteTaskPublishesEvents(t *testing.T) {
+	t.Parallel()
+	ctrx := gomock.NewController(t)
+	defer ctrx.Finish()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	mockTasksRepo := mock.NewMockRepository(ctrx)
+	mockTasksRepo.EXPECT().CreateAttempt(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	mockTasksRepo.EXPECT().UpdateResult(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+	mockTasksRepo.EXPECT().UpdateError(gomock.Any(), int64(2), gomock.Any()).Return(nil).Times(1)
+
+	bus := events.NewBus(sugar, 0)
+	received, unsubscribe := bus.Subscribe(models.TaskEventFilter{})
+	defer unsubscribe()
+
+	transport := &mockRoundTripper{Response: &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader("ok")), Header: make(http.Header)}}
+	executor := NewExecutor(sugar, mockTasksRepo, newMockClientProvider(transport), bus, nil, nil, duration, maxResponseBodySize, 0)
+	executor.ExecuteTask(models.Task{Id: 1, Method: "GET", Url: "https://www.google.com", Status: models.StatusInProcess})
+
+	executor = NewExecutor(sugar, mockTasksRepo, newMockClientProvider(&mockRoundTripper{Err: errors.New("connection refused")}), bus, nil, nil, duration, maxResponseBodySize, 0)
+	executor.ExecuteTask(models.Task{Id: 2, Method: "GET", Url: "https://www.google.com", Status: models.StatusInProcess})
+
+	statuses := make([]string, 0)
+	for range 4 {
+		event := <-received
+		statuses = append(statuses, fmt.Sprintf("%d:%s", event.TaskId, event.Status))
+	}
+	require.Equal(t, []string{"1:in_process", "1:done", "2:in_process", "2:error"}, statuses)
+}
+
 func TestExecutor_ExecuteTaskWithHeader(t *testing.T) {
 	t.Parallel()
 	ctrx := gomock.NewController(t)
@@ -91,6 +133,7 @@ func TestExecutor_ExecuteTaskWithHeader(t *testing.T) {
 	sugar := zap.New(zapcore.NewNopCore()).Sugar()
 
 	mockTasksRepo := mock.NewMockRepository(ctrx)
+	mockTasksRepo.EXPECT().CreateAttempt(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
 
 	headerName := "TEST"
 	headerValue := "TEST_VALUE"
@@ -113,7 +156,7 @@ func TestExecutor_ExecuteTaskWithHeader(t *testing.T) {
 
 	provider := newMockClientProvider(mockTransport)
 
-	executor := NewExecutor(sugar, mockTasksRepo, provider, duration)
+	executor := NewExecutor(sugar, mockTasksRepo, provider, events.NewBus(sugar, 0), nil, nil, duration, maxResponseBodySize, 0)
 
 	task := models.Task{
 		Method: "GET",
@@ -123,8 +166,6 @@ func TestExecutor_ExecuteTaskWithHeader(t *testing.T) {
 
 	_, cancel := context.WithTimeout(context.Background(), duration)
 
-	mockTasksRepo.EXPECT().UpdateStatus(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(1)
-
 	status := int64(200)
 
 	require.NoError(t, err)
@@ -157,7 +198,7 @@ func TestExecutor_ExecuteTaskWithHeader(t *testing.T) {
 	defer cancel()
 }
 
-func TestExecutor_ExecuteTaskWithNotWoringUpdateStatus(t *testing.T) {
+func TestExecutor_ExecuteTaskWithBody(t *testing.T) {
 	t.Parallel()
 	ctrx := gomock.NewController(t)
 	defer ctrx.Finish()
@@ -165,10 +206,11 @@ func TestExecutor_ExecuteTaskWithNotWoringUpdateStatus(t *testing.T) {
 	sugar := zap.New(zapcore.NewNopCore()).Sugar()
 
 	mockTasksRepo := mock.NewMockRepository(ctrx)
+	mockTasksRepo.EXPECT().CreateAttempt(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
 
 	mockResp := &http.Response{
-		StatusCode: 200,
-		Body:       io.NopCloser(strings.NewReader(`{"message": "ok"}`)),
+		StatusCode: 201,
+		Body:       io.NopCloser(strings.NewReader("")),
 		Header:     make(http.Header),
 	}
 
@@ -179,36 +221,674 @@ func TestExecutor_ExecuteTaskWithNotWoringUpdateStatus(t *testing.T) {
 
 	provider := newMockClientProvider(mockTransport)
 
-	executor := NewExecutor(sugar, mockTasksRepo, provider, duration)
+	executor := NewExecutor(sugar, mockTasksRepo, provider, events.NewBus(sugar, 0), nil, nil, duration, maxResponseBodySize, 0)
+
+	t.Run("JSON body", func(t *testing.T) {
+		task := models.Task{
+			Method:       "POST",
+			Url:          "https://www.google.com",
+			Status:       models.StatusInProcess,
+			Body:         `{"key":"value"}`,
+			BodyEncoding: models.BodyEncodingJSON,
+		}
+
+		mockTasksRepo.EXPECT().UpdateResult(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+
+		executor.ExecuteTask(task)
+
+		require.NotNil(t, mockTransport.Request)
+		sent, err := io.ReadAll(mockTransport.Request.Body)
+		require.NoError(t, err)
+		require.Equal(t, task.Body, string(sent))
+		require.Equal(t, "application/json", mockTransport.Request.Header.Get("Content-Type"))
+	})
+
+	t.Run("Base64 body", func(t *testing.T) {
+		task := models.Task{
+			Method:       "PUT",
+			Url:          "https://www.google.com",
+			Status:       models.StatusInProcess,
+			Body:         "AAEC/w==",
+			BodyEncoding: models.BodyEncodingBase64,
+			Headers:      []models.Header{{Name: "Content-Type", Value: "application/octet-stream", Input: true}},
+		}
+
+		mockTasksRepo.EXPECT().UpdateResult(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+
+		executor.ExecuteTask(task)
+
+		require.NotNil(t, mockTransport.Request)
+		sent, err := io.ReadAll(mockTransport.Request.Body)
+		require.NoError(t, err)
+		require.Equal(t, []byte{0x00, 0x01, 0x02, 0xff}, sent)
+		require.Equal(t, "application/octet-stream", mockTransport.Request.Header.Get("Content-Type"))
+	})
+}
+
+func TestExecutor_ExecuteTaskStoresResponseBody(t *testing.T) {
+	t.Parallel()
+	ctrx := gomock.NewController(t)
+	defer ctrx.Finish()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	mockTasksRepo := mock.NewMockRepository(ctrx)
+	mockTasksRepo.EXPECT().CreateAttempt(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	responseBody := `{"message": "ok"}`
+
+	newTransport := func() *mockRoundTripper {
+		header := make(http.Header)
+		header.Set("Content-Type", "application/json")
+		return &mockRoundTripper{
+			Response: &http.Response{
+				StatusCode: 200,
+				Body:       io.NopCloser(strings.NewReader(responseBody)),
+				Header:     header,
+			},
+		}
+	}
 
 	task := models.Task{
+		Id:     1,
 		Method: "GET",
 		Url:    "https://www.google.com",
-		Status: models.StatusNew,
+		Status: models.StatusInProcess,
 	}
 
-	_, cancel := context.WithTimeout(context.Background(), duration)
+	t.Run("Full body", func(t *testing.T) {
+		executor := NewExecutor(sugar, mockTasksRepo, newMockClientProvider(newTransport()), events.NewBus(sugar, 0), nil, nil, duration, maxResponseBodySize, 0)
+
+		mockTasksRepo.EXPECT().UpdateResult(gomock.Any(), gomock.Cond(func(x *models.Task) bool {
+			return x.Response != nil &&
+				x.Response.TaskId == task.Id &&
+				x.Response.ContentType == "application/json" &&
+				string(x.Response.Body) == responseBody &&
+				!x.Response.Truncated &&
+				*x.ResponseLength == int64(len(responseBody))
+		})).Return(nil).Times(1)
+
+		executor.ExecuteTask(task)
+	})
+
+	t.Run("Truncated body", func(t *testing.T) {
+		executor := NewExecutor(sugar, mockTasksRepo, newMockClientProvider(newTransport()), events.NewBus(sugar, 0), nil, nil, duration, 5, 0)
+
+		mockTasksRepo.EXPECT().UpdateResult(gomock.Any(), gomock.Cond(func(x *models.Task) bool {
+			return x.Response != nil &&
+				string(x.Response.Body) == responseBody[:5] &&
+				x.Response.Truncated &&
+				*x.ResponseLength == int64(len(responseBody))
+		})).Return(nil).Times(1)
+
+		executor.ExecuteTask(task)
+	})
+}
+
+func TestExecutor_ExecuteTaskWithTransportError(t *testing.T) {
+	t.Parallel()
+	ctrx := gomock.NewController(t)
+	defer ctrx.Finish()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	mockTasksRepo := mock.NewMockRepository(ctrx)
+	mockTasksRepo.EXPECT().CreateAttempt(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	mockTransport := &mockRoundTripper{
+		Response: nil,
+		Err:      errors.New("connection refused"),
+	}
+
+	provider := newMockClientProvider(mockTransport)
+
+	executor := NewExecutor(sugar, mockTasksRepo, provider, events.NewBus(sugar, 0), nil, nil, duration, maxResponseBodySize, 0)
+
+	task := models.Task{
+		Id:     1,
+		Method: "GET",
+		Url:    "https://www.google.com",
+		Status: models.StatusInProcess,
+	}
 
-	mockTasksRepo.EXPECT().UpdateStatus(gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("error")).Times(1)
+	mockTasksRepo.EXPECT().UpdateError(gomock.Any(), task.Id, gomock.Any()).Return(nil).Times(1)
 
 	mockTasksRepo.EXPECT().UpdateResult(gomock.Any(), gomock.Any()).Times(0)
 
 	executor.ExecuteTask(task)
+}
 
-	defer cancel()
+func TestExecutor_ExecuteTaskWithRetryPolicy(t *testing.T) {
+	t.Parallel()
+	ctrx := gomock.NewController(t)
+	defer ctrx.Finish()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	mockTasksRepo := mock.NewMockRepository(ctrx)
+	mockTasksRepo.EXPECT().CreateAttempt(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	policy := &models.RetryPolicy{
+		MaxAttempts:   3,
+		BackoffBaseMs: 1000,
+		BackoffMaxMs:  10000,
+		RetryOnStatus: []int{http.StatusServiceUnavailable},
+		RetryOnErrors: []string{models.ErrorClassConnection},
+	}
+
+	newTask := func(attempts int) models.Task {
+		return models.Task{
+			Id:          1,
+			Method:      "GET",
+			Url:         "https://www.google.com",
+			Status:      models.StatusInProcess,
+			RetryPolicy: policy,
+			Attempts:    attempts,
+		}
+	}
+
+	unavailable := func() *mockRoundTripper {
+		return &mockRoundTripper{Response: &http.Response{
+			StatusCode: http.StatusServiceUnavailable,
+			Body:       io.NopCloser(strings.NewReader("")),
+			Header:     make(http.Header),
+		}}
+	}
+
+	t.Run("Retry on retryable error", func(t *testing.T) {
+		transport := &mockRoundTripper{Err: &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}}
+		executor := NewExecutor(sugar, mockTasksRepo, newMockClientProvider(transport), events.NewBus(sugar, 0), nil, nil, duration, maxResponseBodySize, 0)
+
+		before := time.Now()
+		mockTasksRepo.EXPECT().ScheduleRetry(gomock.Any(), int64(1), gomock.Cond(func(x time.Time) bool {
+			return !x.Before(before.Add(time.Second))
+		}), gomock.Any()).Return(nil).Times(1)
+
+		executor.ExecuteTask(newTask(1))
+	})
+
+	t.Run("Retry on retryable status", func(t *testing.T) {
+		executor := NewExecutor(sugar, mockTasksRepo, newMockClientProvider(unavailable()), events.NewBus(sugar, 0), nil, nil, duration, maxResponseBodySize, 0)
+
+		mockTasksRepo.EXPECT().ScheduleRetry(gomock.Any(), int64(1), gomock.Any(), "unexpected status code 503").Return(nil).Times(1)
+
+		executor.ExecuteTask(newTask(2))
+	})
+
+	t.Run("Store result after last attempt", func(t *testing.T) {
+		executor := NewExecutor(sugar, mockTasksRepo, newMockClientProvider(unavailable()), events.NewBus(sugar, 0), nil, nil, duration, maxResponseBodySize, 0)
+
+		mockTasksRepo.EXPECT().UpdateResult(gomock.Any(), gomock.Cond(func(x *models.Task) bool {
+			return x.Status == models.StatusDone && *x.ResponseStatus == http.StatusServiceUnavailable
+		})).Return(nil).Times(1)
+
+		executor.ExecuteTask(newTask(3))
+	})
+
+	t.Run("Error after last attempt", func(t *testing.T) {
+		transport := &mockRoundTripper{Err: &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}}
+		executor := NewExecutor(sugar, mockTasksRepo, newMockClientProvider(transport), events.NewBus(sugar, 0), nil, nil, duration, maxResponseBodySize, 0)
+
+		mockTasksRepo.EXPECT().UpdateError(gomock.Any(), int64(1), gomock.Any()).Return(nil).Times(1)
+
+		executor.ExecuteTask(newTask(3))
+	})
+
+	t.Run("Error on not retryable error", func(t *testing.T) {
+		transport := &mockRoundTripper{Err: &net.DNSError{Err: "no such host", Name: "www.google.com", IsNotFound: true}}
+		executor := NewExecutor(sugar, mockTasksRepo, newMockClientProvider(transport), events.NewBus(sugar, 0), nil, nil, duration, maxResponseBodySize, 0)
+
+		mockTasksRepo.EXPECT().UpdateError(gomock.Any(), int64(1), gomock.Any()).Return(nil).Times(1)
+
+		executor.ExecuteTask(newTask(1))
+	})
+}
+
+func TestExecutor_ExecuteTaskRecordsAttempt(t *testing.T) {
+	t.Parallel()
+	ctrx := gomock.NewController(t)
+	defer ctrx.Finish()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	mockTasksRepo := mock.NewMockRepository(ctrx)
+
+	task := models.Task{
+		Id:       1,
+		Method:   "GET",
+		Url:      "https://www.google.com",
+		Status:   models.StatusInProcess,
+		Attempts: 2,
+	}
+
+	t.Run("Successful attempt", func(t *testing.T) {
+		transport := &mockRoundTripper{Response: &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader("ok")),
+			Header:     make(http.Header),
+		}}
+		executor := NewExecutor(sugar, mockTasksRepo, newMockClientProvider(transport), events.NewBus(sugar, 0), nil, nil, duration, maxResponseBodySize, 0)
+
+		mockTasksRepo.EXPECT().UpdateResult(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+		mockTasksRepo.EXPECT().CreateAttempt(gomock.Any(), gomock.Cond(func(x *models.TaskAttempt) bool {
+			return x.TaskId == task.Id &&
+				x.Attempt == task.Attempts &&
+				!x.FinishedAt.Before(x.StartedAt) &&
+				*x.ResponseStatus == http.StatusOK &&
+				*x.ResponseLength == 2 &&
+				x.Error == nil
+		})).Return(nil).Times(1)
+
+		executor.ExecuteTask(task)
+	})
+
+	t.Run("Failed attempt", func(t *testing.T) {
+		transport := &mockRoundTripper{Err: errors.New("connection refused")}
+		executor := NewExecutor(sugar, mockTasksRepo, newMockClientProvider(transport), events.NewBus(sugar, 0), nil, nil, duration, maxResponseBodySize, 0)
+
+		mockTasksRepo.EXPECT().UpdateError(gomock.Any(), task.Id, gomock.Any()).Return(nil).Times(1)
+		mockTasksRepo.EXPECT().CreateAttempt(gomock.Any(), gomock.Cond(func(x *models.TaskAttempt) bool {
+			return x.TaskId == task.Id &&
+				x.Attempt == task.Attempts &&
+				x.ResponseStatus == nil &&
+				x.Error != nil && strings.Contains(*x.Error, "connection refused")
+		})).Return(nil).Times(1)
+
+		executor.ExecuteTask(task)
+	})
+}
+
+func TestExecutor_ExecuteTaskPropagatesTrace(t *testing.T) {
+	t.Parallel()
+	ctrx := gomock.NewController(t)
+	defer ctrx.Finish()
+
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	traceId := "4bf92f3577b34da6a3ce929d0e0e4736"
+	spanId := "00f067aa0ba902b7"
+
+	for _, sampled := range []bool{true, false} {
+		t.Run(fmt.Sprintf("Sampled %v", sampled), func(t *testing.T) {
+			mockTasksRepo := mock.NewMockRepository(ctrx)
+			mockTasksRepo.EXPECT().CreateAttempt(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+			mockTasksRepo.EXPECT().UpdateResult(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+
+			transport := &mockRoundTripper{Response: &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader("ok")), Header: make(http.Header)}}
+			executor := NewExecutor(sugar, mockTasksRepo, newMockClientProvider(transport), events.NewBus(sugar, 0), nil, nil, duration, maxResponseBodySize, 0)
+
+			task := models.Task{Id: 1, Method: "GET", Url: "https://www.google.com", Status: models.StatusNew, TraceId: &traceId, SpanId: &spanId, TraceSampled: sampled}
+
+			executor.ExecuteTask(task)
+
+			require.NotNil(t, transport.Request)
+			traceparent := transport.Request.Header.Get("traceparent")
+			require.True(t, strings.HasPrefix(traceparent, "00-"+traceId+"-"), traceparent)
+			flags := "-00"
+			if sampled {
+				flags = "-01"
+			}
+			require.True(t, strings.HasSuffix(traceparent, flags), traceparent)
+		})
+	}
+}
+
+func TestExecutor_Cancel(t *testing.T) {
+	t.Parallel()
+	ctrx := gomock.NewController(t)
+	defer ctrx.Finish()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	mockTasksRepo := mock.NewMockRepository(ctrx)
+	mockTasksRepo.EXPECT().CreateAttempt(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+	mockTasksRepo.EXPECT().UpdateError(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	mockTasksRepo.EXPECT().ScheduleRetry(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	transport := &mockRoundTripper{Started: make(chan struct{})}
+	executor := NewExecutor(sugar, mockTasksRepo, newMockClientProvider(transport), events.NewBus(sugar, 0), nil, nil, duration, maxResponseBodySize, 0)
+
+	task := models.Task{
+		Id:          1515,
+		Method:      "GET",
+		Url:         "https://www.google.com",
+		Status:      models.StatusInProcess,
+		Attempts:    1,
+		RetryPolicy: &models.RetryPolicy{MaxAttempts: 3, RetryOnErrors: []string{models.ErrorClassOther}},
+	}
+
+	done := make(chan struct{})
+	go func() {
+		executor.ExecuteTask(task)
+		close(done)
+	}()
+
+	<-transport.Started
+	require.True(t, executor.Cancel(task.Id))
+	<-done
+
+	require.False(t, executor.Cancel(task.Id))
+}
+
+func TestExecutor_Interrupt(t *testing.T) {
+	t.Parallel()
+	ctrx := gomock.NewController(t)
+	defer ctrx.Finish()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	mockTasksRepo := mock.NewMockRepository(ctrx)
+	mockTasksRepo.EXPECT().CreateAttempt(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	mockTasksRepo.EXPECT().UpdateError(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	mockTasksRepo.EXPECT().ScheduleRetry(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	transport := &mockRoundTripper{Started: make(chan struct{})}
+	executor := NewExecutor(sugar, mockTasksRepo, newMockClientProvider(transport), events.NewBus(sugar, 0), nil, nil, duration, maxResponseBodySize, 0)
+
+	task := models.Task{
+		Id:          1515,
+		Method:      "GET",
+		Url:         "https://www.google.com",
+		Status:      models.StatusInProcess,
+		Attempts:    1,
+		RetryPolicy: &models.RetryPolicy{MaxAttempts: 3, RetryOnErrors: []string{models.ErrorClassOther}},
+	}
+
+	t.Run("In-flight execution is requeued", func(t *testing.T) {
+		mockTasksRepo.EXPECT().Requeue(gomock.Any(), task.Id, nil).Return(nil).Times(1)
+
+		done := make(chan struct{})
+		go func() {
+			executor.ExecuteTask(task)
+			close(done)
+		}()
+
+		<-transport.Started
+		require.Equal(t, 1, executor.Interrupt())
+		<-done
+	})
+
+	t.Run("Execution started after interrupt is requeued", func(t *testing.T) {
+		mockTasksRepo.EXPECT().Requeue(gomock.Any(), task.Id+1, nil).Return(nil).Times(1)
+		transport.Started = nil
+		transport.Err = context.Canceled
+
+		next := task
+		next.Id++
+		executor.ExecuteTask(next)
+	})
+}
+
+func TestExecutor_CancelOnEvent(t *testing.T) {
+	t.Parallel()
+	ctrx := gomock.NewController(t)
+	defer ctrx.Finish()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	mockTasksRepo := mock.NewMockRepository(ctrx)
+	mockTasksRepo.EXPECT().CreateAttempt(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+	mockTasksRepo.EXPECT().UpdateError(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	bus := events.NewBus(sugar, 0)
+	transport := &mockRoundTripper{Started: make(chan struct{})}
+	executor := NewExecutor(sugar, mockTasksRepo, newMockClientProvider(transport), bus, nil, nil, duration, maxResponseBodySize, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	executor.Start(ctx)
+
+	done := make(chan struct{})
+	go func() {
+		executor.ExecuteTask(models.Task{Id: 1515, Method: "GET", Url: "https://www.google.com", Status: models.StatusInProcess})
+		close(done)
+	}()
+
+	<-transport.Started
+	// Cancelled through another instance.
+	bus.Publish(models.TaskEvent{TaskId: 1515, Status: models.StatusCancelled})
+	<-done
+
+	cancel()
+	executor.Wait()
+}
+
+func TestExecutor_HostLimit(t *testing.T) {
+	t.Parallel()
+	ctrx := gomock.NewController(t)
+	defer ctrx.Finish()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	mockTasksRepo := mock.NewMockRepository(ctrx)
+	mockTasksRepo.EXPECT().CreateAttempt(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+	mockTasksRepo.EXPECT().UpdateError(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	mockTasksRepo.EXPECT().Requeue(gomock.Any(), int64(1515), nil).Return(nil).AnyTimes()
+
+	limiter := NewHostLimiter(config.RateLimitConfig{
+		Default: config.HostLimitConfig{Concurrency: 1},
+		MaxWait: 20 * time.Millisecond,
+	})
+	transport := &mockRoundTripper{Started: make(chan struct{})}
+	executor := NewExecutor(sugar, mockTasksRepo, newMockClientProvider(transport), events.NewBus(sugar, 0), limiter, nil, duration, maxResponseBodySize, 0)
+
+	done := make(chan struct{})
+	go func() {
+		executor.ExecuteTask(models.Task{Id: 1515, Method: "GET", Url: "https://www.google.com", Status: models.StatusInProcess})
+		close(done)
+	}()
+	<-transport.Started
+
+	t.Run("Task waiting too long is requeued with a delay", func(t *testing.T) {
+		mockTasksRepo.EXPECT().Requeue(gomock.Any(), int64(1516), gomock.Cond(func(x *time.Time) bool {
+			return x != nil && x.After(time.Now())
+		})).Return(nil).Times(1)
+
+		executor.ExecuteTask(models.Task{Id: 1516, Method: "GET", Url: "https://www.google.com/search", Status: models.StatusInProcess})
+	})
+
+	executor.Interrupt()
+	<-done
+}
+
+func TestExecutor_CircuitBreaker(t *testing.T) {
+	t.Parallel()
+	ctrx := gomock.NewController(t)
+	defer ctrx.Finish()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	mockTasksRepo := mock.NewMockRepository(ctrx)
+	mockTasksRepo.EXPECT().CreateAttempt(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	breakers := breaker.NewBreakers(config.CircuitBreakerConfig{Enabled: true, FailureThreshold: 1, OpenTimeout: time.Minute})
+	transport := &mockRoundTripper{Response: &http.Response{
+		StatusCode: http.StatusBadGateway,
+		Body:       io.NopCloser(strings.NewReader("")),
+		Header:     make(http.Header),
+	}}
+	executor := NewExecutor(sugar, mockTasksRepo, newMockClientProvider(transport), events.NewBus(sugar, 0), nil, breakers, duration, maxResponseBodySize, 0)
+
+	task := models.Task{Id: 1, Method: "GET", Url: "https://www.google.com", Status: models.StatusInProcess, Attempts: 1}
+
+	mockTasksRepo.EXPECT().UpdateResult(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+	executor.ExecuteTask(task)
+	require.NotNil(t, transport.Request)
+
+	t.Run("Open breaker fails the task without calling the host", func(t *testing.T) {
+		transport.Request = nil
+		mockTasksRepo.EXPECT().UpdateError(gomock.Any(), int64(2), "circuit breaker is open for www.google.com").Return(nil).Times(1)
+
+		next := task
+		next.Id = 2
+		executor.ExecuteTask(next)
+		require.Nil(t, transport.Request)
+	})
+
+	t.Run("Open breaker retries the task when the policy allows", func(t *testing.T) {
+		mockTasksRepo.EXPECT().ScheduleRetry(gomock.Any(), int64(3), gomock.Any(), "circuit breaker is open for www.google.com").Return(nil).Times(1)
+
+		next := task
+		next.Id = 3
+		next.RetryPolicy = &models.RetryPolicy{MaxAttempts: 3, RetryOnErrors: []string{models.ErrorClassCircuitOpen}}
+		executor.ExecuteTask(next)
+		require.Nil(t, transport.Request)
+	})
+}
+
+func TestExecutor_CircuitBreakerBeforeHostLimit(t *testing.T) {
+	t.Parallel()
+	ctrx := gomock.NewController(t)
+	defer ctrx.Finish()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	mockTasksRepo := mock.NewMockRepository(ctrx)
+	mockTasksRepo.EXPECT().CreateAttempt(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	breakers := breaker.NewBreakers(config.CircuitBreakerConfig{Enabled: true, FailureThreshold: 1, OpenTimeout: time.Minute})
+	limiter := NewHostLimiter(config.RateLimitConfig{
+		Default: config.HostLimitConfig{Rate: 0.5, Burst: 1},
+		MaxWait: time.Minute,
+	})
+	transport := &mockRoundTripper{Response: &http.Response{
+		StatusCode: http.StatusBadGateway,
+		Body:       io.NopCloser(strings.NewReader("")),
+		Header:     make(http.Header),
+	}}
+	executor := NewExecutor(sugar, mockTasksRepo, newMockClientProvider(transport), events.NewBus(sugar, 0), limiter, breakers, duration, maxResponseBodySize, 0)
+
+	mockTasksRepo.EXPECT().UpdateResult(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+	executor.ExecuteTask(models.Task{Id: 1, Method: "GET", Url: "https://www.google.com", Status: models.StatusInProcess, Attempts: 1})
+
+	mockTasksRepo.EXPECT().UpdateError(gomock.Any(), int64(2), "circuit breaker is open for www.google.com").Return(nil).Times(1)
+	start := time.Now()
+	executor.ExecuteTask(models.Task{Id: 2, Method: "GET", Url: "https://www.google.com", Status: models.StatusInProcess, Attempts: 1})
+	require.Less(t, time.Since(start), time.Second)
+}
+
+func TestExecutor_TLSProfile(t *testing.T) {
+	t.Parallel()
+	ctrx := gomock.NewController(t)
+	defer ctrx.Finish()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	mockTasksRepo := mock.NewMockRepository(ctrx)
+	mockTasksRepo.EXPECT().CreateAttempt(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	response := func() *http.Response {
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("")), Header: make(http.Header)}
+	}
+	transport := &mockRoundTripper{Response: response()}
+	profileTransport := &mockRoundTripper{Response: response()}
+	provider := newMockClientProvider(transport)
+	provider.profiles = map[string]*mockRoundTripper{"partner-mtls": profileTransport}
+	executor := NewExecutor(sugar, mockTasksRepo, provider, events.NewBus(sugar, 0), nil, nil, duration, maxResponseBodySize, 0)
+
+	t.Run("Task is sent with the client of its profile", func(t *testing.T) {
+		mockTasksRepo.EXPECT().UpdateResult(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+
+		profile := "partner-mtls"
+		executor.ExecuteTask(models.Task{Id: 1, Method: "GET", Url: "https://www.google.com", Status: models.StatusInProcess, TlsProfile: &profile})
+
+		require.NotNil(t, profileTransport.Request)
+		require.Nil(t, transport.Request)
+	})
+
+	t.Run("Task with a profile removed from the config fails", func(t *testing.T) {
+		mockTasksRepo.EXPECT().UpdateError(gomock.Any(), int64(2), "unknown tls profile other").Return(nil).Times(1)
+
+		profile := "other"
+		executor.ExecuteTask(models.Task{Id: 2, Method: "GET", Url: "https://www.google.com", Status: models.StatusInProcess, TlsProfile: &profile})
+
+		require.Nil(t, transport.Request)
+	})
+}
+
+func TestExecutor_Heartbeat(t *testing.T) {
+	t.Parallel()
+	ctrx := gomock.NewController(t)
+	defer ctrx.Finish()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	mockTasksRepo := mock.NewMockRepository(ctrx)
+	mockTasksRepo.EXPECT().CreateAttempt(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	mockTasksRepo.EXPECT().Requeue(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	beat := make(chan []int64, 1)
+	mockTasksRepo.EXPECT().Heartbeat(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, ids []int64) error {
+		select {
+		case beat <- ids:
+		default:
+		}
+		return nil
+	}).MinTimes(1)
+
+	transport := &mockRoundTripper{Started: make(chan struct{})}
+	executor := NewExecutor(sugar, mockTasksRepo, newMockClientProvider(transport), events.NewBus(sugar, 0), nil, nil, duration, maxResponseBodySize, 10*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	executor.Start(ctx)
+
+	done := make(chan struct{})
+	go func() {
+		executor.ExecuteTask(models.Task{Id: 1515, Method: "GET", Url: "https://www.google.com", Status: models.StatusInProcess})
+		close(done)
+	}()
+
+	<-transport.Started
+	for ids := range beat {
+		if len(ids) > 0 {
+			require.Equal(t, []int64{1515}, ids)
+			break
+		}
+	}
+	executor.Interrupt()
+	<-done
+
+	cancel()
+	executor.Wait()
+}
+
+func TestClassifyError(t *testing.T) {
+	t.Parallel()
+
+	errs := map[string]error{
+		models.ErrorClassCircuitOpen: fmt.Errorf("%w for www.google.com", breaker.ErrOpen),
+		models.ErrorClassTimeout:     context.DeadlineExceeded,
+		models.ErrorClassDNS:         &net.OpError{Op: "dial", Err: &net.DNSError{Err: "no such host"}},
+		models.ErrorClassConnection:  &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED},
+		models.ErrorClassTLS:         tls.RecordHeaderError{Msg: "first record does not look like a TLS handshake"},
+		models.ErrorClassOther:       errors.New("error"),
+	}
+
+	for class, err := range errs {
+		require.Equal(t, class, classifyError(err), err.Error())
+	}
 }
 
 type mockRoundTripper struct {
 	Response *http.Response
 	Err      error
+	Request  *http.Request
+	Started  chan struct{}
 }
 
 func (m *mockRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	m.Request = req
+	if m.Started != nil {
+		close(m.Started)
+		<-req.Context().Done()
+		return nil, req.Context().Err()
+	}
 	return m.Response, m.Err
 }
 
 type mockClientProvider struct {
 	transport *mockRoundTripper
+	profiles  map[string]*mockRoundTripper
 }
 
 func newMockClientProvider(transport *mockRoundTripper) *mockClientProvider {
@@ -218,3 +898,11 @@ func newMockClientProvider(transport *mockRoundTripper) *mockClientProvider {
 func (c *mockClientProvider) Client() *http.Client {
 	return &http.Client{Transport: c.transport}
 }
+
+func (c *mockClientProvider) ProfileClient(name string) (*http.Client, error) {
+	transport, ok := c.profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("%w %s", ErrUnknownTLSProfile, name)
+	}
+	return &http.Client{Transport: transport}, nil
+}
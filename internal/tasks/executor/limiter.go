@@ -0,0 +1,173 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"http-task-executor/internal/config"
+	"strings"
+	"sync"
+	"time"
+)
+
+var errLimited = errors.New("host limit wait exceeded")
+
+// LimitedError reports a host over its limits and how long until it's expected to admit a request.
+type LimitedError struct {
+	Host       string
+	RetryAfter time.Duration
+}
+
+func (e *LimitedError) Error() string {
+	return fmt.Sprintf("%v for %s", errLimited, e.Host)
+}
+
+func (e *LimitedError) Is(target error) bool {
+	return target == errLimited
+}
+
+// HostLimiter caps the rate and the concurrency of requests to every target host.
+type HostLimiter struct {
+	cfg   config.RateLimitConfig
+	mu    sync.Mutex
+	hosts map[string]*hostLimit
+}
+
+type hostLimit struct {
+	bucket *bucket
+	slots  chan struct{}
+}
+
+func NewHostLimiter(cfg config.RateLimitConfig) *HostLimiter {
+	hosts := make(map[string]config.HostLimitConfig, len(cfg.Hosts))
+	for host, limit := range cfg.Hosts {
+		hosts[strings.ToLower(host)] = limit
+	}
+	cfg.Hosts = hosts
+	return &HostLimiter{cfg: cfg, hosts: make(map[string]*hostLimit)}
+}
+
+// Acquire blocks until the host has a free slot and a token, giving up with a *LimitedError once
+// the configured max wait runs out. A token due after the max wait isn't waited for at all.
+// The returned func frees the slot once the request is finished. A nil limiter admits everything.
+func (l *HostLimiter) Acquire(ctx context.Context, host string) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+	limit := l.limit(strings.ToLower(host))
+	if limit == nil {
+		return func() {}, nil
+	}
+
+	if l.cfg.MaxWait > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, l.cfg.MaxWait, &LimitedError{Host: host, RetryAfter: l.cfg.MaxWait})
+		defer cancel()
+	}
+
+	release := func() {}
+	if limit.slots != nil {
+		select {
+		case limit.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, context.Cause(ctx)
+		}
+		release = func() { <-limit.slots }
+	}
+
+	if limit.bucket != nil {
+		delay, err := limit.bucket.wait(ctx)
+		if errors.Is(err, errLimited) {
+			err = &LimitedError{Host: host, RetryAfter: delay}
+		}
+		if err != nil {
+			release()
+			return nil, err
+		}
+	}
+	return release, nil
+}
+
+func (l *HostLimiter) limit(host string) *hostLimit {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	limit, ok := l.hosts[host]
+	if ok {
+		return limit
+	}
+
+	cfg, ok := l.cfg.Hosts[host]
+	if !ok {
+		cfg = l.cfg.Default
+	}
+	if cfg.Rate > 0 || cfg.Concurrency > 0 {
+		limit = &hostLimit{}
+		if cfg.Rate > 0 {
+			limit.bucket = newBucket(cfg.Rate, cfg.Burst)
+		}
+		if cfg.Concurrency > 0 {
+			limit.slots = make(chan struct{}, cfg.Concurrency)
+		}
+	}
+	l.hosts[host] = limit
+	return limit
+}
+
+// bucket is a token bucket refilled at rate tokens per second up to burst tokens.
+type bucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newBucket(rate float64, burst int) *bucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &bucket{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// wait takes a token, sleeping until it is refilled. When the token isn't due before the deadline of
+// ctx, it returns errLimited with the delay right away. The token is given back when ctx is done first.
+func (b *bucket) wait(ctx context.Context) (time.Duration, error) {
+	now := time.Now()
+	deadline, ok := ctx.Deadline()
+	delay, reserved := b.reserve(now, deadline, ok)
+	if !reserved {
+		return delay, errLimited
+	}
+	if delay <= 0 {
+		return 0, nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return 0, nil
+	case <-ctx.Done():
+		b.mu.Lock()
+		b.tokens++
+		b.mu.Unlock()
+		return 0, context.Cause(ctx)
+	}
+}
+
+// reserve takes a token in advance, the balance going negative while callers wait for the refill.
+// No token is taken when it wouldn't be refilled before the deadline.
+func (b *bucket) reserve(now time.Time, deadline time.Time, hasDeadline bool) (time.Duration, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	delay := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+	if delay > 0 && hasDeadline && now.Add(delay).After(deadline) {
+		return delay, false
+	}
+	b.tokens--
+	return delay, true
+}
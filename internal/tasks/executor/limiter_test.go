@@ -0,0 +1,116 @@
+package executor
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"http-task-executor/internal/config"
+	"testing"
+	"time"
+)
+
+func TestHostLimiter_Concurrency(t *testing.T) {
+	t.Parallel()
+
+	limiter := NewHostLimiter(config.RateLimitConfig{
+		Default: config.HostLimitConfig{Concurrency: 1},
+		MaxWait: 20 * time.Millisecond,
+	})
+
+	release, err := limiter.Acquire(context.Background(), "partner.com")
+	require.NoError(t, err)
+
+	_, err = limiter.Acquire(context.Background(), "partner.com")
+	require.ErrorIs(t, err, errLimited)
+
+	otherRelease, err := limiter.Acquire(context.Background(), "other.com")
+	require.NoError(t, err)
+	otherRelease()
+
+	release()
+	release, err = limiter.Acquire(context.Background(), "partner.com")
+	require.NoError(t, err)
+	release()
+}
+
+func TestHostLimiter_Rate(t *testing.T) {
+	t.Parallel()
+
+	limiter := NewHostLimiter(config.RateLimitConfig{
+		Default: config.HostLimitConfig{Rate: 20, Burst: 2},
+	})
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		release, err := limiter.Acquire(context.Background(), "partner.com")
+		require.NoError(t, err)
+		release()
+	}
+	require.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond)
+}
+
+func TestHostLimiter_HostOverride(t *testing.T) {
+	t.Parallel()
+
+	limiter := NewHostLimiter(config.RateLimitConfig{
+		Default: config.HostLimitConfig{Concurrency: 1},
+		Hosts:   map[string]config.HostLimitConfig{"Partner.com": {}},
+		MaxWait: 20 * time.Millisecond,
+	})
+
+	for i := 0; i < 3; i++ {
+		_, err := limiter.Acquire(context.Background(), "partner.com")
+		require.NoError(t, err)
+	}
+
+	_, err := limiter.Acquire(context.Background(), "other.com")
+	require.NoError(t, err)
+	_, err = limiter.Acquire(context.Background(), "other.com")
+	require.ErrorIs(t, err, errLimited)
+}
+
+func TestHostLimiter_Cancelled(t *testing.T) {
+	t.Parallel()
+
+	limiter := NewHostLimiter(config.RateLimitConfig{
+		Default: config.HostLimitConfig{Rate: 1, Burst: 1},
+	})
+
+	release, err := limiter.Acquire(context.Background(), "partner.com")
+	require.NoError(t, err)
+	release()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	_, err = limiter.Acquire(ctx, "partner.com")
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestHostLimiter_RateBeyondMaxWait(t *testing.T) {
+	t.Parallel()
+
+	limiter := NewHostLimiter(config.RateLimitConfig{
+		Default: config.HostLimitConfig{Rate: 1, Burst: 1},
+		MaxWait: 20 * time.Millisecond,
+	})
+
+	release, err := limiter.Acquire(context.Background(), "partner.com")
+	require.NoError(t, err)
+	release()
+
+	start := time.Now()
+	_, err = limiter.Acquire(context.Background(), "partner.com")
+	var limited *LimitedError
+	require.ErrorAs(t, err, &limited)
+	require.ErrorIs(t, err, errLimited)
+	require.Less(t, time.Since(start), 20*time.Millisecond)
+	require.Greater(t, limited.RetryAfter, 900*time.Millisecond)
+}
+
+func TestHostLimiter_Nil(t *testing.T) {
+	t.Parallel()
+
+	var limiter *HostLimiter
+	release, err := limiter.Acquire(context.Background(), "partner.com")
+	require.NoError(t, err)
+	release()
+}
@@ -0,0 +1,44 @@
+package executor
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"http-task-executor/internal/breaker"
+	"http-task-executor/internal/egress"
+	"http-task-executor/internal/models"
+	"io"
+	"net"
+	"syscall"
+)
+
+func classifyError(err error) string {
+	var netErr net.Error
+	var dnsErr *net.DNSError
+	var opErr *net.OpError
+	var recordHeaderErr tls.RecordHeaderError
+	var certVerificationErr *tls.CertificateVerificationError
+	var unknownAuthorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var certInvalidErr x509.CertificateInvalidError
+
+	switch {
+	case errors.Is(err, breaker.ErrOpen):
+		return models.ErrorClassCircuitOpen
+	case errors.Is(err, egress.ErrDenied):
+		return models.ErrorClassOther
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return models.ErrorClassTimeout
+	case errors.As(err, &dnsErr):
+		return models.ErrorClassDNS
+	case errors.As(err, &recordHeaderErr), errors.As(err, &certVerificationErr),
+		errors.As(err, &unknownAuthorityErr), errors.As(err, &hostnameErr), errors.As(err, &certInvalidErr):
+		return models.ErrorClassTLS
+	case errors.As(err, &opErr), errors.Is(err, syscall.ECONNREFUSED), errors.Is(err, syscall.ECONNRESET),
+		errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, io.EOF):
+		return models.ErrorClassConnection
+	default:
+		return models.ErrorClassOther
+	}
+}
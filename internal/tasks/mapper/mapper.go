@@ -1,33 +1,189 @@
 package mapper
 
 import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"http-task-executor/internal/models"
 	"http-task-executor/internal/tasks/delivery/http/dto"
+	httpErrors "http-task-executor/pkg/errors/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 )
 
-func MapRequestToTask(req *dto.NewTaskRequest) models.Task {
+const (
+	defaultBackoffBaseMs = 1000
+	defaultBackoffMaxMs  = 60000
+	maxIdempotencyKeyLen = 255
+)
+
+func MapRequestToTask(req *dto.NewTaskRequest) (models.Task, error) {
 	task := models.Task{}
 	task.Url = req.Url
 	task.Method = req.Method
+	task.Body = req.Body
+	task.BodyEncoding = req.BodyEncoding
+	if task.BodyEncoding == "" && task.Body != "" {
+		task.BodyEncoding = models.BodyEncodingText
+	}
 	task.Status = models.StatusNew
+	runAt, err := mapRunAt(req)
+	if err != nil {
+		return task, err
+	}
+	if runAt != nil && runAt.After(time.Now()) {
+		task.RunAt = runAt
+		task.Status = models.StatusScheduled
+	}
+	task.RetryPolicy = mapRetryPolicy(req.Retry)
+	task.TlsProfile = req.TlsProfile
+	if req.Callback != nil {
+		task.Callback = &models.Callback{Url: req.Callback.Url, Secret: req.Callback.Secret}
+	}
 	task.Headers = make([]models.Header, 0)
 	if len(req.Headers) > 0 {
 		for name, value := range req.Headers {
 			task.Headers = append(task.Headers, models.Header{Name: name, Value: value, Input: true})
 		}
 	}
-	return task
+	return task, nil
+}
+
+// MapIdempotencyKey attaches the key to the task along with a hash of the request,
+// which tells a retry of the request apart from a different request reusing the key.
+func MapIdempotencyKey(key string, req *dto.NewTaskRequest, task *models.Task) error {
+	if key == "" {
+		return nil
+	}
+	if len(key) > maxIdempotencyKeyLen {
+		return httpErrors.NewBadRequestError(fmt.Errorf("idempotency key exceeds %d characters", maxIdempotencyKeyLen))
+	}
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(payload)
+	hash := hex.EncodeToString(sum[:])
+	task.IdempotencyKey = &key
+	task.RequestHash = &hash
+	return nil
+}
+
+func MapBatchRequestToTasks(req []dto.NewTaskRequest) ([]models.Task, error) {
+	tasks := make([]models.Task, 0, len(req))
+	itemErrors := make([]httpErrors.ItemError, 0)
+	for i := range req {
+		task, err := MapRequestToTask(&req[i])
+		if err != nil {
+			itemErrors = append(itemErrors, httpErrors.NewItemError(i, err))
+		}
+		tasks = append(tasks, task)
+	}
+	if len(itemErrors) > 0 {
+		return nil, httpErrors.NewBatchValidationError(itemErrors)
+	}
+	return tasks, nil
+}
+
+func MapQueryToBatchTracking(query url.Values) (bool, error) {
+	value := query.Get("track")
+	if value == "" {
+		return false, nil
+	}
+	track, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, httpErrors.NewBadRequestError(errors.New("invalid track"))
+	}
+	return track, nil
+}
+
+func mapRunAt(req *dto.NewTaskRequest) (*time.Time, error) {
+	if req.Delay == "" {
+		return req.RunAt, nil
+	}
+	if req.RunAt != nil {
+		return nil, httpErrors.NewBadRequestError(errors.New("runAt and delay are mutually exclusive"))
+	}
+	delay, err := time.ParseDuration(req.Delay)
+	if err != nil || delay < 0 {
+		return nil, httpErrors.NewBadRequestError(fmt.Errorf("invalid delay %s", req.Delay))
+	}
+	runAt := time.Now().Add(delay)
+	return &runAt, nil
+}
+
+func mapRetryPolicy(req *dto.RetryPolicy) *models.RetryPolicy {
+	if req == nil {
+		return nil
+	}
+	policy := &models.RetryPolicy{
+		MaxAttempts:   req.MaxAttempts,
+		BackoffBaseMs: req.BackoffBaseMs,
+		BackoffMaxMs:  req.BackoffMaxMs,
+		Jitter:        req.Jitter,
+		RetryOnStatus: req.RetryOnStatus,
+		RetryOnErrors: req.RetryOnErrors,
+	}
+	if policy.BackoffBaseMs == 0 {
+		policy.BackoffBaseMs = defaultBackoffBaseMs
+	}
+	if policy.BackoffMaxMs == 0 {
+		policy.BackoffMaxMs = max(defaultBackoffMaxMs, policy.BackoffBaseMs)
+	}
+	if len(policy.RetryOnStatus) == 0 && len(policy.RetryOnErrors) == 0 {
+		policy.RetryOnErrors = []string{models.ErrorClassTimeout, models.ErrorClassConnection, models.ErrorClassCircuitOpen}
+	}
+	return policy
 }
 
 func MapIdToTaskResponse(id int64) dto.NewTaskResponse {
 	return dto.NewTaskResponse{Id: id}
 }
 
+func MapBatchToResponse(batch *models.Batch) dto.NewBatchResponse {
+	return dto.NewBatchResponse{Ids: batch.TaskIds, BatchId: batch.Id}
+}
+
+func MapBatchProgressToResponse(progress *models.BatchProgress) dto.BatchProgressResponse {
+	finished := progress.Finished()
+	return dto.BatchProgressResponse{
+		ID:        progress.Id,
+		Total:     progress.Size,
+		Finished:  finished,
+		Completed: finished >= progress.Size,
+		Statuses:  progress.Statuses,
+		CreatedAt: progress.CreatedAt,
+	}
+}
+
+func MapCancelledTaskToResponse(id int64, previousStatus string) dto.CancelTaskResponse {
+	return dto.CancelTaskResponse{ID: id, Status: models.StatusCancelled, PreviousStatus: previousStatus}
+}
+
 func MapTaskToGetResponse(task *models.Task) dto.GetTaskResponse {
 	response := dto.GetTaskResponse{ID: task.Id,
 		Status:         task.Status,
 		ResponseStatus: task.ResponseStatus,
-		ResponseLength: task.ResponseLength}
+		ResponseLength: task.ResponseLength,
+		Attempts:       task.Attempts,
+		NextAttemptAt:  task.NextAttemptAt,
+		LastError:      task.LastError,
+		RunAt:          task.RunAt,
+		TraceId:        task.TraceId,
+		TlsProfile:     task.TlsProfile}
+	if task.Callback != nil {
+		response.Callback = &dto.CallbackResponse{
+			Url:       task.Callback.Url,
+			Status:    task.Callback.Status,
+			Attempts:  task.Callback.Attempts,
+			LastError: task.Callback.LastError,
+		}
+	}
 	response.Headers = make(map[string]string)
 	if len(task.Headers) > 0 {
 		for _, header := range task.Headers {
@@ -36,3 +192,148 @@ func MapTaskToGetResponse(task *models.Task) dto.GetTaskResponse {
 	}
 	return response
 }
+
+func MapAttemptsToResponse(attempts []models.TaskAttempt) []dto.TaskAttemptResponse {
+	response := make([]dto.TaskAttemptResponse, 0, len(attempts))
+	for _, attempt := range attempts {
+		response = append(response, dto.TaskAttemptResponse{
+			Attempt:        attempt.Attempt,
+			StartedAt:      attempt.StartedAt,
+			FinishedAt:     attempt.FinishedAt,
+			DurationMs:     attempt.DurationMs,
+			ResponseStatus: attempt.ResponseStatus,
+			ResponseLength: attempt.ResponseLength,
+			Error:          attempt.Error,
+		})
+	}
+	return response
+}
+
+func MapQueryToTaskFilter(query url.Values) (models.TaskFilter, error) {
+	filter := models.TaskFilter{
+		Method:    query.Get("method"),
+		Host:      query.Get("host"),
+		UrlPrefix: query.Get("urlPrefix"),
+		SortBy:    query.Get("sort"),
+		SortDesc:  query.Get("order") != "asc",
+	}
+	if order := query.Get("order"); order != "" && order != "asc" && order != "desc" {
+		return filter, httpErrors.NewBadRequestError(fmt.Errorf("invalid order %s", order))
+	}
+	filter.Statuses = parseStatuses(query)
+
+	var err error
+	if filter.MinResponseStatus, err = parseInt64Param(query, "minCode"); err != nil {
+		return filter, err
+	}
+	if filter.MaxResponseStatus, err = parseInt64Param(query, "maxCode"); err != nil {
+		return filter, err
+	}
+	if filter.CreatedFrom, err = parseTimeParam(query, "createdFrom"); err != nil {
+		return filter, err
+	}
+	if filter.CreatedTo, err = parseTimeParam(query, "createdTo"); err != nil {
+		return filter, err
+	}
+	if limit, err := parseInt64Param(query, "limit"); err != nil {
+		return filter, err
+	} else if limit != nil {
+		filter.Limit = int(*limit)
+	}
+	if cursor := query.Get("cursor"); cursor != "" {
+		if filter.After, err = decodeCursor(cursor); err != nil {
+			return filter, httpErrors.NewBadRequestError(errors.New("invalid cursor"))
+		}
+	}
+	return filter, nil
+}
+
+func MapTaskPageToResponse(page *models.TaskPage) dto.TaskListResponse {
+	response := dto.TaskListResponse{Tasks: make([]dto.TaskSummaryResponse, 0, len(page.Tasks))}
+	for _, task := range page.Tasks {
+		response.Tasks = append(response.Tasks, dto.TaskSummaryResponse{
+			ID:             task.Id,
+			Url:            task.Url,
+			Method:         task.Method,
+			Status:         task.Status,
+			ResponseStatus: task.ResponseStatus,
+			ResponseLength: task.ResponseLength,
+			Attempts:       task.Attempts,
+			NextAttemptAt:  task.NextAttemptAt,
+			LastError:      task.LastError,
+			RunAt:          task.RunAt,
+			CreatedAt:      task.CreatedAt,
+		})
+	}
+	if page.Next != nil {
+		response.NextCursor = encodeCursor(page.Next)
+	}
+	return response
+}
+
+func MapQueryToEventFilter(query url.Values) models.TaskEventFilter {
+	return models.TaskEventFilter{Statuses: parseStatuses(query)}
+}
+
+func MapEventToResponse(event models.TaskEvent) dto.TaskEventResponse {
+	return dto.TaskEventResponse{
+		ID:             event.TaskId,
+		Status:         event.Status,
+		ResponseStatus: event.ResponseStatus,
+		LastError:      event.LastError,
+		At:             event.At,
+	}
+}
+
+func parseStatuses(query url.Values) []string {
+	var statuses []string
+	for _, status := range query["status"] {
+		for _, s := range strings.Split(status, ",") {
+			if s != "" {
+				statuses = append(statuses, s)
+			}
+		}
+	}
+	return statuses
+}
+
+func parseInt64Param(query url.Values, name string) (*int64, error) {
+	value := query.Get(name)
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return nil, httpErrors.NewBadRequestError(fmt.Errorf("invalid %s", name))
+	}
+	return &parsed, nil
+}
+
+func parseTimeParam(query url.Values, name string) (*time.Time, error) {
+	value := query.Get(name)
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, httpErrors.NewBadRequestError(fmt.Errorf("invalid %s, expected RFC3339", name))
+	}
+	return &parsed, nil
+}
+
+func encodeCursor(cursor *models.TaskCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(value string) (*models.TaskCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	cursor := &models.TaskCursor{}
+	if err := json.Unmarshal(data, cursor); err != nil {
+		return nil, err
+	}
+	return cursor, nil
+}
@@ -11,6 +11,7 @@ package mock
 
 import (
 	models "http-task-executor/internal/models"
+	http "net/http"
 	reflect "reflect"
 
 	gomock "go.uber.org/mock/gomock"
@@ -40,6 +41,20 @@ func (m *MockExecutor) EXPECT() *MockExecutorMockRecorder {
 	return m.recorder
 }
 
+// Cancel mocks base method.
+func (m *MockExecutor) Cancel(id int64) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Cancel", id)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// Cancel indicates an expected call of Cancel.
+func (mr *MockExecutorMockRecorder) Cancel(id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Cancel", reflect.TypeOf((*MockExecutor)(nil).Cancel), id)
+}
+
 // ExecuteTask mocks base method.
 func (m *MockExecutor) ExecuteTask(task models.Task) {
 	m.ctrl.T.Helper()
@@ -51,3 +66,56 @@ func (mr *MockExecutorMockRecorder) ExecuteTask(task any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecuteTask", reflect.TypeOf((*MockExecutor)(nil).ExecuteTask), task)
 }
+
+// MockClientProvider is a mock of ClientProvider interface.
+type MockClientProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockClientProviderMockRecorder
+	isgomock struct{}
+}
+
+// MockClientProviderMockRecorder is the mock recorder for MockClientProvider.
+type MockClientProviderMockRecorder struct {
+	mock *MockClientProvider
+}
+
+// NewMockClientProvider creates a new mock instance.
+func NewMockClientProvider(ctrl *gomock.Controller) *MockClientProvider {
+	mock := &MockClientProvider{ctrl: ctrl}
+	mock.recorder = &MockClientProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockClientProvider) EXPECT() *MockClientProviderMockRecorder {
+	return m.recorder
+}
+
+// Client mocks base method.
+func (m *MockClientProvider) Client() *http.Client {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Client")
+	ret0, _ := ret[0].(*http.Client)
+	return ret0
+}
+
+// Client indicates an expected call of Client.
+func (mr *MockClientProviderMockRecorder) Client() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Client", reflect.TypeOf((*MockClientProvider)(nil).Client))
+}
+
+// ProfileClient mocks base method.
+func (m *MockClientProvider) ProfileClient(name string) (*http.Client, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProfileClient", name)
+	ret0, _ := ret[0].(*http.Client)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ProfileClient indicates an expected call of ProfileClient.
+func (mr *MockClientProviderMockRecorder) ProfileClient(name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProfileClient", reflect.TypeOf((*MockClientProvider)(nil).ProfileClient), name)
+}
@@ -13,6 +13,7 @@ import (
 	context "context"
 	models "http-task-executor/internal/models"
 	reflect "reflect"
+	time "time"
 
 	gomock "go.uber.org/mock/gomock"
 )
@@ -41,21 +42,156 @@ func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
 	return m.recorder
 }
 
-// Create mocks base method.
-func (m *MockRepository) Create(ctx context.Context, task *models.Task) (*models.Task, error) {
+// Cancel mocks base method.
+func (m *MockRepository) Cancel(ctx context.Context, id int64) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Create", ctx, task)
+	ret := m.ctrl.Call(m, "Cancel", ctx, id)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Cancel indicates an expected call of Cancel.
+func (mr *MockRepositoryMockRecorder) Cancel(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Cancel", reflect.TypeOf((*MockRepository)(nil).Cancel), ctx, id)
+}
+
+// ClaimCallback mocks base method.
+func (m *MockRepository) ClaimCallback(ctx context.Context, leaseUntil time.Time) (*models.Task, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimCallback", ctx, leaseUntil)
+	ret0, _ := ret[0].(*models.Task)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimCallback indicates an expected call of ClaimCallback.
+func (mr *MockRepositoryMockRecorder) ClaimCallback(ctx, leaseUntil any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimCallback", reflect.TypeOf((*MockRepository)(nil).ClaimCallback), ctx, leaseUntil)
+}
+
+// ClaimNew mocks base method.
+func (m *MockRepository) ClaimNew(ctx context.Context) (*models.Task, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimNew", ctx)
 	ret0, _ := ret[0].(*models.Task)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
+// ClaimNew indicates an expected call of ClaimNew.
+func (mr *MockRepositoryMockRecorder) ClaimNew(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimNew", reflect.TypeOf((*MockRepository)(nil).ClaimNew), ctx)
+}
+
+// ClaimStuck mocks base method.
+func (m *MockRepository) ClaimStuck(ctx context.Context, leaseExpiredAt time.Time) (*models.Task, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimStuck", ctx, leaseExpiredAt)
+	ret0, _ := ret[0].(*models.Task)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimStuck indicates an expected call of ClaimStuck.
+func (mr *MockRepositoryMockRecorder) ClaimStuck(ctx, leaseExpiredAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimStuck", reflect.TypeOf((*MockRepository)(nil).ClaimStuck), ctx, leaseExpiredAt)
+}
+
+// CountBacklog mocks base method.
+func (m *MockRepository) CountBacklog(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountBacklog", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountBacklog indicates an expected call of CountBacklog.
+func (mr *MockRepositoryMockRecorder) CountBacklog(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountBacklog", reflect.TypeOf((*MockRepository)(nil).CountBacklog), ctx)
+}
+
+// Create mocks base method.
+func (m *MockRepository) Create(ctx context.Context, task *models.Task) (*models.Task, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, task)
+	ret0, _ := ret[0].(*models.Task)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
 // Create indicates an expected call of Create.
 func (mr *MockRepositoryMockRecorder) Create(ctx, task any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockRepository)(nil).Create), ctx, task)
 }
 
+// CreateAttempt mocks base method.
+func (m *MockRepository) CreateAttempt(ctx context.Context, attempt *models.TaskAttempt) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAttempt", ctx, attempt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateAttempt indicates an expected call of CreateAttempt.
+func (mr *MockRepositoryMockRecorder) CreateAttempt(ctx, attempt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAttempt", reflect.TypeOf((*MockRepository)(nil).CreateAttempt), ctx, attempt)
+}
+
+// CreateBatch mocks base method.
+func (m *MockRepository) CreateBatch(ctx context.Context, tasks []models.Task, track bool) (*models.Batch, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateBatch", ctx, tasks, track)
+	ret0, _ := ret[0].(*models.Batch)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateBatch indicates an expected call of CreateBatch.
+func (mr *MockRepositoryMockRecorder) CreateBatch(ctx, tasks, track any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateBatch", reflect.TypeOf((*MockRepository)(nil).CreateBatch), ctx, tasks, track)
+}
+
+// GetAttempts mocks base method.
+func (m *MockRepository) GetAttempts(ctx context.Context, id int64) ([]models.TaskAttempt, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAttempts", ctx, id)
+	ret0, _ := ret[0].([]models.TaskAttempt)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAttempts indicates an expected call of GetAttempts.
+func (mr *MockRepositoryMockRecorder) GetAttempts(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAttempts", reflect.TypeOf((*MockRepository)(nil).GetAttempts), ctx, id)
+}
+
+// GetBatchProgress mocks base method.
+func (m *MockRepository) GetBatchProgress(ctx context.Context, id int64) (*models.BatchProgress, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBatchProgress", ctx, id)
+	ret0, _ := ret[0].(*models.BatchProgress)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBatchProgress indicates an expected call of GetBatchProgress.
+func (mr *MockRepositoryMockRecorder) GetBatchProgress(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBatchProgress", reflect.TypeOf((*MockRepository)(nil).GetBatchProgress), ctx, id)
+}
+
 // GetByIdWithOutputHeaders mocks base method.
 func (m *MockRepository) GetByIdWithOutputHeaders(ctx context.Context, id int64) (*models.Task, error) {
 	m.ctrl.T.Helper()
@@ -71,6 +207,135 @@ func (mr *MockRepositoryMockRecorder) GetByIdWithOutputHeaders(ctx, id any) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByIdWithOutputHeaders", reflect.TypeOf((*MockRepository)(nil).GetByIdWithOutputHeaders), ctx, id)
 }
 
+// GetClient mocks base method.
+func (m *MockRepository) GetClient(ctx context.Context, id int64) (*string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetClient", ctx, id)
+	ret0, _ := ret[0].(*string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetClient indicates an expected call of GetClient.
+func (mr *MockRepositoryMockRecorder) GetClient(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetClient", reflect.TypeOf((*MockRepository)(nil).GetClient), ctx, id)
+}
+
+// GetResponse mocks base method.
+func (m *MockRepository) GetResponse(ctx context.Context, id int64) (*models.TaskResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetResponse", ctx, id)
+	ret0, _ := ret[0].(*models.TaskResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetResponse indicates an expected call of GetResponse.
+func (mr *MockRepositoryMockRecorder) GetResponse(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetResponse", reflect.TypeOf((*MockRepository)(nil).GetResponse), ctx, id)
+}
+
+// Heartbeat mocks base method.
+func (m *MockRepository) Heartbeat(ctx context.Context, ids []int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Heartbeat", ctx, ids)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Heartbeat indicates an expected call of Heartbeat.
+func (mr *MockRepositoryMockRecorder) Heartbeat(ctx, ids any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Heartbeat", reflect.TypeOf((*MockRepository)(nil).Heartbeat), ctx, ids)
+}
+
+// List mocks base method.
+func (m *MockRepository) List(ctx context.Context, filter models.TaskFilter) ([]models.Task, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, filter)
+	ret0, _ := ret[0].([]models.Task)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockRepositoryMockRecorder) List(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockRepository)(nil).List), ctx, filter)
+}
+
+// NotifyEvent mocks base method.
+func (m *MockRepository) NotifyEvent(ctx context.Context, event models.TaskEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NotifyEvent", ctx, event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// NotifyEvent indicates an expected call of NotifyEvent.
+func (mr *MockRepositoryMockRecorder) NotifyEvent(ctx, event any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NotifyEvent", reflect.TypeOf((*MockRepository)(nil).NotifyEvent), ctx, event)
+}
+
+// Requeue mocks base method.
+func (m *MockRepository) Requeue(ctx context.Context, id int64, nextAttemptAt *time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Requeue", ctx, id, nextAttemptAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Requeue indicates an expected call of Requeue.
+func (mr *MockRepositoryMockRecorder) Requeue(ctx, id, nextAttemptAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Requeue", reflect.TypeOf((*MockRepository)(nil).Requeue), ctx, id, nextAttemptAt)
+}
+
+// ScheduleRetry mocks base method.
+func (m *MockRepository) ScheduleRetry(ctx context.Context, id int64, nextAttemptAt time.Time, lastError string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ScheduleRetry", ctx, id, nextAttemptAt, lastError)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ScheduleRetry indicates an expected call of ScheduleRetry.
+func (mr *MockRepositoryMockRecorder) ScheduleRetry(ctx, id, nextAttemptAt, lastError any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScheduleRetry", reflect.TypeOf((*MockRepository)(nil).ScheduleRetry), ctx, id, nextAttemptAt, lastError)
+}
+
+// UpdateCallbackStatus mocks base method.
+func (m *MockRepository) UpdateCallbackStatus(ctx context.Context, id int64, status string, nextAttemptAt *time.Time, lastError *string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateCallbackStatus", ctx, id, status, nextAttemptAt, lastError)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateCallbackStatus indicates an expected call of UpdateCallbackStatus.
+func (mr *MockRepositoryMockRecorder) UpdateCallbackStatus(ctx, id, status, nextAttemptAt, lastError any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateCallbackStatus", reflect.TypeOf((*MockRepository)(nil).UpdateCallbackStatus), ctx, id, status, nextAttemptAt, lastError)
+}
+
+// UpdateError mocks base method.
+func (m *MockRepository) UpdateError(ctx context.Context, id int64, lastError string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateError", ctx, id, lastError)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateError indicates an expected call of UpdateError.
+func (mr *MockRepositoryMockRecorder) UpdateError(ctx, id, lastError any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateError", reflect.TypeOf((*MockRepository)(nil).UpdateError), ctx, id, lastError)
+}
+
 // UpdateResult mocks base method.
 func (m *MockRepository) UpdateResult(ctx context.Context, task *models.Task) error {
 	m.ctrl.T.Helper()
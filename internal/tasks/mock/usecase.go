@@ -41,6 +41,21 @@ func (m *MockUseCase) EXPECT() *MockUseCaseMockRecorder {
 	return m.recorder
 }
 
+// Cancel mocks base method.
+func (m *MockUseCase) Cancel(ctx context.Context, id int64) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Cancel", ctx, id)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Cancel indicates an expected call of Cancel.
+func (mr *MockUseCaseMockRecorder) Cancel(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Cancel", reflect.TypeOf((*MockUseCase)(nil).Cancel), ctx, id)
+}
+
 // Create mocks base method.
 func (m *MockUseCase) Create(ctx context.Context, task *models.Task) (*models.Task, error) {
 	m.ctrl.T.Helper()
@@ -56,6 +71,51 @@ func (mr *MockUseCaseMockRecorder) Create(ctx, task any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockUseCase)(nil).Create), ctx, task)
 }
 
+// CreateBatch mocks base method.
+func (m *MockUseCase) CreateBatch(ctx context.Context, tasks []models.Task, track bool) (*models.Batch, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateBatch", ctx, tasks, track)
+	ret0, _ := ret[0].(*models.Batch)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateBatch indicates an expected call of CreateBatch.
+func (mr *MockUseCaseMockRecorder) CreateBatch(ctx, tasks, track any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateBatch", reflect.TypeOf((*MockUseCase)(nil).CreateBatch), ctx, tasks, track)
+}
+
+// GetAttempts mocks base method.
+func (m *MockUseCase) GetAttempts(ctx context.Context, id int64) ([]models.TaskAttempt, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAttempts", ctx, id)
+	ret0, _ := ret[0].([]models.TaskAttempt)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAttempts indicates an expected call of GetAttempts.
+func (mr *MockUseCaseMockRecorder) GetAttempts(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAttempts", reflect.TypeOf((*MockUseCase)(nil).GetAttempts), ctx, id)
+}
+
+// GetBatchProgress mocks base method.
+func (m *MockUseCase) GetBatchProgress(ctx context.Context, id int64) (*models.BatchProgress, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBatchProgress", ctx, id)
+	ret0, _ := ret[0].(*models.BatchProgress)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBatchProgress indicates an expected call of GetBatchProgress.
+func (mr *MockUseCaseMockRecorder) GetBatchProgress(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBatchProgress", reflect.TypeOf((*MockUseCase)(nil).GetBatchProgress), ctx, id)
+}
+
 // GetByIdWithOutputHeaders mocks base method.
 func (m *MockUseCase) GetByIdWithOutputHeaders(ctx context.Context, id int64) (*models.Task, error) {
 	m.ctrl.T.Helper()
@@ -70,3 +130,63 @@ func (mr *MockUseCaseMockRecorder) GetByIdWithOutputHeaders(ctx, id any) *gomock
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByIdWithOutputHeaders", reflect.TypeOf((*MockUseCase)(nil).GetByIdWithOutputHeaders), ctx, id)
 }
+
+// GetResponse mocks base method.
+func (m *MockUseCase) GetResponse(ctx context.Context, id int64) (*models.TaskResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetResponse", ctx, id)
+	ret0, _ := ret[0].(*models.TaskResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetResponse indicates an expected call of GetResponse.
+func (mr *MockUseCaseMockRecorder) GetResponse(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetResponse", reflect.TypeOf((*MockUseCase)(nil).GetResponse), ctx, id)
+}
+
+// List mocks base method.
+func (m *MockUseCase) List(ctx context.Context, filter models.TaskFilter) (*models.TaskPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, filter)
+	ret0, _ := ret[0].(*models.TaskPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockUseCaseMockRecorder) List(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockUseCase)(nil).List), ctx, filter)
+}
+
+// Subscribe mocks base method.
+func (m *MockUseCase) Subscribe(ctx context.Context, filter models.TaskEventFilter) (<-chan models.TaskEvent, func(), error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Subscribe", ctx, filter)
+	ret0, _ := ret[0].(<-chan models.TaskEvent)
+	ret1, _ := ret[1].(func())
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Subscribe indicates an expected call of Subscribe.
+func (mr *MockUseCaseMockRecorder) Subscribe(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Subscribe", reflect.TypeOf((*MockUseCase)(nil).Subscribe), ctx, filter)
+}
+
+// Validate mocks base method.
+func (m *MockUseCase) Validate(ctx context.Context, task *models.Task) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Validate", ctx, task)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Validate indicates an expected call of Validate.
+func (mr *MockUseCaseMockRecorder) Validate(ctx, task any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Validate", reflect.TypeOf((*MockUseCase)(nil).Validate), ctx, task)
+}
@@ -0,0 +1,6 @@
+//go:generate mockgen -source pool.go -destination mock/pool.go -package mock
+package tasks
+
+type Pool interface {
+	Notify()
+}
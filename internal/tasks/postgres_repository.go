@@ -4,11 +4,30 @@ package tasks
 import (
 	"context"
 	"http-task-executor/internal/models"
+	"time"
 )
 
 type Repository interface {
-	Create(ctx context.Context, task *models.Task) (*models.Task, error)
+	Create(ctx context.Context, task *models.Task) (created *models.Task, replayed bool, err error)
+	CreateBatch(ctx context.Context, tasks []models.Task, track bool) (*models.Batch, error)
+	GetBatchProgress(ctx context.Context, id int64) (*models.BatchProgress, error)
 	GetByIdWithOutputHeaders(ctx context.Context, id int64) (*models.Task, error)
+	GetClient(ctx context.Context, id int64) (*string, error)
 	UpdateStatus(ctx context.Context, id int64, newStatus string) error
 	UpdateResult(ctx context.Context, task *models.Task) error
+	ClaimNew(ctx context.Context) (*models.Task, error)
+	CountBacklog(ctx context.Context) (int64, error)
+	Heartbeat(ctx context.Context, ids []int64) error
+	ClaimStuck(ctx context.Context, leaseExpiredAt time.Time) (*models.Task, error)
+	GetResponse(ctx context.Context, id int64) (*models.TaskResponse, error)
+	ScheduleRetry(ctx context.Context, id int64, nextAttemptAt time.Time, lastError string) error
+	UpdateError(ctx context.Context, id int64, lastError string) error
+	Requeue(ctx context.Context, id int64, nextAttemptAt *time.Time) error
+	Cancel(ctx context.Context, id int64) (string, error)
+	ClaimCallback(ctx context.Context, leaseUntil time.Time) (*models.Task, error)
+	UpdateCallbackStatus(ctx context.Context, id int64, status string, nextAttemptAt *time.Time, lastError *string) error
+	NotifyEvent(ctx context.Context, event models.TaskEvent) error
+	CreateAttempt(ctx context.Context, attempt *models.TaskAttempt) error
+	GetAttempts(ctx context.Context, id int64) ([]models.TaskAttempt, error)
+	List(ctx context.Context, filter models.TaskFilter) ([]models.Task, error)
 }
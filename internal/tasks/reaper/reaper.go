@@ -0,0 +1,115 @@
+package reaper
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"http-task-executor/internal/config"
+	"http-task-executor/internal/logger"
+	"http-task-executor/internal/metrics"
+	"http-task-executor/internal/models"
+	"http-task-executor/internal/tasks"
+	"sync"
+	"time"
+)
+
+const (
+	ActionRequeued = "requeued"
+	ActionFailed   = "failed"
+)
+
+// Reaper recovers tasks left in process by an instance that died mid-execution. A task whose
+// heartbeat is older than the lease is retried when its retry policy allows another attempt,
+// otherwise it fails, as the target may have seen the request already.
+type Reaper struct {
+	log          logger.Logger
+	repo         tasks.Repository
+	events       tasks.EventPublisher
+	pollInterval time.Duration
+	lease        time.Duration
+	wg           sync.WaitGroup
+}
+
+func NewReaper(log logger.Logger, repo tasks.Repository, events tasks.EventPublisher, cfg config.ReaperConfig) *Reaper {
+	pollInterval := cfg.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = 30 * time.Second
+	}
+	lease := cfg.Lease
+	if lease <= 0 {
+		lease = 2 * time.Minute
+	}
+	return &Reaper{log: log, repo: repo, events: events, pollInterval: pollInterval, lease: lease}
+}
+
+func (r *Reaper) Start(ctx context.Context) {
+	r.log.Infof("Starting reaper with lease %s and poll interval %s", r.lease, r.pollInterval)
+	r.wg.Add(1)
+	go r.run(ctx)
+}
+
+func (r *Reaper) Wait() {
+	r.wg.Wait()
+}
+
+func (r *Reaper) run(ctx context.Context) {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(r.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+
+		task, err := r.repo.ClaimStuck(ctx, time.Now().Add(-r.lease))
+		switch {
+		case err == nil:
+			r.Reap(ctx, task)
+			continue
+		case errors.Is(err, sql.ErrNoRows):
+		case ctx.Err() != nil:
+			return
+		default:
+			r.log.Errorf("Reaper.run.ClaimStuck : %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Reaper) Reap(ctx context.Context, task *models.Task) {
+	reason := fmt.Sprintf("execution lease of %s expired", r.lease)
+	if task.StartedAt != nil {
+		reason = fmt.Sprintf("%s, started at %s", reason, task.StartedAt.Format(time.RFC3339))
+	}
+
+	if task.RetryPolicy.ShouldRetry(task.Attempts) {
+		nextAttemptAt := time.Now().Add(task.RetryPolicy.Backoff(task.Attempts))
+		err := r.repo.ScheduleRetry(ctx, task.Id, nextAttemptAt, reason)
+		if err != nil {
+			r.log.Errorf("Reaper.Reap.ScheduleRetry : %v", err)
+			return
+		}
+		r.log.Infof("Reaper.Reap: task %v requeued, %s", task.Id, reason)
+		metrics.TasksReaped.WithLabelValues(ActionRequeued).Inc()
+		r.events.Publish(models.TaskEvent{TaskId: task.Id, Status: models.StatusNew, LastError: &reason, At: time.Now(), Client: task.Client})
+		return
+	}
+
+	err := r.repo.UpdateError(ctx, task.Id, reason)
+	if err != nil {
+		r.log.Errorf("Reaper.Reap.UpdateError : %v", err)
+		return
+	}
+	r.log.Infof("Reaper.Reap: task %v failed, %s", task.Id, reason)
+	metrics.TasksReaped.WithLabelValues(ActionFailed).Inc()
+	metrics.TasksFinished.WithLabelValues(models.StatusError).Inc()
+	r.events.Publish(models.TaskEvent{TaskId: task.Id, Status: models.StatusError, LastError: &reason, At: time.Now(), Client: task.Client})
+}
@@ -0,0 +1,67 @@
+package reaper
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"http-task-executor/internal/config"
+	"http-task-executor/internal/models"
+	"http-task-executor/internal/tasks/events"
+	"http-task-executor/internal/tasks/mock"
+	"strings"
+	"testing"
+	"time"
+)
+
+var reaperConfig = config.ReaperConfig{
+	PollInterval: time.Hour,
+	Lease:        time.Minute,
+}
+
+func TestReaper_Reap(t *testing.T) {
+	t.Parallel()
+	ctrx := gomock.NewController(t)
+	defer ctrx.Finish()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	mockTasksRepo := mock.NewMockRepository(ctrx)
+	bus := events.NewBus(sugar, 0)
+	reaper := NewReaper(sugar, mockTasksRepo, bus, reaperConfig)
+
+	isLeaseReason := func(x string) bool { return strings.HasPrefix(x, "execution lease of 1m0s expired") }
+
+	t.Run("Requeued when retry policy allows", func(t *testing.T) {
+		events, unsubscribe := bus.Subscribe(models.TaskEventFilter{})
+		defer unsubscribe()
+		task := &models.Task{Id: 1, Status: models.StatusInProcess, Attempts: 1, RetryPolicy: &models.RetryPolicy{MaxAttempts: 3, BackoffBaseMs: 1000, BackoffMaxMs: 1000}}
+
+		mockTasksRepo.EXPECT().ScheduleRetry(gomock.Any(), task.Id,
+			gomock.Cond(func(x time.Time) bool { return x.After(time.Now()) }),
+			gomock.Cond(isLeaseReason)).Return(nil).Times(1)
+
+		reaper.Reap(context.Background(), task)
+
+		event := <-events
+		require.Equal(t, models.StatusNew, event.Status)
+	})
+
+	t.Run("Failed without retry policy", func(t *testing.T) {
+		events, unsubscribe := bus.Subscribe(models.TaskEventFilter{})
+		defer unsubscribe()
+		startedAt := time.Now().Add(-time.Hour)
+		task := &models.Task{Id: 2, Status: models.StatusInProcess, Attempts: 1, StartedAt: &startedAt}
+
+		mockTasksRepo.EXPECT().UpdateError(gomock.Any(), task.Id, gomock.Cond(func(x string) bool {
+			return isLeaseReason(x) && strings.Contains(x, startedAt.Format(time.RFC3339))
+		})).Return(nil).Times(1)
+
+		reaper.Reap(context.Background(), task)
+
+		event := <-events
+		require.Equal(t, models.StatusError, event.Status)
+		require.NotNil(t, event.LastError)
+	})
+}
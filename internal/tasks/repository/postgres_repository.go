@@ -3,14 +3,39 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"github.com/jmoiron/sqlx"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/trace"
 	"http-task-executor/internal/logger"
+	"http-task-executor/internal/metrics"
 	"http-task-executor/internal/models"
+	"http-task-executor/internal/tracing"
+	"slices"
 	"strings"
+	"time"
 )
 
+// observe traces the repository method and records its latency, the returned func ends both.
+func observe(ctx context.Context, method string) (context.Context, func()) {
+	ctx, span := tracing.Start(ctx, "TaskRepository."+method, trace.WithSpanKind(trace.SpanKindClient))
+	start := time.Now()
+	return ctx, func() {
+		metrics.ObserveQuery(method, start)
+		span.End()
+	}
+}
+
+// maxNotifiedErrorLen keeps notification payloads below the Postgres limit of 8000 bytes.
+const maxNotifiedErrorLen = 1024
+
+// headersPerStatement keeps multi-row header inserts well below the limit of bind parameters.
+const headersPerStatement = 1000
+
+// tasksPerStatement keeps multi-row task inserts, 15 parameters a row, well below the limit of bind parameters.
+const tasksPerStatement = 1000
+
 type TaskRepository struct {
 	db  *sqlx.DB
 	log logger.Logger
@@ -20,60 +45,212 @@ func NewRepository(db *sqlx.DB, log logger.Logger) *TaskRepository {
 	return &TaskRepository{db: db, log: log}
 }
 
-func (r *TaskRepository) Create(ctx context.Context, task *models.Task) (*models.Task, error) {
+// Create inserts the task. When the client already created a task with the same idempotency key,
+// that task is returned instead and replayed is reported.
+func (r *TaskRepository) Create(ctx context.Context, task *models.Task) (*models.Task, bool, error) {
+	ctx, done := observe(ctx, "Create")
+	defer done()
+
 	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
 	if err != nil {
-		return nil, errors.Wrap(err, "TaskRepository.Create.BeginTx")
+		return nil, false, errors.Wrap(err, "TaskRepository.Create.BeginTx")
 	}
 
 	if task.Headers == nil {
 		task.Headers = make([]models.Header, 0)
 	}
 
-	prepare, err := tx.PrepareContext(ctx, "INSERT INTO task (method, url, status, response_status_code, response_length) VALUES ($1, $2, $3, $4, $5) RETURNING id")
+	prepare, err := tx.PrepareContext(ctx, `INSERT INTO task (method, url, status, response_status_code, response_length, body, body_encoding, retry_policy, run_at, callback_url, callback_secret, idempotency_key, request_hash, trace_id, span_id, trace_sampled, client, tls_profile, recurring_task_id)
+									VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)
+									ON CONFLICT ((COALESCE(client, '')), idempotency_key) WHERE idempotency_key IS NOT NULL DO NOTHING
+									RETURNING id`)
 	if err != nil {
 		err1 := tx.Rollback()
 		if err1 != nil {
-			return nil, errors.Wrap(err1, "TaskRepository.Create.PrepareContext.Rollback")
+			return nil, false, errors.Wrap(err1, "TaskRepository.Create.PrepareContext.Rollback")
 		}
-		return nil, errors.Wrap(err, "TaskRepository.Create.PrepareContext")
+		return nil, false, errors.Wrap(err, "TaskRepository.Create.PrepareContext")
+	}
+	var callbackUrl, callbackSecret *string
+	if task.Callback != nil {
+		callbackUrl, callbackSecret = &task.Callback.Url, &task.Callback.Secret
 	}
 	var id int64
-	rowContext := prepare.QueryRowContext(ctx, task.Method, task.Url, task.Status, task.ResponseStatus, task.ResponseLength)
+	rowContext := prepare.QueryRowContext(ctx, task.Method, task.Url, task.Status, task.ResponseStatus, task.ResponseLength, task.Body, task.BodyEncoding, task.RetryPolicy, task.RunAt, callbackUrl, callbackSecret, task.IdempotencyKey, task.RequestHash, task.TraceId, task.SpanId, task.TraceSampled, task.Client, task.TlsProfile, task.RecurringTaskId)
 	err = rowContext.Scan(&id)
 	if err != nil {
 		err1 := tx.Rollback()
 		if err1 != nil {
-			return nil, errors.Wrap(err1, "TaskRepository.Create.QueryRowContext.Rollback")
+			return nil, false, errors.Wrap(err1, "TaskRepository.Create.QueryRowContext.Rollback")
+		}
+		if errors.Is(err, sql.ErrNoRows) && task.IdempotencyKey != nil {
+			existing, err := r.getByIdempotencyKey(ctx, task)
+			return existing, err == nil, err
 		}
-		return nil, errors.Wrap(err, "TaskRepository.Create.QueryRowContext")
+		return nil, false, errors.Wrap(err, "TaskRepository.Create.QueryRowContext")
 	}
 
 	err = createHeaders(ctx, tx, id, task.Headers)
 	if err != nil {
 		err1 := tx.Rollback()
 		if err1 != nil {
-			return nil, errors.Wrap(err1, "TaskRepository.Create.createHeaders.Rollback")
+			return nil, false, errors.Wrap(err1, "TaskRepository.Create.createHeaders.Rollback")
 		}
-		return nil, errors.Wrap(err, "TaskRepository.Create.createHeaders")
+		return nil, false, errors.Wrap(err, "TaskRepository.Create.createHeaders")
 	}
 
 	err = tx.Commit()
 	if err != nil {
-		return nil, errors.Wrap(err, "TaskRepository.Create.Commit")
+		return nil, false, errors.Wrap(err, "TaskRepository.Create.Commit")
 	}
 	task.Id = id
 
+	return task, false, nil
+}
+
+// getByIdempotencyKey returns the task created earlier by the same client with the idempotency key
+// of task, provided it was created from the same request.
+func (r *TaskRepository) getByIdempotencyKey(ctx context.Context, task *models.Task) (*models.Task, error) {
+	prepareContext, err := r.db.PrepareContext(ctx, "SELECT id, status, request_hash FROM task WHERE COALESCE(client, '') = COALESCE($1, '') AND idempotency_key = $2")
+	if err != nil {
+		return nil, errors.Wrap(err, "TaskRepository.getByIdempotencyKey.PrepareContext")
+	}
+
+	var id int64
+	var status string
+	var requestHash *string
+	err = prepareContext.QueryRowContext(ctx, task.Client, *task.IdempotencyKey).Scan(&id, &status, &requestHash)
+	if err != nil {
+		return nil, errors.Wrap(err, "TaskRepository.getByIdempotencyKey.QueryRowContext")
+	}
+	if requestHash == nil || task.RequestHash == nil || *requestHash != *task.RequestHash {
+		return nil, errors.Wrapf(models.ErrIdempotencyKeyConflict, "task %d", id)
+	}
+
+	task.Id = id
+	task.Status = status
 	return task, nil
 }
 
+func (r *TaskRepository) CreateBatch(ctx context.Context, tasks []models.Task, track bool) (*models.Batch, error) {
+	ctx, done := observe(ctx, "CreateBatch")
+	defer done()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "TaskRepository.CreateBatch.BeginTx")
+	}
+
+	batch := &models.Batch{}
+	if track {
+		batch.Id, err = createBatch(ctx, tx, len(tasks), tasks[0].Client)
+		if err != nil {
+			err1 := tx.Rollback()
+			if err1 != nil {
+				return nil, errors.Wrap(err1, "TaskRepository.CreateBatch.createBatch.Rollback")
+			}
+			return nil, errors.Wrap(err, "TaskRepository.CreateBatch.createBatch")
+		}
+	}
+
+	batch.TaskIds, err = createTasks(ctx, tx, tasks, batch.Id)
+	if err != nil {
+		err1 := tx.Rollback()
+		if err1 != nil {
+			return nil, errors.Wrap(err1, "TaskRepository.CreateBatch.createTasks.Rollback")
+		}
+		return nil, errors.Wrap(err, "TaskRepository.CreateBatch.createTasks")
+	}
+
+	err = createBatchHeaders(ctx, tx, tasks, batch.TaskIds)
+	if err != nil {
+		err1 := tx.Rollback()
+		if err1 != nil {
+			return nil, errors.Wrap(err1, "TaskRepository.CreateBatch.createBatchHeaders.Rollback")
+		}
+		return nil, errors.Wrap(err, "TaskRepository.CreateBatch.createBatchHeaders")
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, errors.Wrap(err, "TaskRepository.CreateBatch.Commit")
+	}
+	for i := range tasks {
+		tasks[i].Id = batch.TaskIds[i]
+	}
+
+	return batch, nil
+}
+
+func (r *TaskRepository) GetBatchProgress(ctx context.Context, id int64) (*models.BatchProgress, error) {
+	ctx, done := observe(ctx, "GetBatchProgress")
+	defer done()
+
+	prepareContext, err := r.db.PrepareContext(ctx, `SELECT b.id, b.size, b.created_at, b.client, t.status, COUNT(t.id)
+									FROM task_batch b
+									LEFT JOIN task t ON t.batch_id = b.id
+									WHERE b.id = $1
+									GROUP BY b.id, b.size, b.created_at, b.client, t.status`)
+	if err != nil {
+		return nil, errors.Wrap(err, "TaskRepository.GetBatchProgress.PrepareContext")
+	}
+	rows, err := prepareContext.QueryContext(ctx, id)
+	if err != nil {
+		return nil, errors.Wrap(err, "TaskRepository.GetBatchProgress.QueryContext")
+	}
+
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.log.Errorf("TaskRepository.GetBatchProgress.rows.Close(): %v", err)
+		}
+	}(rows)
+
+	var progress *models.BatchProgress
+	for rows.Next() {
+		if progress == nil {
+			progress = &models.BatchProgress{Statuses: make(map[string]int)}
+		}
+		var status *string
+		var count int
+		err = rows.Scan(&progress.Id, &progress.Size, &progress.CreatedAt, &progress.Client, &status, &count)
+		if err != nil {
+			return nil, errors.Wrap(err, "TaskRepository.GetBatchProgress.Scan")
+		}
+		if status != nil {
+			progress.Statuses[*status] = count
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "TaskRepository.GetBatchProgress.rows.Err")
+	}
+	if progress == nil {
+		return nil, sql.ErrNoRows
+	}
+	return progress, nil
+}
+
 func (r *TaskRepository) GetByIdWithOutputHeaders(ctx context.Context, id int64) (*models.Task, error) {
+	ctx, done := observe(ctx, "GetByIdWithOutputHeaders")
+	defer done()
+
 	prepareContext, err := r.db.PrepareContext(ctx, `SELECT t.id,
        								t.url as url,
        								t.method as method,
 									t.status as status,
 									t.response_status_code as response_status,
 									t.response_length as response_length,
+									t.attempts as attempts,
+									t.next_attempt_at as next_attempt_at,
+									t.last_error as last_error,
+									t.run_at as run_at,
+									t.callback_url as callback_url,
+									t.callback_status as callback_status,
+									t.callback_attempts as callback_attempts,
+									t.callback_last_error as callback_last_error,
+									t.trace_id as trace_id,
+									t.client as client,
+									t.tls_profile as tls_profile,
 									COALESCE(h.name, '') as header_name,
 									COALESCE(h.value, '') as header_value
 									FROM task t
@@ -96,14 +273,16 @@ func (r *TaskRepository) GetByIdWithOutputHeaders(ctx context.Context, id int64)
 
 	var task *models.Task
 	tempTask := &models.Task{}
+	var callbackUrl *string
+	callback := &models.Callback{}
 	for rows.Next() {
 		header := models.Header{Input: false}
 		if task == nil {
 			task = &models.Task{}
 			task.Headers = make([]models.Header, 0)
-			err = rows.Scan(&task.Id, &task.Url, &task.Method, &task.Status, &task.ResponseStatus, &task.ResponseLength, &header.Name, &header.Value)
+			err = rows.Scan(&task.Id, &task.Url, &task.Method, &task.Status, &task.ResponseStatus, &task.ResponseLength, &task.Attempts, &task.NextAttemptAt, &task.LastError, &task.RunAt, &callbackUrl, &callback.Status, &callback.Attempts, &callback.LastError, &task.TraceId, &task.Client, &task.TlsProfile, &header.Name, &header.Value)
 		} else {
-			err = rows.Scan(&tempTask.Id, &tempTask.Url, &tempTask.Method, &tempTask.Status, &tempTask.ResponseStatus, &tempTask.ResponseLength, &header.Name, &header.Value)
+			err = rows.Scan(&tempTask.Id, &tempTask.Url, &tempTask.Method, &tempTask.Status, &tempTask.ResponseStatus, &tempTask.ResponseLength, &tempTask.Attempts, &tempTask.NextAttemptAt, &tempTask.LastError, &tempTask.RunAt, &callbackUrl, &callback.Status, &callback.Attempts, &callback.LastError, &tempTask.TraceId, &tempTask.Client, &tempTask.TlsProfile, &header.Name, &header.Value)
 		}
 		if err != nil {
 			return nil, err
@@ -115,17 +294,25 @@ func (r *TaskRepository) GetByIdWithOutputHeaders(ctx context.Context, id int64)
 	if task == nil {
 		return nil, sql.ErrNoRows
 	}
+	if callbackUrl != nil {
+		callback.Url = *callbackUrl
+		task.Callback = callback
+	}
 
 	return task, nil
 }
 
 func (r *TaskRepository) UpdateStatus(ctx context.Context, id int64, newStatus string) error {
-	prepareContext, err := r.db.PrepareContext(ctx, "UPDATE task SET status=$1 WHERE id=$2")
+	ctx, done := observe(ctx, "UpdateStatus")
+	defer done()
+
+	condition, params := statusIn(newStatus, 3, newStatus, id)
+	prepareContext, err := r.db.PrepareContext(ctx, "UPDATE task SET status=$1 WHERE id=$2 AND "+condition)
 	if err != nil {
 		return errors.Wrap(err, "TaskRepository.UpdateStatus.PrepareContext")
 	}
 
-	result, err := prepareContext.ExecContext(ctx, newStatus, id)
+	result, err := prepareContext.ExecContext(ctx, params...)
 	if err != nil {
 		return errors.Wrap(err, "TaskRepository.UpdateStatus.ExecContext")
 	}
@@ -140,13 +327,128 @@ func (r *TaskRepository) UpdateStatus(ctx context.Context, id int64, newStatus s
 	return nil
 }
 
+func (r *TaskRepository) Cancel(ctx context.Context, id int64) (string, error) {
+	ctx, done := observe(ctx, "Cancel")
+	defer done()
+
+	condition, params := statusIn(models.StatusCancelled, 3, models.StatusCancelled, id)
+	prepareContext, err := r.db.PrepareContext(ctx, `UPDATE task t SET status = $1, next_attempt_at = NULL
+									FROM (SELECT id, status FROM task WHERE id = $2 FOR UPDATE) prev
+									WHERE t.id = prev.id AND prev.`+condition+`
+									RETURNING prev.status`)
+	if err != nil {
+		return "", errors.Wrap(err, "TaskRepository.Cancel.PrepareContext")
+	}
+
+	var previousStatus string
+	err = prepareContext.QueryRowContext(ctx, params...).Scan(&previousStatus)
+	if err == nil {
+		return previousStatus, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return "", errors.Wrap(err, "TaskRepository.Cancel.QueryRowContext")
+	}
+
+	statusContext, err := r.db.PrepareContext(ctx, "SELECT status FROM task WHERE id = $1")
+	if err != nil {
+		return "", errors.Wrap(err, "TaskRepository.Cancel.Status.PrepareContext")
+	}
+	var currentStatus string
+	err = statusContext.QueryRowContext(ctx, id).Scan(&currentStatus)
+	if err != nil {
+		return "", errors.Wrap(err, "TaskRepository.Cancel.Status.QueryRowContext")
+	}
+	return "", errors.Wrapf(models.ErrInvalidStatusTransition, "task is %s", currentStatus)
+}
+
+func (r *TaskRepository) ScheduleRetry(ctx context.Context, id int64, nextAttemptAt time.Time, lastError string) error {
+	ctx, done := observe(ctx, "ScheduleRetry")
+	defer done()
+
+	condition, params := statusIn(models.StatusNew, 5, models.StatusNew, nextAttemptAt, lastError, id)
+	prepareContext, err := r.db.PrepareContext(ctx, "UPDATE task SET status = $1, next_attempt_at = $2, last_error = $3 WHERE id = $4 AND "+condition)
+	if err != nil {
+		return errors.Wrap(err, "TaskRepository.ScheduleRetry.PrepareContext")
+	}
+
+	result, err := prepareContext.ExecContext(ctx, params...)
+	if err != nil {
+		return errors.Wrap(err, "TaskRepository.ScheduleRetry.ExecContext")
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "TaskRepository.ScheduleRetry.RowsAffected")
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *TaskRepository) UpdateError(ctx context.Context, id int64, lastError string) error {
+	ctx, done := observe(ctx, "UpdateError")
+	defer done()
+
+	condition, params := statusIn(models.StatusError, 5, models.StatusError, lastError, id, models.CallbackStatusPending)
+	prepareContext, err := r.db.PrepareContext(ctx, "UPDATE task SET status = $1, last_error = $2, "+pendingCallback(4)+" WHERE id = $3 AND "+condition)
+	if err != nil {
+		return errors.Wrap(err, "TaskRepository.UpdateError.PrepareContext")
+	}
+
+	result, err := prepareContext.ExecContext(ctx, params...)
+	if err != nil {
+		return errors.Wrap(err, "TaskRepository.UpdateError.ExecContext")
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "TaskRepository.UpdateError.RowsAffected")
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// Requeue returns an interrupted task to new, the interrupted attempt doesn't count towards the retry policy.
+// The task isn't claimed again before nextAttemptAt when it's set.
+func (r *TaskRepository) Requeue(ctx context.Context, id int64, nextAttemptAt *time.Time) error {
+	ctx, done := observe(ctx, "Requeue")
+	defer done()
+
+	condition, params := statusIn(models.StatusNew, 4, models.StatusNew, nextAttemptAt, id)
+	prepareContext, err := r.db.PrepareContext(ctx, "UPDATE task SET status = $1, attempts = GREATEST(attempts - 1, 0), next_attempt_at = $2 WHERE id = $3 AND "+condition)
+	if err != nil {
+		return errors.Wrap(err, "TaskRepository.Requeue.PrepareContext")
+	}
+
+	result, err := prepareContext.ExecContext(ctx, params...)
+	if err != nil {
+		return errors.Wrap(err, "TaskRepository.Requeue.ExecContext")
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "TaskRepository.Requeue.RowsAffected")
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
 func (r *TaskRepository) UpdateResult(ctx context.Context, task *models.Task) error {
+	ctx, done := observe(ctx, "UpdateResult")
+	defer done()
+
 	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
 	if err != nil {
 		return errors.Wrap(err, "TaskRepository.UpdateResult.BeginTx")
 	}
 
-	prepare, err := tx.PrepareContext(ctx, "UPDATE task SET status = $1, response_status_code = $2, response_length = $3 WHERE id = $4")
+	condition, params := statusIn(task.Status, 6, task.Status, task.ResponseStatus, task.ResponseLength, task.Id, models.CallbackStatusPending)
+	prepare, err := tx.PrepareContext(ctx, "UPDATE task SET status = $1, response_status_code = $2, response_length = $3, "+pendingCallback(5)+" WHERE id = $4 AND "+condition)
 	if err != nil {
 		err1 := tx.Rollback()
 		if err1 != nil {
@@ -154,7 +456,7 @@ func (r *TaskRepository) UpdateResult(ctx context.Context, task *models.Task) er
 		}
 		return errors.Wrap(err, "TaskRepository.UpdateResult.PrepareContext")
 	}
-	res, err := prepare.ExecContext(ctx, task.Status, task.ResponseStatus, task.ResponseLength, task.Id)
+	res, err := prepare.ExecContext(ctx, params...)
 	if err != nil {
 		err1 := tx.Rollback()
 		if err1 != nil {
@@ -166,10 +468,18 @@ func (r *TaskRepository) UpdateResult(ctx context.Context, task *models.Task) er
 	affected, err := res.RowsAffected()
 
 	if err != nil {
+		err1 := tx.Rollback()
+		if err1 != nil {
+			return errors.Wrap(err1, "TaskRepository.UpdateResult.RowsAffected.Rollback")
+		}
 		return errors.Wrap(err, "TaskRepository.UpdateResult.RowsAffected")
 	}
 
 	if affected == 0 {
+		err1 := tx.Rollback()
+		if err1 != nil {
+			return errors.Wrap(err1, "TaskRepository.UpdateResult.Rollback")
+		}
 		return sql.ErrNoRows
 	}
 
@@ -188,6 +498,15 @@ func (r *TaskRepository) UpdateResult(ctx context.Context, task *models.Task) er
 		return errors.Wrap(err, "TaskRepository.UpdateResult.createHeaders")
 	}
 
+	err = createResponse(ctx, tx, task.Response)
+	if err != nil {
+		err1 := tx.Rollback()
+		if err1 != nil {
+			return errors.Wrap(err1, "TaskRepository.UpdateResult.createResponse.Rollback")
+		}
+		return errors.Wrap(err, "TaskRepository.UpdateResult.createResponse")
+	}
+
 	err = tx.Commit()
 	if err != nil {
 		return errors.Wrap(err, "TaskRepository.UpdateResult.Commit")
@@ -196,6 +515,445 @@ func (r *TaskRepository) UpdateResult(ctx context.Context, task *models.Task) er
 	return nil
 }
 
+// GetClient returns the client owning the task, nil for tasks created without authentication.
+func (r *TaskRepository) GetClient(ctx context.Context, id int64) (*string, error) {
+	ctx, done := observe(ctx, "GetClient")
+	defer done()
+
+	prepareContext, err := r.db.PrepareContext(ctx, "SELECT client FROM task WHERE id = $1")
+	if err != nil {
+		return nil, errors.Wrap(err, "TaskRepository.GetClient.PrepareContext")
+	}
+
+	var client *string
+	err = prepareContext.QueryRowContext(ctx, id).Scan(&client)
+	if err != nil {
+		return nil, errors.Wrap(err, "TaskRepository.GetClient.QueryRowContext")
+	}
+	return client, nil
+}
+
+// Heartbeat extends the execution lease of the in-process tasks.
+func (r *TaskRepository) Heartbeat(ctx context.Context, ids []int64) error {
+	ctx, done := observe(ctx, "Heartbeat")
+	defer done()
+
+	if len(ids) == 0 {
+		return nil
+	}
+	placeholders := make([]string, 0, len(ids))
+	params := make([]interface{}, 0, len(ids)+1)
+	params = append(params, models.StatusInProcess)
+	for i, id := range ids {
+		placeholders = append(placeholders, fmt.Sprintf("$%d", i+2))
+		params = append(params, id)
+	}
+	prepareContext, err := r.db.PrepareContext(ctx, "UPDATE task SET heartbeat_at = now() WHERE status = $1 AND id IN ("+strings.Join(placeholders, ", ")+")")
+	if err != nil {
+		return errors.Wrap(err, "TaskRepository.Heartbeat.PrepareContext")
+	}
+
+	_, err = prepareContext.ExecContext(ctx, params...)
+	if err != nil {
+		return errors.Wrap(err, "TaskRepository.Heartbeat.ExecContext")
+	}
+	return nil
+}
+
+// ClaimStuck claims an in-process task whose last heartbeat is older than leaseExpiredAt. The heartbeat
+// is bumped, so other reapers skip the task while it is being handled.
+func (r *TaskRepository) ClaimStuck(ctx context.Context, leaseExpiredAt time.Time) (*models.Task, error) {
+	ctx, done := observe(ctx, "ClaimStuck")
+	defer done()
+
+	prepareContext, err := r.db.PrepareContext(ctx, `UPDATE task SET heartbeat_at = now()
+									WHERE id = (SELECT id FROM task
+												WHERE status = $1 AND heartbeat_at < $2
+												ORDER BY id
+												LIMIT 1
+												FOR UPDATE SKIP LOCKED)
+									RETURNING id, url, method, status, retry_policy, attempts, started_at, client`)
+	if err != nil {
+		return nil, errors.Wrap(err, "TaskRepository.ClaimStuck.PrepareContext")
+	}
+
+	task := &models.Task{}
+	err = prepareContext.QueryRowContext(ctx, models.StatusInProcess, leaseExpiredAt).Scan(&task.Id, &task.Url, &task.Method, &task.Status, &task.RetryPolicy, &task.Attempts, &task.StartedAt, &task.Client)
+	if err != nil {
+		return nil, errors.Wrap(err, "TaskRepository.ClaimStuck.QueryRowContext")
+	}
+	return task, nil
+}
+
+// CountBacklog counts the tasks that are due and waiting for a worker.
+func (r *TaskRepository) CountBacklog(ctx context.Context) (int64, error) {
+	ctx, done := observe(ctx, "CountBacklog")
+	defer done()
+
+	condition, params := statusIn(models.StatusInProcess, 1)
+	prepareContext, err := r.db.PrepareContext(ctx, `SELECT count(*) FROM task
+									WHERE `+condition+`
+									AND (next_attempt_at IS NULL OR next_attempt_at <= now())
+									AND (run_at IS NULL OR run_at <= now())`)
+	if err != nil {
+		return 0, errors.Wrap(err, "TaskRepository.CountBacklog.PrepareContext")
+	}
+
+	var count int64
+	err = prepareContext.QueryRowContext(ctx, params...).Scan(&count)
+	if err != nil {
+		return 0, errors.Wrap(err, "TaskRepository.CountBacklog.QueryRowContext")
+	}
+	return count, nil
+}
+
+func (r *TaskRepository) ClaimNew(ctx context.Context) (*models.Task, error) {
+	ctx, done := observe(ctx, "ClaimNew")
+	defer done()
+
+	condition, params := statusIn(models.StatusInProcess, 2, models.StatusInProcess)
+	prepareContext, err := r.db.PrepareContext(ctx, `UPDATE task SET status = $1, attempts = attempts + 1, next_attempt_at = NULL, started_at = now(), heartbeat_at = now()
+									WHERE id = (SELECT id FROM task
+												WHERE `+condition+`
+												AND (next_attempt_at IS NULL OR next_attempt_at <= now())
+												AND (run_at IS NULL OR run_at <= now())
+												ORDER BY id
+												LIMIT 1
+												FOR UPDATE SKIP LOCKED)
+									RETURNING id, url, method, status, body, body_encoding, retry_policy, attempts, trace_id, span_id, trace_sampled, tls_profile, client`)
+	if err != nil {
+		return nil, errors.Wrap(err, "TaskRepository.ClaimNew.PrepareContext")
+	}
+
+	task := &models.Task{}
+	err = prepareContext.QueryRowContext(ctx, params...).Scan(&task.Id, &task.Url, &task.Method, &task.Status, &task.Body, &task.BodyEncoding, &task.RetryPolicy, &task.Attempts, &task.TraceId, &task.SpanId, &task.TraceSampled, &task.TlsProfile, &task.Client)
+	if err != nil {
+		return nil, errors.Wrap(err, "TaskRepository.ClaimNew.QueryRowContext")
+	}
+
+	task.Headers, err = r.getInputHeaders(ctx, task.Id)
+	if err != nil {
+		return nil, errors.Wrap(err, "TaskRepository.ClaimNew.getInputHeaders")
+	}
+
+	return task, nil
+}
+
+func (r *TaskRepository) ClaimCallback(ctx context.Context, leaseUntil time.Time) (*models.Task, error) {
+	ctx, done := observe(ctx, "ClaimCallback")
+	defer done()
+
+	prepareContext, err := r.db.PrepareContext(ctx, `UPDATE task SET callback_attempts = callback_attempts + 1, callback_next_attempt_at = $1
+									WHERE id = (SELECT id FROM task
+												WHERE callback_status = $2 AND (callback_next_attempt_at IS NULL OR callback_next_attempt_at <= now())
+												ORDER BY id
+												LIMIT 1
+												FOR UPDATE SKIP LOCKED)
+									RETURNING id, callback_secret`)
+	if err != nil {
+		return nil, errors.Wrap(err, "TaskRepository.ClaimCallback.PrepareContext")
+	}
+
+	var id int64
+	var secret *string
+	err = prepareContext.QueryRowContext(ctx, leaseUntil, models.CallbackStatusPending).Scan(&id, &secret)
+	if err != nil {
+		return nil, errors.Wrap(err, "TaskRepository.ClaimCallback.QueryRowContext")
+	}
+
+	task, err := r.GetByIdWithOutputHeaders(ctx, id)
+	if err != nil {
+		return nil, errors.Wrap(err, "TaskRepository.ClaimCallback.GetByIdWithOutputHeaders")
+	}
+	if task.Callback == nil {
+		return nil, errors.Errorf("TaskRepository.ClaimCallback: task %d has no callback", id)
+	}
+	if secret != nil {
+		task.Callback.Secret = *secret
+	}
+	return task, nil
+}
+
+func (r *TaskRepository) UpdateCallbackStatus(ctx context.Context, id int64, status string, nextAttemptAt *time.Time, lastError *string) error {
+	ctx, done := observe(ctx, "UpdateCallbackStatus")
+	defer done()
+
+	prepareContext, err := r.db.PrepareContext(ctx, "UPDATE task SET callback_status = $1, callback_next_attempt_at = $2, callback_last_error = $3 WHERE id = $4")
+	if err != nil {
+		return errors.Wrap(err, "TaskRepository.UpdateCallbackStatus.PrepareContext")
+	}
+
+	result, err := prepareContext.ExecContext(ctx, status, nextAttemptAt, lastError, id)
+	if err != nil {
+		return errors.Wrap(err, "TaskRepository.UpdateCallbackStatus.ExecContext")
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "TaskRepository.UpdateCallbackStatus.RowsAffected")
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *TaskRepository) NotifyEvent(ctx context.Context, event models.TaskEvent) error {
+	ctx, done := observe(ctx, "NotifyEvent")
+	defer done()
+
+	if event.LastError != nil && len(*event.LastError) > maxNotifiedErrorLen {
+		lastError := (*event.LastError)[:maxNotifiedErrorLen]
+		event.LastError = &lastError
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrap(err, "TaskRepository.NotifyEvent.Marshal")
+	}
+
+	prepareContext, err := r.db.PrepareContext(ctx, "SELECT pg_notify($1, $2)")
+	if err != nil {
+		return errors.Wrap(err, "TaskRepository.NotifyEvent.PrepareContext")
+	}
+	_, err = prepareContext.ExecContext(ctx, models.TaskEventsChannel, string(payload))
+	if err != nil {
+		return errors.Wrap(err, "TaskRepository.NotifyEvent.ExecContext")
+	}
+	return nil
+}
+
+func (r *TaskRepository) GetResponse(ctx context.Context, id int64) (*models.TaskResponse, error) {
+	ctx, done := observe(ctx, "GetResponse")
+	defer done()
+
+	prepareContext, err := r.db.PrepareContext(ctx, "SELECT task_id, content_type, body, truncated FROM task_response WHERE task_id = $1")
+	if err != nil {
+		return nil, errors.Wrap(err, "TaskRepository.GetResponse.PrepareContext")
+	}
+
+	response := &models.TaskResponse{}
+	err = prepareContext.QueryRowContext(ctx, id).Scan(&response.TaskId, &response.ContentType, &response.Body, &response.Truncated)
+	if err != nil {
+		return nil, errors.Wrap(err, "TaskRepository.GetResponse.QueryRowContext")
+	}
+
+	return response, nil
+}
+
+func (r *TaskRepository) CreateAttempt(ctx context.Context, attempt *models.TaskAttempt) error {
+	ctx, done := observe(ctx, "CreateAttempt")
+	defer done()
+
+	prepareContext, err := r.db.PrepareContext(ctx, `INSERT INTO task_attempt (task_id, attempt, started_at, finished_at, duration_ms, response_status_code, response_length, error)
+									VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id`)
+	if err != nil {
+		return errors.Wrap(err, "TaskRepository.CreateAttempt.PrepareContext")
+	}
+
+	err = prepareContext.QueryRowContext(ctx, attempt.TaskId, attempt.Attempt, attempt.StartedAt, attempt.FinishedAt, attempt.DurationMs,
+		attempt.ResponseStatus, attempt.ResponseLength, attempt.Error).Scan(&attempt.Id)
+	if err != nil {
+		return errors.Wrap(err, "TaskRepository.CreateAttempt.QueryRowContext")
+	}
+	return nil
+}
+
+func (r *TaskRepository) GetAttempts(ctx context.Context, id int64) ([]models.TaskAttempt, error) {
+	ctx, done := observe(ctx, "GetAttempts")
+	defer done()
+
+	existsContext, err := r.db.PrepareContext(ctx, "SELECT EXISTS(SELECT 1 FROM task WHERE id = $1)")
+	if err != nil {
+		return nil, errors.Wrap(err, "TaskRepository.GetAttempts.Exists.PrepareContext")
+	}
+	var exists bool
+	err = existsContext.QueryRowContext(ctx, id).Scan(&exists)
+	if err != nil {
+		return nil, errors.Wrap(err, "TaskRepository.GetAttempts.Exists.QueryRowContext")
+	}
+	if !exists {
+		return nil, sql.ErrNoRows
+	}
+
+	prepareContext, err := r.db.PrepareContext(ctx, `SELECT id, task_id, attempt, started_at, finished_at, duration_ms, response_status_code, response_length, error
+									FROM task_attempt
+									WHERE task_id = $1
+									ORDER BY attempt, id`)
+	if err != nil {
+		return nil, errors.Wrap(err, "TaskRepository.GetAttempts.PrepareContext")
+	}
+	rows, err := prepareContext.QueryContext(ctx, id)
+	if err != nil {
+		return nil, errors.Wrap(err, "TaskRepository.GetAttempts.QueryContext")
+	}
+
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.log.Errorf("TaskRepository.GetAttempts.rows.Close(): %v", err)
+		}
+	}(rows)
+
+	attempts := make([]models.TaskAttempt, 0)
+	for rows.Next() {
+		attempt := models.TaskAttempt{}
+		err = rows.Scan(&attempt.Id, &attempt.TaskId, &attempt.Attempt, &attempt.StartedAt, &attempt.FinishedAt, &attempt.DurationMs,
+			&attempt.ResponseStatus, &attempt.ResponseLength, &attempt.Error)
+		if err != nil {
+			return nil, errors.Wrap(err, "TaskRepository.GetAttempts.Scan")
+		}
+		attempts = append(attempts, attempt)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "TaskRepository.GetAttempts.rows.Err")
+	}
+	return attempts, nil
+}
+
+func (r *TaskRepository) List(ctx context.Context, filter models.TaskFilter) ([]models.Task, error) {
+	ctx, done := observe(ctx, "List")
+	defer done()
+
+	query, params := buildListQuery(filter)
+
+	prepareContext, err := r.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, errors.Wrap(err, "TaskRepository.List.PrepareContext")
+	}
+	rows, err := prepareContext.QueryContext(ctx, params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "TaskRepository.List.QueryContext")
+	}
+
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.log.Errorf("TaskRepository.List.rows.Close(): %v", err)
+		}
+	}(rows)
+
+	result := make([]models.Task, 0)
+	for rows.Next() {
+		task := models.Task{}
+		err = rows.Scan(&task.Id, &task.Url, &task.Method, &task.Status, &task.ResponseStatus, &task.ResponseLength,
+			&task.Attempts, &task.NextAttemptAt, &task.LastError, &task.RunAt, &task.CreatedAt)
+		if err != nil {
+			return nil, errors.Wrap(err, "TaskRepository.List.Scan")
+		}
+		result = append(result, task)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "TaskRepository.List.rows.Err")
+	}
+	return result, nil
+}
+
+func buildListQuery(filter models.TaskFilter) (string, []interface{}) {
+	sb := new(strings.Builder)
+	sb.WriteString("SELECT id, url, method, status, response_status_code, response_length, attempts, next_attempt_at, last_error, run_at, created_at FROM task WHERE TRUE")
+	params := make([]interface{}, 0)
+	param := func(value interface{}) string {
+		params = append(params, value)
+		return fmt.Sprintf("$%d", len(params))
+	}
+
+	if filter.Client != nil {
+		fmt.Fprintf(sb, " AND client = %s", param(*filter.Client))
+	}
+	if len(filter.Statuses) > 0 {
+		placeholders := make([]string, 0, len(filter.Statuses))
+		for _, status := range filter.Statuses {
+			placeholders = append(placeholders, param(status))
+		}
+		fmt.Fprintf(sb, " AND status IN (%s)", strings.Join(placeholders, ", "))
+	}
+	if filter.Method != "" {
+		fmt.Fprintf(sb, " AND upper(method) = %s", param(strings.ToUpper(filter.Method)))
+	}
+	if filter.Host != "" {
+		fmt.Fprintf(sb, " AND lower(substring(url from '^[^:]+://(?:[^@/]*@)?([^/:?#]+)')) = %s", param(strings.ToLower(filter.Host)))
+	}
+	if filter.UrlPrefix != "" {
+		fmt.Fprintf(sb, " AND starts_with(url, %s)", param(filter.UrlPrefix))
+	}
+	if filter.MinResponseStatus != nil {
+		fmt.Fprintf(sb, " AND response_status_code >= %s", param(*filter.MinResponseStatus))
+	}
+	if filter.MaxResponseStatus != nil {
+		fmt.Fprintf(sb, " AND response_status_code <= %s", param(*filter.MaxResponseStatus))
+	}
+	if filter.CreatedFrom != nil {
+		fmt.Fprintf(sb, " AND created_at >= %s", param(*filter.CreatedFrom))
+	}
+	if filter.CreatedTo != nil {
+		fmt.Fprintf(sb, " AND created_at < %s", param(*filter.CreatedTo))
+	}
+
+	comparison, order := ">", "ASC"
+	if filter.SortDesc {
+		comparison, order = "<", "DESC"
+	}
+	if filter.SortBy == models.SortByCreatedAt {
+		if filter.After != nil {
+			fmt.Fprintf(sb, " AND (created_at, id) %s (%s, %s)", comparison, param(filter.After.CreatedAt), param(filter.After.Id))
+		}
+		fmt.Fprintf(sb, " ORDER BY created_at %s, id %s", order, order)
+	} else {
+		if filter.After != nil {
+			fmt.Fprintf(sb, " AND id %s %s", comparison, param(filter.After.Id))
+		}
+		fmt.Fprintf(sb, " ORDER BY id %s", order)
+	}
+	fmt.Fprintf(sb, " LIMIT %s", param(filter.Limit))
+
+	return sb.String(), params
+}
+
+func (r *TaskRepository) getInputHeaders(ctx context.Context, taskId int64) ([]models.Header, error) {
+	prepareContext, err := r.db.PrepareContext(ctx, "SELECT name, value FROM headers WHERE task_id = $1 AND input = true")
+	if err != nil {
+		return nil, err
+	}
+	rows, err := prepareContext.QueryContext(ctx, taskId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.log.Errorf("TaskRepository.getInputHeaders.rows.Close(): %v", err)
+		}
+	}(rows)
+
+	headers := make([]models.Header, 0)
+	for rows.Next() {
+		header := models.Header{Input: true}
+		if err := rows.Scan(&header.Name, &header.Value); err != nil {
+			return nil, err
+		}
+		headers = append(headers, header)
+	}
+	return headers, rows.Err()
+}
+
+// pendingCallback renders the assignment that queues the webhook of tasks which have one.
+func pendingCallback(param int) string {
+	return fmt.Sprintf("callback_status = CASE WHEN callback_url IS NULL THEN NULL ELSE $%d END", param)
+}
+
+// statusIn renders a "status IN (...)" condition over the statuses a task may move
+// to newStatus from, numbering placeholders from firstParam, and appends them to params.
+func statusIn(newStatus string, firstParam int, params ...interface{}) (string, []interface{}) {
+	allowed := models.AllowedPreviousStatuses(newStatus)
+	placeholders := make([]string, 0, len(allowed))
+	for i, status := range allowed {
+		placeholders = append(placeholders, fmt.Sprintf("$%d", firstParam+i))
+		params = append(params, status)
+	}
+	return fmt.Sprintf("status IN (%s)", strings.Join(placeholders, ", ")), params
+}
+
 func createHeaders(ctx context.Context, tx *sql.Tx, taskId int64, headers []models.Header) error {
 	if len(headers) == 0 {
 		return nil
@@ -225,3 +983,139 @@ func createHeaders(ctx context.Context, tx *sql.Tx, taskId int64, headers []mode
 	}
 	return nil
 }
+
+func createBatch(ctx context.Context, tx *sql.Tx, size int, client *string) (*int64, error) {
+	prepare, err := tx.PrepareContext(ctx, "INSERT INTO task_batch (size, client) VALUES ($1, $2) RETURNING id")
+	if err != nil {
+		return nil, err
+	}
+	var id int64
+	err = prepare.QueryRowContext(ctx, size, client).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+// createTasks inserts the tasks, tasksPerStatement rows at a time, and returns their ids in the order of tasks.
+func createTasks(ctx context.Context, tx *sql.Tx, tasks []models.Task, batchId *int64) ([]int64, error) {
+	ids := make([]int64, 0, len(tasks))
+	for chunk := range slices.Chunk(tasks, tasksPerStatement) {
+		chunkIds, err := insertTasks(ctx, tx, chunk, batchId)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, chunkIds...)
+	}
+	return ids, nil
+}
+
+// insertTasks inserts the tasks with a single statement and returns their ids in the order of tasks.
+// The ids are drawn up front and returned next to the position of every row, as RETURNING promises
+// neither the order of the rows nor the order the sequence is drawn in.
+func insertTasks(ctx context.Context, tx *sql.Tx, tasks []models.Task, batchId *int64) ([]int64, error) {
+	sb := new(strings.Builder)
+	sb.WriteString("WITH input (ord, method, url, status, body, body_encoding, retry_policy, run_at, callback_url, callback_secret, batch_id, trace_id, span_id, trace_sampled, client, tls_profile) AS (VALUES ")
+	params := make([]interface{}, 0, len(tasks)*15)
+	for i, task := range tasks {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		var callbackUrl, callbackSecret *string
+		if task.Callback != nil {
+			callbackUrl, callbackSecret = &task.Callback.Url, &task.Callback.Secret
+		}
+		n := len(params)
+		params = append(params, task.Method, task.Url, task.Status, task.Body, task.BodyEncoding, task.RetryPolicy, task.RunAt, callbackUrl, callbackSecret, batchId, task.TraceId, task.SpanId, task.TraceSampled, task.Client, task.TlsProfile)
+		fmt.Fprintf(sb, "(%d, $%d, $%d, $%d, $%d, $%d, $%d::jsonb, $%d::timestamptz, $%d, $%d, $%d::bigint, $%d, $%d, $%d::boolean, $%d, $%d)", i, n+1, n+2, n+3, n+4, n+5, n+6, n+7, n+8, n+9, n+10, n+11, n+12, n+13, n+14, n+15)
+	}
+	sb.WriteString("), numbered AS (SELECT nextval(pg_get_serial_sequence('task', 'id')) AS id, * FROM input), " +
+		"inserted AS (INSERT INTO task (id, method, url, status, body, body_encoding, retry_policy, run_at, callback_url, callback_secret, batch_id, trace_id, span_id, trace_sampled, client, tls_profile) " +
+		"SELECT id, method, url, status, body, body_encoding, retry_policy, run_at, callback_url, callback_secret, batch_id, trace_id, span_id, trace_sampled, client, tls_profile FROM numbered RETURNING id) " +
+		"SELECT numbered.ord, inserted.id FROM inserted JOIN numbered USING (id)")
+
+	prepare, err := tx.PrepareContext(ctx, sb.String())
+	if err != nil {
+		return nil, err
+	}
+	rows, err := prepare.QueryContext(ctx, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make([]int64, len(tasks))
+	inserted := 0
+	for rows.Next() {
+		var ord int
+		var id int64
+		if err := rows.Scan(&ord, &id); err != nil {
+			return nil, err
+		}
+		if ord < 0 || ord >= len(ids) {
+			return nil, fmt.Errorf("inserted task at unknown position %d", ord)
+		}
+		ids[ord] = id
+		inserted++
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if inserted != len(tasks) {
+		return nil, fmt.Errorf("inserted %d tasks of %d", inserted, len(tasks))
+	}
+	return ids, nil
+}
+
+// createBatchHeaders inserts the input headers of all tasks, headersPerStatement rows at a time.
+func createBatchHeaders(ctx context.Context, tx *sql.Tx, tasks []models.Task, ids []int64) error {
+	params := make([]interface{}, 0)
+	flush := func() error {
+		if len(params) == 0 {
+			return nil
+		}
+		sb := new(strings.Builder)
+		sb.WriteString("INSERT INTO headers(name, value, input, task_id) VALUES ")
+		for n := 0; n < len(params); n += 4 {
+			if n > 0 {
+				sb.WriteString(", ")
+			}
+			fmt.Fprintf(sb, "($%d, $%d, $%d, $%d)", n+1, n+2, n+3, n+4)
+		}
+		prepare, err := tx.PrepareContext(ctx, sb.String())
+		if err != nil {
+			return err
+		}
+		_, err = prepare.ExecContext(ctx, params...)
+		params = params[:0]
+		return err
+	}
+
+	for i, task := range tasks {
+		for _, header := range task.Headers {
+			params = append(params, header.Name, header.Value, header.Input, ids[i])
+			if len(params) == headersPerStatement*4 {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return flush()
+}
+
+func createResponse(ctx context.Context, tx *sql.Tx, response *models.TaskResponse) error {
+	if response == nil {
+		return nil
+	}
+	prepare, err := tx.PrepareContext(ctx, `INSERT INTO task_response (task_id, content_type, body, truncated) VALUES ($1, $2, $3, $4)
+									ON CONFLICT (task_id) DO UPDATE SET content_type = EXCLUDED.content_type, body = EXCLUDED.body, truncated = EXCLUDED.truncated`)
+	if err != nil {
+		return err
+	}
+	_, err = prepare.ExecContext(ctx, response.TaskId, response.ContentType, response.Body, response.Truncated)
+	if err != nil {
+		return err
+	}
+	return nil
+}
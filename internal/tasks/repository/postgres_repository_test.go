@@ -4,6 +4,7 @@ import (
 	"context"
 	dbSql "database/sql"
 	"errors"
+	"fmt"
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/jmoiron/sqlx"
 	"github.com/stretchr/testify/assert"
@@ -11,9 +12,16 @@ import (
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"http-task-executor/internal/models"
+	"strings"
 	"testing"
+	"time"
 )
 
+const createSql = `INSERT INTO task (method, url, status, response_status_code, response_length, body, body_encoding, retry_policy, run_at, callback_url, callback_secret, idempotency_key, request_hash, trace_id, span_id, trace_sampled, client, tls_profile, recurring_task_id)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)
+			ON CONFLICT ((COALESCE(client, '')), idempotency_key) WHERE idempotency_key IS NOT NULL DO NOTHING
+			RETURNING id`
+
 func TestTasksRepo_CreateWithoutHeaders(t *testing.T) {
 	t.Parallel()
 
@@ -33,13 +41,13 @@ func TestTasksRepo_CreateWithoutHeaders(t *testing.T) {
 			Status: models.StatusNew,
 		}
 
-		sql := "INSERT INTO task (method, url, status, response_status_code, response_length) VALUES ($1, $2, $3, $4, $5) RETURNING id"
+		sql := createSql
 		mock.ExpectBegin()
 		mock.ExpectPrepare(sql)
-		mock.ExpectQuery(sql).WithArgs(task.Method, task.Url, task.Status, task.ResponseStatus, task.ResponseLength).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectQuery(sql).WithArgs(task.Method, task.Url, task.Status, task.ResponseStatus, task.ResponseLength, task.Body, task.BodyEncoding, task.RetryPolicy, task.RunAt, nil, nil, nil, nil, nil, nil, false, nil, nil, nil).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 		mock.ExpectCommit()
 
-		created, err := tasksRepo.Create(context.Background(), task)
+		created, _, err := tasksRepo.Create(context.Background(), task)
 
 		require.NoError(t, err)
 		require.NotEmpty(t, created)
@@ -48,6 +56,25 @@ func TestTasksRepo_CreateWithoutHeaders(t *testing.T) {
 		assert.Equal(t, task.Url, created.Url)
 		assert.Equal(t, task.Status, created.Status)
 	})
+
+	t.Run("Two tasks without idempotency key for the same client", func(t *testing.T) {
+		client := "acme"
+		for id := int64(2); id <= 3; id++ {
+			task := &models.Task{Method: "GET", Url: "https://www.google.com", Status: models.StatusNew, Client: &client}
+
+			mock.ExpectBegin()
+			mock.ExpectPrepare(createSql)
+			mock.ExpectQuery(createSql).WithArgs(task.Method, task.Url, task.Status, task.ResponseStatus, task.ResponseLength, task.Body, task.BodyEncoding, task.RetryPolicy, task.RunAt, nil, nil, nil, nil, nil, nil, false, &client, nil, nil).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(id))
+			mock.ExpectCommit()
+
+			created, replayed, err := tasksRepo.Create(context.Background(), task)
+
+			require.NoError(t, err)
+			require.False(t, replayed)
+			assert.Equal(t, id, created.Id)
+		}
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
 }
 
 func TestTasksRepo_CreateWithHeaders(t *testing.T) {
@@ -79,16 +106,16 @@ func TestTasksRepo_CreateWithHeaders(t *testing.T) {
 			Headers: headers,
 		}
 
-		sql := "INSERT INTO task (method, url, status, response_status_code, response_length) VALUES ($1, $2, $3, $4, $5) RETURNING id"
+		sql := createSql
 		headersSql := "INSERT INTO headers(name, value, input, task_id) VALUES ($1, $2, $3, 1) "
 		mock.ExpectBegin()
 		mock.ExpectPrepare(sql)
-		mock.ExpectQuery(sql).WithArgs(task.Method, task.Url, task.Status, task.ResponseStatus, task.ResponseLength).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectQuery(sql).WithArgs(task.Method, task.Url, task.Status, task.ResponseStatus, task.ResponseLength, task.Body, task.BodyEncoding, task.RetryPolicy, task.RunAt, nil, nil, nil, nil, nil, nil, false, nil, nil, nil).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 		mock.ExpectPrepare(headersSql)
 		mock.ExpectExec(headersSql).WithArgs(header.Name, header.Value, header.Input).WillReturnResult(sqlmock.NewResult(1, 1))
 		mock.ExpectCommit()
 
-		created, err := tasksRepo.Create(context.Background(), task)
+		created, _, err := tasksRepo.Create(context.Background(), task)
 
 		require.NoError(t, err)
 		require.NotEmpty(t, created)
@@ -106,16 +133,16 @@ func TestTasksRepo_CreateWithHeaders(t *testing.T) {
 			Headers: twoHeaders,
 		}
 
-		sql := "INSERT INTO task (method, url, status, response_status_code, response_length) VALUES ($1, $2, $3, $4, $5) RETURNING id"
+		sql := createSql
 		headersSql := "INSERT INTO headers(name, value, input, task_id) VALUES ($1, $2, $3, 1) ,($4, $5, $6, 1) "
 		mock.ExpectBegin()
 		mock.ExpectPrepare(sql)
-		mock.ExpectQuery(sql).WithArgs(task.Method, task.Url, task.Status, task.ResponseStatus, task.ResponseLength).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectQuery(sql).WithArgs(task.Method, task.Url, task.Status, task.ResponseStatus, task.ResponseLength, task.Body, task.BodyEncoding, task.RetryPolicy, task.RunAt, nil, nil, nil, nil, nil, nil, false, nil, nil, nil).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 		mock.ExpectPrepare(headersSql)
 		mock.ExpectExec(headersSql).WithArgs(header.Name, header.Value, header.Input, secondHeader.Name, secondHeader.Value, secondHeader.Input).WillReturnResult(sqlmock.NewResult(1, 2))
 		mock.ExpectCommit()
 
-		created, err := tasksRepo.Create(context.Background(), task)
+		created, _, err := tasksRepo.Create(context.Background(), task)
 
 		require.NoError(t, err)
 		require.NotEmpty(t, created)
@@ -133,22 +160,76 @@ func TestTasksRepo_CreateWithHeaders(t *testing.T) {
 			Headers: twoHeaders,
 		}
 
-		sql := "INSERT INTO task (method, url, status, response_status_code, response_length) VALUES ($1, $2, $3, $4, $5) RETURNING id"
+		sql := createSql
 		headersSql := "INSERT INTO headers(name, value, input, task_id) VALUES ($1, $2, $3, 1) ,($4, $5, $6, 1) "
 		mock.ExpectBegin()
 		mock.ExpectPrepare(sql)
-		mock.ExpectQuery(sql).WithArgs(task.Method, task.Url, task.Status, task.ResponseStatus, task.ResponseLength).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectQuery(sql).WithArgs(task.Method, task.Url, task.Status, task.ResponseStatus, task.ResponseLength, task.Body, task.BodyEncoding, task.RetryPolicy, task.RunAt, nil, nil, nil, nil, nil, nil, false, nil, nil, nil).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 		mock.ExpectPrepare(headersSql)
 		mock.ExpectExec(headersSql).WithArgs(header.Name, header.Value, header.Input, secondHeader.Name, secondHeader.Value, secondHeader.Input).WillReturnError(errors.New("error"))
 		mock.ExpectRollback()
 
-		created, err := tasksRepo.Create(context.Background(), task)
+		created, _, err := tasksRepo.Create(context.Background(), task)
 
 		require.Error(t, err)
 		require.Empty(t, created)
 	})
 }
 
+func TestTasksRepo_CreateWithIdempotencyKey(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+
+	sqlxDb := sqlx.NewDb(db, "sqlmock")
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	tasksRepo := NewRepository(sqlxDb, sugar)
+
+	key := "key"
+	hash := "hash"
+	existingSql := "SELECT id, status, request_hash FROM task WHERE COALESCE(client, '') = COALESCE($1, '') AND idempotency_key = $2"
+
+	t.Run("Repeated key returns original task", func(t *testing.T) {
+		client := "acme"
+		task := &models.Task{Method: "GET", Url: "https://www.google.com", Status: models.StatusNew, IdempotencyKey: &key, RequestHash: &hash, Client: &client}
+
+		mock.ExpectBegin()
+		mock.ExpectPrepare(createSql)
+		mock.ExpectQuery(createSql).WithArgs(task.Method, task.Url, task.Status, nil, nil, "", "", nil, nil, nil, nil, key, hash, nil, nil, false, &client, nil, nil).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectRollback()
+		mock.ExpectPrepare(existingSql)
+		mock.ExpectQuery(existingSql).WithArgs(&client, key).WillReturnRows(sqlmock.NewRows([]string{"id", "status", "request_hash"}).AddRow(5, models.StatusDone, hash))
+
+		created, replayed, err := tasksRepo.Create(context.Background(), task)
+
+		require.NoError(t, err)
+		assert.True(t, replayed)
+		assert.Equal(t, int64(5), created.Id)
+		assert.Equal(t, models.StatusDone, created.Status)
+	})
+
+	t.Run("Repeated key with different payload", func(t *testing.T) {
+		otherHash := "other"
+		task := &models.Task{Method: "GET", Url: "https://www.google.com", Status: models.StatusNew, IdempotencyKey: &key, RequestHash: &otherHash}
+
+		mock.ExpectBegin()
+		mock.ExpectPrepare(createSql)
+		mock.ExpectQuery(createSql).WithArgs(task.Method, task.Url, task.Status, nil, nil, "", "", nil, nil, nil, nil, key, otherHash, nil, nil, false, nil, nil, nil).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectRollback()
+		mock.ExpectPrepare(existingSql)
+		mock.ExpectQuery(existingSql).WithArgs(nil, key).WillReturnRows(sqlmock.NewRows([]string{"id", "status", "request_hash"}).AddRow(5, models.StatusDone, hash))
+
+		_, _, err := tasksRepo.Create(context.Background(), task)
+
+		require.ErrorIs(t, err, models.ErrIdempotencyKeyConflict)
+	})
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestTasksRepo_GetByIdWithOutputHeaders(t *testing.T) {
 	t.Parallel()
 
@@ -167,6 +248,17 @@ func TestTasksRepo_GetByIdWithOutputHeaders(t *testing.T) {
 									t.status as status,
 									t.response_status_code as response_status,
 									t.response_length as response_length,
+									t.attempts as attempts,
+									t.next_attempt_at as next_attempt_at,
+									t.last_error as last_error,
+									t.run_at as run_at,
+									t.callback_url as callback_url,
+									t.callback_status as callback_status,
+									t.callback_attempts as callback_attempts,
+									t.callback_last_error as callback_last_error,
+									t.trace_id as trace_id,
+									t.client as client,
+									t.tls_profile as tls_profile,
 									COALESCE(h.name, '') as header_name,
 									COALESCE(h.value, '') as header_value
 									FROM task t
@@ -183,7 +275,7 @@ func TestTasksRepo_GetByIdWithOutputHeaders(t *testing.T) {
 		headerName := "TEST_NAME"
 		headerValue := "TEST_VALUE"
 
-		rows := sqlmock.NewRows([]string{"id", "url", "method", "status", "response_status_code", "response_length", "header_name", "header_value"}).AddRow(id, url, method, status, responseStatusCode, responseLength, headerName, headerValue)
+		rows := sqlmock.NewRows([]string{"id", "url", "method", "status", "response_status_code", "response_length", "attempts", "next_attempt_at", "last_error", "run_at", "callback_url", "callback_status", "callback_attempts", "callback_last_error", "trace_id", "client", "tls_profile", "header_name", "header_value"}).AddRow(id, url, method, status, responseStatusCode, responseLength, 1, nil, nil, nil, nil, nil, 0, nil, nil, nil, nil, headerName, headerValue)
 
 		mock.ExpectPrepare(sql)
 		mock.ExpectQuery(sql).WithArgs(id).WillReturnRows(rows)
@@ -213,7 +305,7 @@ func TestTasksRepo_GetByIdWithOutputHeaders(t *testing.T) {
 		responseStatusCode := int64(200)
 		responseLength := int64(10)
 
-		rows := sqlmock.NewRows([]string{"id", "url", "method", "status", "response_status_code", "response_length", "header_name", "header_value"}).AddRow(id, url, method, status, responseStatusCode, responseLength, "", "")
+		rows := sqlmock.NewRows([]string{"id", "url", "method", "status", "response_status_code", "response_length", "attempts", "next_attempt_at", "last_error", "run_at", "callback_url", "callback_status", "callback_attempts", "callback_last_error", "trace_id", "client", "tls_profile", "header_name", "header_value"}).AddRow(id, url, method, status, responseStatusCode, responseLength, 1, nil, nil, nil, nil, nil, 0, nil, nil, nil, nil, "", "")
 
 		mock.ExpectPrepare(sql)
 		mock.ExpectQuery(sql).WithArgs(id).WillReturnRows(rows)
@@ -245,9 +337,9 @@ func TestTasksRepo_GetByIdWithOutputHeaders(t *testing.T) {
 		headerName2 := "TEST_NAME2"
 		headerValue2 := "TEST_VALUE2"
 
-		rows := sqlmock.NewRows([]string{"id", "url", "method", "status", "response_status_code", "response_length", "header_name", "header_value"}).
-			AddRow(id, url, method, status, responseStatusCode, responseLength, headerName, headerValue).
-			AddRow(id, url, method, status, responseStatusCode, responseLength, headerName2, headerValue2)
+		rows := sqlmock.NewRows([]string{"id", "url", "method", "status", "response_status_code", "response_length", "attempts", "next_attempt_at", "last_error", "run_at", "callback_url", "callback_status", "callback_attempts", "callback_last_error", "trace_id", "client", "tls_profile", "header_name", "header_value"}).
+			AddRow(id, url, method, status, responseStatusCode, responseLength, 1, nil, nil, nil, nil, nil, 0, nil, nil, nil, nil, headerName, headerValue).
+			AddRow(id, url, method, status, responseStatusCode, responseLength, 1, nil, nil, nil, nil, nil, 0, nil, nil, nil, nil, headerName2, headerValue2)
 
 		mock.ExpectPrepare(sql)
 		mock.ExpectQuery(sql).WithArgs(id).WillReturnRows(rows)
@@ -271,7 +363,7 @@ func TestTasksRepo_GetByIdWithOutputHeaders(t *testing.T) {
 	t.Run("GetById with empty result", func(t *testing.T) {
 		id := int64(1515)
 
-		rows := sqlmock.NewRows([]string{"id", "url", "method", "status", "response_status_code", "response_length", "header_name", "header_value"})
+		rows := sqlmock.NewRows([]string{"id", "url", "method", "status", "response_status_code", "response_length", "attempts", "next_attempt_at", "last_error", "run_at", "callback_url", "callback_status", "callback_attempts", "callback_last_error", "trace_id", "client", "tls_profile", "header_name", "header_value"})
 
 		mock.ExpectPrepare(sql)
 		mock.ExpectQuery(sql).WithArgs(id).WillReturnRows(rows)
@@ -294,7 +386,7 @@ func TestTasksRepo_UpdateStatus(t *testing.T) {
 
 	sugar := zap.New(zapcore.NewNopCore()).Sugar()
 
-	sql := "UPDATE task SET status=$1 WHERE id=$2"
+	sql := "UPDATE task SET status=$1 WHERE id=$2 AND status IN ($3, $4)"
 
 	tasksRepo := NewRepository(sqlxDb, sugar)
 
@@ -303,7 +395,7 @@ func TestTasksRepo_UpdateStatus(t *testing.T) {
 		newStatus := models.StatusInProcess
 
 		mock.ExpectPrepare(sql)
-		mock.ExpectExec(sql).WithArgs(newStatus, id).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(sql).WithArgs(newStatus, id, models.StatusNew, models.StatusScheduled).WillReturnResult(sqlmock.NewResult(1, 1))
 
 		err := tasksRepo.UpdateStatus(context.Background(), id, newStatus)
 
@@ -315,7 +407,7 @@ func TestTasksRepo_UpdateStatus(t *testing.T) {
 		newStatus := models.StatusInProcess
 
 		mock.ExpectPrepare(sql)
-		mock.ExpectExec(sql).WithArgs(newStatus, id).WillReturnResult(sqlmock.NewResult(1, 0))
+		mock.ExpectExec(sql).WithArgs(newStatus, id, models.StatusNew, models.StatusScheduled).WillReturnResult(sqlmock.NewResult(1, 0))
 
 		err := tasksRepo.UpdateStatus(context.Background(), id, newStatus)
 
@@ -336,7 +428,7 @@ func TestTasksRepo_UpdateResultWithoutHeaders(t *testing.T) {
 
 	tasksRepo := NewRepository(sqlxDb, sugar)
 
-	sql := "UPDATE task SET status = $1, response_status_code = $2, response_length = $3 WHERE id = $4"
+	sql := "UPDATE task SET status = $1, response_status_code = $2, response_length = $3, callback_status = CASE WHEN callback_url IS NULL THEN NULL ELSE $5 END WHERE id = $4 AND status IN ($6)"
 
 	t.Run("Update result without headers", func(t *testing.T) {
 		status := int64(200)
@@ -350,7 +442,7 @@ func TestTasksRepo_UpdateResultWithoutHeaders(t *testing.T) {
 
 		mock.ExpectBegin()
 		mock.ExpectPrepare(sql)
-		mock.ExpectExec(sql).WithArgs(task.Status, task.ResponseStatus, task.ResponseLength, task.Id).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(sql).WithArgs(task.Status, task.ResponseStatus, task.ResponseLength, task.Id, models.CallbackStatusPending, models.StatusInProcess).WillReturnResult(sqlmock.NewResult(1, 1))
 		mock.ExpectCommit()
 
 		err := tasksRepo.UpdateResult(context.Background(), task)
@@ -373,7 +465,7 @@ func TestTasksRepo_UpdateResultWithoutHeaders(t *testing.T) {
 		headersSql := "INSERT INTO headers(name, value, input, task_id) VALUES ($1, $2, $3, 1515) "
 		mock.ExpectBegin()
 		mock.ExpectPrepare(sql)
-		mock.ExpectExec(sql).WithArgs(task.Status, task.ResponseStatus, task.ResponseLength, task.Id).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(sql).WithArgs(task.Status, task.ResponseStatus, task.ResponseLength, task.Id, models.CallbackStatusPending, models.StatusInProcess).WillReturnResult(sqlmock.NewResult(1, 1))
 		mock.ExpectPrepare(headersSql)
 		mock.ExpectExec(headersSql).WithArgs(header.Name, header.Value, header.Input).WillReturnResult(sqlmock.NewResult(1, 1))
 
@@ -400,7 +492,7 @@ func TestTasksRepo_UpdateResultWithoutHeaders(t *testing.T) {
 		headersSql := "INSERT INTO headers(name, value, input, task_id) VALUES ($1, $2, $3, 1515) ,($4, $5, $6, 1515) "
 		mock.ExpectBegin()
 		mock.ExpectPrepare(sql)
-		mock.ExpectExec(sql).WithArgs(task.Status, task.ResponseStatus, task.ResponseLength, task.Id).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(sql).WithArgs(task.Status, task.ResponseStatus, task.ResponseLength, task.Id, models.CallbackStatusPending, models.StatusInProcess).WillReturnResult(sqlmock.NewResult(1, 1))
 		mock.ExpectPrepare(headersSql)
 		mock.ExpectExec(headersSql).WithArgs(header.Name, header.Value, header.Input, secondHeader.Name, secondHeader.Value, secondHeader.Input).WillReturnResult(sqlmock.NewResult(1, 1))
 
@@ -426,7 +518,7 @@ func TestTasksRepo_UpdateResultWithoutHeaders(t *testing.T) {
 		headersSql := "INSERT INTO headers(name, value, input, task_id) VALUES ($1, $2, $3, 1515) "
 		mock.ExpectBegin()
 		mock.ExpectPrepare(sql)
-		mock.ExpectExec(sql).WithArgs(task.Status, task.ResponseStatus, task.ResponseLength, task.Id).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(sql).WithArgs(task.Status, task.ResponseStatus, task.ResponseLength, task.Id, models.CallbackStatusPending, models.StatusInProcess).WillReturnResult(sqlmock.NewResult(1, 1))
 		mock.ExpectPrepare(headersSql)
 		mock.ExpectExec(headersSql).WithArgs(header.Name, header.Value, header.Input).WillReturnError(errors.New("error"))
 
@@ -449,18 +541,914 @@ func TestTasksRepo_UpdateResultWithoutHeaders(t *testing.T) {
 			ResponseLength: &responseLength,
 			Headers:        headers,
 		}
-		headersSql := "INSERT INTO headers(name, value, input, task_id) VALUES ($1, $2, $3, 1515) "
 		mock.ExpectBegin()
 		mock.ExpectPrepare(sql)
-		mock.ExpectExec(sql).WithArgs(task.Status, task.ResponseStatus, task.ResponseLength, task.Id).WillReturnResult(sqlmock.NewResult(1, 0))
-		mock.ExpectPrepare(headersSql)
-		mock.ExpectExec(headersSql).WithArgs(header.Name, header.Value, header.Input).WillReturnError(errors.New("error"))
-
+		mock.ExpectExec(sql).WithArgs(task.Status, task.ResponseStatus, task.ResponseLength, task.Id, models.CallbackStatusPending, models.StatusInProcess).WillReturnResult(sqlmock.NewResult(1, 0))
 		mock.ExpectRollback()
 
 		err := tasksRepo.UpdateResult(context.Background(), task)
 
 		require.Error(t, err)
 		require.ErrorIs(t, err, dbSql.ErrNoRows)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestTasksRepo_CountBacklog(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+
+	sqlxDb := sqlx.NewDb(db, "sqlmock")
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	tasksRepo := NewRepository(sqlxDb, sugar)
+
+	sql := `SELECT count(*) FROM task
+			WHERE status IN ($1, $2)
+			AND (next_attempt_at IS NULL OR next_attempt_at <= now())
+			AND (run_at IS NULL OR run_at <= now())`
+
+	mock.ExpectPrepare(sql)
+	mock.ExpectQuery(sql).WithArgs(models.StatusNew, models.StatusScheduled).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(42))
+
+	count, err := tasksRepo.CountBacklog(context.Background())
+
+	require.NoError(t, err)
+	require.Equal(t, int64(42), count)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTasksRepo_GetClient(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+
+	sqlxDb := sqlx.NewDb(db, "sqlmock")
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	tasksRepo := NewRepository(sqlxDb, sugar)
+
+	sql := "SELECT client FROM task WHERE id = $1"
+
+	mock.ExpectPrepare(sql)
+	mock.ExpectQuery(sql).WithArgs(int64(1515)).WillReturnRows(sqlmock.NewRows([]string{"client"}).AddRow("billing"))
+
+	client, err := tasksRepo.GetClient(context.Background(), 1515)
+
+	require.NoError(t, err)
+	require.Equal(t, "billing", *client)
+}
+
+func TestTasksRepo_Heartbeat(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+
+	sqlxDb := sqlx.NewDb(db, "sqlmock")
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	tasksRepo := NewRepository(sqlxDb, sugar)
+
+	sql := "UPDATE task SET heartbeat_at = now() WHERE status = $1 AND id IN ($2, $3)"
+
+	mock.ExpectPrepare(sql)
+	mock.ExpectExec(sql).WithArgs(models.StatusInProcess, int64(1), int64(2)).WillReturnResult(sqlmock.NewResult(0, 2))
+
+	require.NoError(t, tasksRepo.Heartbeat(context.Background(), []int64{1, 2}))
+	require.NoError(t, tasksRepo.Heartbeat(context.Background(), nil))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTasksRepo_ClaimStuck(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+
+	sqlxDb := sqlx.NewDb(db, "sqlmock")
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	tasksRepo := NewRepository(sqlxDb, sugar)
+
+	sql := `UPDATE task SET heartbeat_at = now()
+			WHERE id = (SELECT id FROM task
+						WHERE status = $1 AND heartbeat_at < $2
+						ORDER BY id
+						LIMIT 1
+						FOR UPDATE SKIP LOCKED)
+			RETURNING id, url, method, status, retry_policy, attempts, started_at, client`
+	leaseExpiredAt := time.Now().Add(-time.Minute)
+
+	t.Run("Claim stuck task", func(t *testing.T) {
+		startedAt := time.Now().Add(-time.Hour)
+
+		mock.ExpectPrepare(sql)
+		mock.ExpectQuery(sql).WithArgs(models.StatusInProcess, leaseExpiredAt).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "url", "method", "status", "retry_policy", "attempts", "started_at", "client"}).
+				AddRow(1515, "https://www.google.com", "GET", models.StatusInProcess, []byte(`{"maxAttempts":3}`), 1, startedAt, "acme"))
+
+		task, err := tasksRepo.ClaimStuck(context.Background(), leaseExpiredAt)
+
+		require.NoError(t, err)
+		require.Equal(t, int64(1515), task.Id)
+		require.Equal(t, 3, task.RetryPolicy.MaxAttempts)
+		require.Equal(t, startedAt, *task.StartedAt)
+		require.Equal(t, "acme", *task.Client)
+	})
+
+	t.Run("No stuck tasks", func(t *testing.T) {
+		mock.ExpectPrepare(sql)
+		mock.ExpectQuery(sql).WithArgs(models.StatusInProcess, leaseExpiredAt).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "url", "method", "status", "retry_policy", "attempts", "started_at", "client"}))
+
+		_, err := tasksRepo.ClaimStuck(context.Background(), leaseExpiredAt)
+
+		require.ErrorIs(t, err, dbSql.ErrNoRows)
+	})
+}
+
+func TestTasksRepo_ClaimNew(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+
+	sqlxDb := sqlx.NewDb(db, "sqlmock")
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	tasksRepo := NewRepository(sqlxDb, sugar)
+
+	sql := `UPDATE task SET status = $1, attempts = attempts + 1, next_attempt_at = NULL, started_at = now(), heartbeat_at = now()
+									WHERE id = (SELECT id FROM task
+												WHERE status IN ($2, $3)
+												AND (next_attempt_at IS NULL OR next_attempt_at <= now())
+												AND (run_at IS NULL OR run_at <= now())
+												ORDER BY id
+												LIMIT 1
+												FOR UPDATE SKIP LOCKED)
+									RETURNING id, url, method, status, body, body_encoding, retry_policy, attempts, trace_id, span_id, trace_sampled, tls_profile, client`
+	headersSql := "SELECT name, value FROM headers WHERE task_id = $1 AND input = true"
+
+	t.Run("Claim task with input headers", func(t *testing.T) {
+		id := int64(1)
+		url := "https://www.google.com"
+		method := "GET"
+		headerName := "TEST_NAME"
+		headerValue := "TEST_VALUE"
+
+		mock.ExpectPrepare(sql)
+		mock.ExpectQuery(sql).WithArgs(models.StatusInProcess, models.StatusNew, models.StatusScheduled).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "url", "method", "status", "body", "body_encoding", "retry_policy", "attempts", "trace_id", "span_id", "trace_sampled", "tls_profile", "client"}).AddRow(id, url, method, models.StatusInProcess, "", "", []byte(`{"maxAttempts":3,"retryOnStatus":[503]}`), 1, nil, nil, false, "partner-mtls", "acme"))
+		mock.ExpectPrepare(headersSql)
+		mock.ExpectQuery(headersSql).WithArgs(id).
+			WillReturnRows(sqlmock.NewRows([]string{"name", "value"}).AddRow(headerName, headerValue))
+
+		task, err := tasksRepo.ClaimNew(context.Background())
+
+		require.NoError(t, err)
+		require.NotNil(t, task)
+		assert.Equal(t, id, task.Id)
+		assert.Equal(t, url, task.Url)
+		assert.Equal(t, method, task.Method)
+		assert.Equal(t, models.StatusInProcess, task.Status)
+		assert.Equal(t, "partner-mtls", *task.TlsProfile)
+		assert.Equal(t, "acme", *task.Client)
+		assert.Equal(t, 1, task.Attempts)
+		require.NotNil(t, task.RetryPolicy)
+		assert.Equal(t, 3, task.RetryPolicy.MaxAttempts)
+		assert.Equal(t, []int{503}, task.RetryPolicy.RetryOnStatus)
+		require.Len(t, task.Headers, 1)
+		assert.Equal(t, headerName, task.Headers[0].Name)
+		assert.Equal(t, headerValue, task.Headers[0].Value)
+		assert.True(t, task.Headers[0].Input)
+	})
+
+	t.Run("No new tasks", func(t *testing.T) {
+		mock.ExpectPrepare(sql)
+		mock.ExpectQuery(sql).WithArgs(models.StatusInProcess, models.StatusNew, models.StatusScheduled).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "url", "method", "status", "body", "body_encoding", "retry_policy", "attempts", "trace_id", "span_id"}))
+
+		task, err := tasksRepo.ClaimNew(context.Background())
+
+		require.Error(t, err)
+		require.Nil(t, task)
+		require.ErrorIs(t, err, dbSql.ErrNoRows)
+	})
+}
+
+func TestTasksRepo_UpdateResultWithResponse(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+
+	sqlxDb := sqlx.NewDb(db, "sqlmock")
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	tasksRepo := NewRepository(sqlxDb, sugar)
+
+	sql := "UPDATE task SET status = $1, response_status_code = $2, response_length = $3, callback_status = CASE WHEN callback_url IS NULL THEN NULL ELSE $5 END WHERE id = $4 AND status IN ($6)"
+	responseSql := `INSERT INTO task_response (task_id, content_type, body, truncated) VALUES ($1, $2, $3, $4)
+									ON CONFLICT (task_id) DO UPDATE SET content_type = EXCLUDED.content_type, body = EXCLUDED.body, truncated = EXCLUDED.truncated`
+
+	status := int64(200)
+	responseLength := int64(2)
+	task := &models.Task{
+		Id:             int64(1515),
+		Status:         models.StatusDone,
+		ResponseStatus: &status,
+		ResponseLength: &responseLength,
+		Response:       &models.TaskResponse{TaskId: 1515, ContentType: "text/plain", Body: []byte("ok")},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare(sql)
+	mock.ExpectExec(sql).WithArgs(task.Status, task.ResponseStatus, task.ResponseLength, task.Id, models.CallbackStatusPending, models.StatusInProcess).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectPrepare(responseSql)
+	mock.ExpectExec(responseSql).WithArgs(task.Id, task.Response.ContentType, task.Response.Body, task.Response.Truncated).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err = tasksRepo.UpdateResult(context.Background(), task)
+
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTasksRepo_GetResponse(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+
+	sqlxDb := sqlx.NewDb(db, "sqlmock")
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	tasksRepo := NewRepository(sqlxDb, sugar)
+
+	sql := "SELECT task_id, content_type, body, truncated FROM task_response WHERE task_id = $1"
+
+	t.Run("GetResponse successfully", func(t *testing.T) {
+		id := int64(1)
+
+		mock.ExpectPrepare(sql)
+		mock.ExpectQuery(sql).WithArgs(id).
+			WillReturnRows(sqlmock.NewRows([]string{"task_id", "content_type", "body", "truncated"}).AddRow(id, "text/plain", []byte("ok"), false))
+
+		response, err := tasksRepo.GetResponse(context.Background(), id)
+
+		require.NoError(t, err)
+		require.NotNil(t, response)
+		assert.Equal(t, id, response.TaskId)
+		assert.Equal(t, "text/plain", response.ContentType)
+		assert.Equal(t, []byte("ok"), response.Body)
+		assert.False(t, response.Truncated)
+	})
+
+	t.Run("GetResponse not found", func(t *testing.T) {
+		id := int64(1515)
+
+		mock.ExpectPrepare(sql)
+		mock.ExpectQuery(sql).WithArgs(id).
+			WillReturnRows(sqlmock.NewRows([]string{"task_id", "content_type", "body", "truncated"}))
+
+		response, err := tasksRepo.GetResponse(context.Background(), id)
+
+		require.Nil(t, response)
+		require.ErrorIs(t, err, dbSql.ErrNoRows)
+	})
+}
+
+func TestTasksRepo_ScheduleRetry(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+
+	sqlxDb := sqlx.NewDb(db, "sqlmock")
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	tasksRepo := NewRepository(sqlxDb, sugar)
+
+	sql := "UPDATE task SET status = $1, next_attempt_at = $2, last_error = $3 WHERE id = $4 AND status IN ($5)"
+
+	t.Run("ScheduleRetry successfully", func(t *testing.T) {
+		id := int64(1515)
+		nextAttemptAt := time.Now().Add(time.Minute)
+
+		mock.ExpectPrepare(sql)
+		mock.ExpectExec(sql).WithArgs(models.StatusNew, nextAttemptAt, "error", id, models.StatusInProcess).WillReturnResult(sqlmock.NewResult(1, 1))
+
+		err := tasksRepo.ScheduleRetry(context.Background(), id, nextAttemptAt, "error")
+
+		require.NoError(t, err)
+	})
+
+	t.Run("ScheduleRetry not rows affected", func(t *testing.T) {
+		id := int64(1515)
+		nextAttemptAt := time.Now().Add(time.Minute)
+
+		mock.ExpectPrepare(sql)
+		mock.ExpectExec(sql).WithArgs(models.StatusNew, nextAttemptAt, "error", id, models.StatusInProcess).WillReturnResult(sqlmock.NewResult(1, 0))
+
+		err := tasksRepo.ScheduleRetry(context.Background(), id, nextAttemptAt, "error")
+
+		require.ErrorIs(t, err, dbSql.ErrNoRows)
 	})
 }
+
+func TestTasksRepo_UpdateError(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+
+	sqlxDb := sqlx.NewDb(db, "sqlmock")
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	tasksRepo := NewRepository(sqlxDb, sugar)
+
+	sql := "UPDATE task SET status = $1, last_error = $2, callback_status = CASE WHEN callback_url IS NULL THEN NULL ELSE $4 END WHERE id = $3 AND status IN ($5)"
+
+	id := int64(1515)
+
+	mock.ExpectPrepare(sql)
+	mock.ExpectExec(sql).WithArgs(models.StatusError, "error", id, models.CallbackStatusPending, models.StatusInProcess).WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = tasksRepo.UpdateError(context.Background(), id, "error")
+
+	require.NoError(t, err)
+}
+
+func TestTasksRepo_Requeue(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+
+	sqlxDb := sqlx.NewDb(db, "sqlmock")
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	tasksRepo := NewRepository(sqlxDb, sugar)
+
+	sql := "UPDATE task SET status = $1, attempts = GREATEST(attempts - 1, 0), next_attempt_at = $2 WHERE id = $3 AND status IN ($4)"
+
+	id := int64(1515)
+
+	t.Run("In process task", func(t *testing.T) {
+		mock.ExpectPrepare(sql)
+		mock.ExpectExec(sql).WithArgs(models.StatusNew, nil, id, models.StatusInProcess).WillReturnResult(sqlmock.NewResult(1, 1))
+
+		err := tasksRepo.Requeue(context.Background(), id, nil)
+
+		require.NoError(t, err)
+	})
+
+	t.Run("Task already finished", func(t *testing.T) {
+		mock.ExpectPrepare(sql)
+		nextAttemptAt := time.Now().Add(time.Second)
+		mock.ExpectExec(sql).WithArgs(models.StatusNew, &nextAttemptAt, id, models.StatusInProcess).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := tasksRepo.Requeue(context.Background(), id, &nextAttemptAt)
+
+		require.ErrorIs(t, err, dbSql.ErrNoRows)
+	})
+}
+
+func TestTasksRepo_Cancel(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+
+	sqlxDb := sqlx.NewDb(db, "sqlmock")
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	tasksRepo := NewRepository(sqlxDb, sugar)
+
+	sql := `UPDATE task t SET status = $1, next_attempt_at = NULL
+			FROM (SELECT id, status FROM task WHERE id = $2 FOR UPDATE) prev
+			WHERE t.id = prev.id AND prev.status IN ($3, $4, $5)
+			RETURNING prev.status`
+	statusSql := "SELECT status FROM task WHERE id = $1"
+	id := int64(1515)
+
+	t.Run("Cancel in process task", func(t *testing.T) {
+		mock.ExpectPrepare(sql)
+		mock.ExpectQuery(sql).WithArgs(models.StatusCancelled, id, models.StatusNew, models.StatusScheduled, models.StatusInProcess).
+			WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(models.StatusInProcess))
+
+		previousStatus, err := tasksRepo.Cancel(context.Background(), id)
+
+		require.NoError(t, err)
+		require.Equal(t, models.StatusInProcess, previousStatus)
+	})
+
+	t.Run("Cancel finished task", func(t *testing.T) {
+		mock.ExpectPrepare(sql)
+		mock.ExpectQuery(sql).WithArgs(models.StatusCancelled, id, models.StatusNew, models.StatusScheduled, models.StatusInProcess).
+			WillReturnRows(sqlmock.NewRows([]string{"status"}))
+		mock.ExpectPrepare(statusSql)
+		mock.ExpectQuery(statusSql).WithArgs(id).WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(models.StatusDone))
+
+		_, err := tasksRepo.Cancel(context.Background(), id)
+
+		require.Error(t, err)
+		require.ErrorIs(t, err, models.ErrInvalidStatusTransition)
+	})
+
+	t.Run("Cancel missing task", func(t *testing.T) {
+		mock.ExpectPrepare(sql)
+		mock.ExpectQuery(sql).WithArgs(models.StatusCancelled, id, models.StatusNew, models.StatusScheduled, models.StatusInProcess).
+			WillReturnRows(sqlmock.NewRows([]string{"status"}))
+		mock.ExpectPrepare(statusSql)
+		mock.ExpectQuery(statusSql).WithArgs(id).WillReturnRows(sqlmock.NewRows([]string{"status"}))
+
+		_, err := tasksRepo.Cancel(context.Background(), id)
+
+		require.Error(t, err)
+		require.ErrorIs(t, err, dbSql.ErrNoRows)
+	})
+}
+
+func TestTasksRepo_ClaimCallback(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+
+	sqlxDb := sqlx.NewDb(db, "sqlmock")
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	tasksRepo := NewRepository(sqlxDb, sugar)
+
+	sql := `UPDATE task SET callback_attempts = callback_attempts + 1, callback_next_attempt_at = $1
+			WHERE id = (SELECT id FROM task
+						WHERE callback_status = $2 AND (callback_next_attempt_at IS NULL OR callback_next_attempt_at <= now())
+						ORDER BY id
+						LIMIT 1
+						FOR UPDATE SKIP LOCKED)
+			RETURNING id, callback_secret`
+	getSql := `SELECT t.id, t.url as url, t.method as method, t.status as status,
+			t.response_status_code as response_status, t.response_length as response_length,
+			t.attempts as attempts, t.next_attempt_at as next_attempt_at, t.last_error as last_error, t.run_at as run_at,
+			t.callback_url as callback_url, t.callback_status as callback_status,
+			t.callback_attempts as callback_attempts, t.callback_last_error as callback_last_error, t.trace_id as trace_id, t.client as client, t.tls_profile as tls_profile,
+			COALESCE(h.name, '') as header_name, COALESCE(h.value, '') as header_value
+			FROM task t
+			LEFT JOIN headers h ON h.task_id = t.id AND h.input=false
+			WHERE t.id = $1`
+	leaseUntil := time.Now().Add(time.Minute)
+
+	t.Run("Claim pending callback", func(t *testing.T) {
+		id := int64(1515)
+		callbackUrl := "https://example.com/hook"
+
+		mock.ExpectPrepare(sql)
+		mock.ExpectQuery(sql).WithArgs(leaseUntil, models.CallbackStatusPending).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "callback_secret"}).AddRow(id, "secret"))
+		mock.ExpectPrepare(getSql)
+		mock.ExpectQuery(getSql).WithArgs(id).WillReturnRows(sqlmock.NewRows([]string{"id", "url", "method", "status", "response_status_code", "response_length", "attempts", "next_attempt_at", "last_error", "run_at", "callback_url", "callback_status", "callback_attempts", "callback_last_error", "trace_id", "client", "tls_profile", "header_name", "header_value"}).
+			AddRow(id, "https://www.google.com", "GET", models.StatusDone, 200, 10, 1, nil, nil, nil, callbackUrl, models.CallbackStatusPending, 2, nil, nil, nil, nil, "", ""))
+
+		task, err := tasksRepo.ClaimCallback(context.Background(), leaseUntil)
+
+		require.NoError(t, err)
+		require.NotNil(t, task.Callback)
+		assert.Equal(t, callbackUrl, task.Callback.Url)
+		assert.Equal(t, "secret", task.Callback.Secret)
+		assert.Equal(t, 2, task.Callback.Attempts)
+		assert.Equal(t, models.CallbackStatusPending, *task.Callback.Status)
+	})
+
+	t.Run("No pending callbacks", func(t *testing.T) {
+		mock.ExpectPrepare(sql)
+		mock.ExpectQuery(sql).WithArgs(leaseUntil, models.CallbackStatusPending).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "callback_secret"}))
+
+		task, err := tasksRepo.ClaimCallback(context.Background(), leaseUntil)
+
+		require.ErrorIs(t, err, dbSql.ErrNoRows)
+		require.Nil(t, task)
+	})
+}
+
+func TestTasksRepo_UpdateCallbackStatus(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+
+	sqlxDb := sqlx.NewDb(db, "sqlmock")
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	tasksRepo := NewRepository(sqlxDb, sugar)
+
+	sql := "UPDATE task SET callback_status = $1, callback_next_attempt_at = $2, callback_last_error = $3 WHERE id = $4"
+	id := int64(1515)
+	nextAttemptAt := time.Now()
+	lastError := "unexpected status code 500"
+
+	mock.ExpectPrepare(sql)
+	mock.ExpectExec(sql).WithArgs(models.CallbackStatusPending, &nextAttemptAt, &lastError, id).WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = tasksRepo.UpdateCallbackStatus(context.Background(), id, models.CallbackStatusPending, &nextAttemptAt, &lastError)
+
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTasksRepo_CreateAttempt(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+
+	sqlxDb := sqlx.NewDb(db, "sqlmock")
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	tasksRepo := NewRepository(sqlxDb, sugar)
+
+	sql := `INSERT INTO task_attempt (task_id, attempt, started_at, finished_at, duration_ms, response_status_code, response_length, error)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id`
+
+	status := int64(200)
+	length := int64(10)
+	startedAt := time.Now()
+	attempt := &models.TaskAttempt{
+		TaskId:         1515,
+		Attempt:        1,
+		StartedAt:      startedAt,
+		FinishedAt:     startedAt.Add(time.Second),
+		DurationMs:     1000,
+		ResponseStatus: &status,
+		ResponseLength: &length,
+	}
+
+	mock.ExpectPrepare(sql)
+	mock.ExpectQuery(sql).WithArgs(attempt.TaskId, attempt.Attempt, attempt.StartedAt, attempt.FinishedAt, attempt.DurationMs,
+		attempt.ResponseStatus, attempt.ResponseLength, attempt.Error).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+
+	err = tasksRepo.CreateAttempt(context.Background(), attempt)
+
+	require.NoError(t, err)
+	require.Equal(t, int64(7), attempt.Id)
+}
+
+func TestTasksRepo_GetAttempts(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+
+	sqlxDb := sqlx.NewDb(db, "sqlmock")
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	tasksRepo := NewRepository(sqlxDb, sugar)
+
+	existsSql := "SELECT EXISTS(SELECT 1 FROM task WHERE id = $1)"
+	sql := `SELECT id, task_id, attempt, started_at, finished_at, duration_ms, response_status_code, response_length, error
+			FROM task_attempt
+			WHERE task_id = $1
+			ORDER BY attempt, id`
+	columns := []string{"id", "task_id", "attempt", "started_at", "finished_at", "duration_ms", "response_status_code", "response_length", "error"}
+
+	t.Run("GetAttempts with two attempts", func(t *testing.T) {
+		id := int64(1)
+		startedAt := time.Now()
+
+		mock.ExpectPrepare(existsSql)
+		mock.ExpectQuery(existsSql).WithArgs(id).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		mock.ExpectPrepare(sql)
+		mock.ExpectQuery(sql).WithArgs(id).WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(1, id, 1, startedAt, startedAt.Add(time.Second), 1000, nil, nil, "connection refused").
+			AddRow(2, id, 2, startedAt.Add(time.Minute), startedAt.Add(time.Minute+time.Second), 1000, 200, 10, nil))
+
+		attempts, err := tasksRepo.GetAttempts(context.Background(), id)
+
+		require.NoError(t, err)
+		require.Len(t, attempts, 2)
+		assert.Equal(t, 1, attempts[0].Attempt)
+		assert.Nil(t, attempts[0].ResponseStatus)
+		assert.Equal(t, "connection refused", *attempts[0].Error)
+		assert.Equal(t, 2, attempts[1].Attempt)
+		assert.Equal(t, int64(200), *attempts[1].ResponseStatus)
+		assert.Nil(t, attempts[1].Error)
+	})
+
+	t.Run("GetAttempts of unknown task", func(t *testing.T) {
+		id := int64(1515)
+
+		mock.ExpectPrepare(existsSql)
+		mock.ExpectQuery(existsSql).WithArgs(id).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+		attempts, err := tasksRepo.GetAttempts(context.Background(), id)
+
+		require.Nil(t, attempts)
+		require.ErrorIs(t, err, dbSql.ErrNoRows)
+	})
+}
+
+func TestTasksRepo_List(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+
+	sqlxDb := sqlx.NewDb(db, "sqlmock")
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	tasksRepo := NewRepository(sqlxDb, sugar)
+
+	selectSql := "SELECT id, url, method, status, response_status_code, response_length, attempts, next_attempt_at, last_error, run_at, created_at FROM task WHERE TRUE"
+	columns := []string{"id", "url", "method", "status", "response_status_code", "response_length", "attempts", "next_attempt_at", "last_error", "run_at", "created_at"}
+
+	t.Run("List without filters", func(t *testing.T) {
+		sql := selectSql + " ORDER BY id ASC LIMIT $1"
+		createdAt := time.Now()
+
+		mock.ExpectPrepare(sql)
+		mock.ExpectQuery(sql).WithArgs(10).WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(1, "https://www.google.com", "GET", models.StatusDone, 200, 10, 1, nil, nil, nil, createdAt).
+			AddRow(2, "https://www.google.com", "POST", models.StatusNew, nil, nil, 0, nil, nil, nil, createdAt))
+
+		found, err := tasksRepo.List(context.Background(), models.TaskFilter{SortBy: models.SortById, Limit: 10})
+
+		require.NoError(t, err)
+		require.Len(t, found, 2)
+		assert.Equal(t, int64(1), found[0].Id)
+		assert.Equal(t, int64(200), *found[0].ResponseStatus)
+		assert.Equal(t, int64(2), found[1].Id)
+		assert.Nil(t, found[1].ResponseStatus)
+	})
+
+	t.Run("List tasks of client", func(t *testing.T) {
+		client := "billing"
+		sql := selectSql + " AND client = $1 ORDER BY id ASC LIMIT $2"
+
+		mock.ExpectPrepare(sql)
+		mock.ExpectQuery(sql).WithArgs(client, 10).WillReturnRows(sqlmock.NewRows(columns))
+
+		found, err := tasksRepo.List(context.Background(), models.TaskFilter{SortBy: models.SortById, Limit: 10, Client: &client})
+
+		require.NoError(t, err)
+		require.Empty(t, found)
+	})
+
+	t.Run("List with all filters", func(t *testing.T) {
+		minCode := int64(200)
+		maxCode := int64(299)
+		from := time.Now().Add(-time.Hour)
+		to := time.Now()
+		after := &models.TaskCursor{Id: 15, CreatedAt: to.Add(-time.Minute)}
+		filter := models.TaskFilter{
+			Statuses:          []string{models.StatusDone, models.StatusError},
+			Method:            "get",
+			Host:              "WWW.google.com",
+			UrlPrefix:         "https://www.google.com/api",
+			MinResponseStatus: &minCode,
+			MaxResponseStatus: &maxCode,
+			CreatedFrom:       &from,
+			CreatedTo:         &to,
+			SortBy:            models.SortByCreatedAt,
+			SortDesc:          true,
+			Limit:             5,
+			After:             after,
+		}
+		sql := selectSql +
+			" AND status IN ($1, $2)" +
+			" AND upper(method) = $3" +
+			" AND lower(substring(url from '^[^:]+://(?:[^@/]*@)?([^/:?#]+)')) = $4" +
+			" AND starts_with(url, $5)" +
+			" AND response_status_code >= $6" +
+			" AND response_status_code <= $7" +
+			" AND created_at >= $8" +
+			" AND created_at < $9" +
+			" AND (created_at, id) < ($10, $11)" +
+			" ORDER BY created_at DESC, id DESC LIMIT $12"
+
+		mock.ExpectPrepare(sql)
+		mock.ExpectQuery(sql).WithArgs(models.StatusDone, models.StatusError, "GET", "www.google.com", filter.UrlPrefix,
+			minCode, maxCode, from, to, after.CreatedAt, after.Id, 5).WillReturnRows(sqlmock.NewRows(columns))
+
+		found, err := tasksRepo.List(context.Background(), filter)
+
+		require.NoError(t, err)
+		require.Empty(t, found)
+	})
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTasksRepo_CreateBatch(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+
+	sqlxDb := sqlx.NewDb(db, "sqlmock")
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	tasksRepo := NewRepository(sqlxDb, sugar)
+
+	batchSql := "INSERT INTO task_batch (size, client) VALUES ($1, $2) RETURNING id"
+	tasksSql := "WITH input (ord, method, url, status, body, body_encoding, retry_policy, run_at, callback_url, callback_secret, batch_id, trace_id, span_id, trace_sampled, client, tls_profile) AS (VALUES " +
+		"(0, $1, $2, $3, $4, $5, $6::jsonb, $7::timestamptz, $8, $9, $10::bigint, $11, $12, $13::boolean, $14, $15), " +
+		"(1, $16, $17, $18, $19, $20, $21::jsonb, $22::timestamptz, $23, $24, $25::bigint, $26, $27, $28::boolean, $29, $30)" +
+		"), numbered AS (SELECT nextval(pg_get_serial_sequence('task', 'id')) AS id, * FROM input), " +
+		"inserted AS (INSERT INTO task (id, method, url, status, body, body_encoding, retry_policy, run_at, callback_url, callback_secret, batch_id, trace_id, span_id, trace_sampled, client, tls_profile) " +
+		"SELECT id, method, url, status, body, body_encoding, retry_policy, run_at, callback_url, callback_secret, batch_id, trace_id, span_id, trace_sampled, client, tls_profile FROM numbered RETURNING id) " +
+		"SELECT numbered.ord, inserted.id FROM inserted JOIN numbered USING (id)"
+	headersSql := "INSERT INTO headers(name, value, input, task_id) VALUES ($1, $2, $3, $4)"
+
+	t.Run("Tracked batch", func(t *testing.T) {
+		tlsProfile := "partner-mtls"
+		client := "acme"
+		tasks := []models.Task{
+			{Method: "GET", Url: "https://www.google.com", Status: models.StatusNew, Client: &client},
+			{Method: "POST", Url: "https://www.google.com", Status: models.StatusNew, Headers: []models.Header{{Name: "TEST_NAME", Value: "TEST_VALUE", Input: true}},
+				Callback: &models.Callback{Url: "https://example.com/hook", Secret: "secret"}, TlsProfile: &tlsProfile, Client: &client},
+		}
+		batchId := int64(3)
+
+		mock.ExpectBegin()
+		mock.ExpectPrepare(batchSql)
+		mock.ExpectQuery(batchSql).WithArgs(2, &client).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(batchId))
+		mock.ExpectPrepare(tasksSql)
+		mock.ExpectQuery(tasksSql).WithArgs(
+			"GET", "https://www.google.com", models.StatusNew, "", "", nil, nil, nil, nil, &batchId, nil, nil, false, &client, nil,
+			"POST", "https://www.google.com", models.StatusNew, "", "", nil, nil, "https://example.com/hook", "secret", &batchId, nil, nil, false, &client, "partner-mtls").
+			WillReturnRows(sqlmock.NewRows([]string{"ord", "id"}).AddRow(1, 10).AddRow(0, 11))
+		mock.ExpectPrepare(headersSql)
+		mock.ExpectExec(headersSql).WithArgs("TEST_NAME", "TEST_VALUE", true, int64(10)).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		batch, err := tasksRepo.CreateBatch(context.Background(), tasks, true)
+
+		require.NoError(t, err)
+		assert.Equal(t, batchId, *batch.Id)
+		assert.Equal(t, []int64{11, 10}, batch.TaskIds)
+		assert.Equal(t, int64(10), tasks[1].Id)
+	})
+
+	t.Run("Rollback if cannot create tasks", func(t *testing.T) {
+		tasks := []models.Task{
+			{Method: "GET", Url: "https://www.google.com", Status: models.StatusNew},
+			{Method: "GET", Url: "https://www.google.com", Status: models.StatusNew},
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectPrepare(tasksSql)
+		mock.ExpectQuery(tasksSql).WillReturnError(errors.New("error"))
+		mock.ExpectRollback()
+
+		batch, err := tasksRepo.CreateBatch(context.Background(), tasks, false)
+
+		require.Error(t, err)
+		require.Nil(t, batch)
+	})
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTasksRepo_CreateBatchChunked(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherFunc(func(expectedSQL, actualSQL string) error {
+		if !strings.HasPrefix(actualSQL, expectedSQL) {
+			return fmt.Errorf("query %q does not start with %q", actualSQL, expectedSQL)
+		}
+		return nil
+	})))
+	require.NoError(t, err)
+
+	sqlxDb := sqlx.NewDb(db, "sqlmock")
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	tasksRepo := NewRepository(sqlxDb, sugar)
+
+	tasksSql := "WITH input (ord, method, url, status, body, body_encoding, retry_policy, run_at, callback_url, callback_secret, batch_id, trace_id, span_id, trace_sampled, client, tls_profile) AS (VALUES "
+	lastSql := tasksSql + "(0, $1, $2, $3, $4, $5, $6::jsonb, $7::timestamptz, $8, $9, $10::bigint, $11, $12, $13::boolean, $14, $15)" +
+		"), numbered AS (SELECT nextval(pg_get_serial_sequence('task', 'id')) AS id, * FROM input), " +
+		"inserted AS (INSERT INTO task (id, method, url, status, body, body_encoding, retry_policy, run_at, callback_url, callback_secret, batch_id, trace_id, span_id, trace_sampled, client, tls_profile) " +
+		"SELECT id, method, url, status, body, body_encoding, retry_policy, run_at, callback_url, callback_secret, batch_id, trace_id, span_id, trace_sampled, client, tls_profile FROM numbered RETURNING id) " +
+		"SELECT numbered.ord, inserted.id FROM inserted JOIN numbered USING (id)"
+
+	tasks := make([]models.Task, tasksPerStatement+1)
+	first := sqlmock.NewRows([]string{"ord", "id"})
+	for i := range tasksPerStatement {
+		tasks[i] = models.Task{Method: "GET", Url: "https://www.google.com", Status: models.StatusNew}
+		first.AddRow(i, int64(i+1))
+	}
+	tasks[tasksPerStatement] = models.Task{Method: "GET", Url: "https://www.google.com", Status: models.StatusNew}
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare(tasksSql)
+	mock.ExpectQuery(tasksSql).WillReturnRows(first)
+	mock.ExpectPrepare(lastSql)
+	mock.ExpectQuery(lastSql).
+		WillReturnRows(sqlmock.NewRows([]string{"ord", "id"}).AddRow(0, int64(tasksPerStatement+1)))
+	mock.ExpectCommit()
+
+	batch, err := tasksRepo.CreateBatch(context.Background(), tasks, false)
+
+	require.NoError(t, err)
+	require.Len(t, batch.TaskIds, tasksPerStatement+1)
+	assert.Equal(t, int64(tasksPerStatement+1), tasks[tasksPerStatement].Id)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTasksRepo_GetBatchProgress(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+
+	sqlxDb := sqlx.NewDb(db, "sqlmock")
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	tasksRepo := NewRepository(sqlxDb, sugar)
+
+	sql := `SELECT b.id, b.size, b.created_at, b.client, t.status, COUNT(t.id)
+			FROM task_batch b
+			LEFT JOIN task t ON t.batch_id = b.id
+			WHERE b.id = $1
+			GROUP BY b.id, b.size, b.created_at, b.client, t.status`
+	columns := []string{"id", "size", "created_at", "client", "status", "count"}
+
+	t.Run("Found", func(t *testing.T) {
+		now := time.Now()
+		mock.ExpectPrepare(sql)
+		mock.ExpectQuery(sql).WithArgs(int64(3)).WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(3, 5, now, "acme", models.StatusDone, 2).
+			AddRow(3, 5, now, "acme", models.StatusError, 1).
+			AddRow(3, 5, now, "acme", models.StatusNew, 2))
+
+		progress, err := tasksRepo.GetBatchProgress(context.Background(), 3)
+
+		require.NoError(t, err)
+		assert.Equal(t, 5, progress.Size)
+		assert.Equal(t, 3, progress.Finished())
+		assert.Equal(t, "acme", *progress.Client)
+		assert.Equal(t, map[string]int{models.StatusDone: 2, models.StatusError: 1, models.StatusNew: 2}, progress.Statuses)
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		mock.ExpectPrepare(sql)
+		mock.ExpectQuery(sql).WithArgs(int64(4)).WillReturnRows(sqlmock.NewRows(columns))
+
+		_, err := tasksRepo.GetBatchProgress(context.Background(), 4)
+
+		require.ErrorIs(t, err, dbSql.ErrNoRows)
+	})
+}
+
+func TestTasksRepo_NotifyEvent(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+
+	sqlxDb := sqlx.NewDb(db, "sqlmock")
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	tasksRepo := NewRepository(sqlxDb, sugar)
+
+	sql := "SELECT pg_notify($1, $2)"
+	at := time.Date(2026, 10, 17, 10, 0, 0, 0, time.UTC)
+	lastError := strings.Repeat("e", 2000)
+
+	mock.ExpectPrepare(sql)
+	mock.ExpectExec(sql).WithArgs(models.TaskEventsChannel, sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectPrepare(sql)
+	mock.ExpectExec(sql).WithArgs(models.TaskEventsChannel, `{"taskId":1,"status":"done","at":"2026-10-17T10:00:00Z"}`).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, tasksRepo.NotifyEvent(context.Background(), models.TaskEvent{TaskId: 1, Status: models.StatusError, LastError: &lastError, At: at}))
+	require.Len(t, lastError, 2000)
+	require.NoError(t, tasksRepo.NotifyEvent(context.Background(), models.TaskEvent{TaskId: 1, Status: models.StatusDone, At: at}))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
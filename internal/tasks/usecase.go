@@ -8,5 +8,13 @@ import (
 
 type UseCase interface {
 	Create(ctx context.Context, task *models.Task) (*models.Task, error)
+	CreateBatch(ctx context.Context, tasks []models.Task, track bool) (*models.Batch, error)
+	GetBatchProgress(ctx context.Context, id int64) (*models.BatchProgress, error)
+	Validate(ctx context.Context, task *models.Task) error
 	GetByIdWithOutputHeaders(ctx context.Context, id int64) (*models.Task, error)
+	GetResponse(ctx context.Context, id int64) (*models.TaskResponse, error)
+	GetAttempts(ctx context.Context, id int64) ([]models.TaskAttempt, error)
+	Cancel(ctx context.Context, id int64) (string, error)
+	List(ctx context.Context, filter models.TaskFilter) (*models.TaskPage, error)
+	Subscribe(ctx context.Context, filter models.TaskEventFilter) (<-chan models.TaskEvent, func(), error)
 }
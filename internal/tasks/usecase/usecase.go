@@ -2,44 +2,155 @@ package usecase
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
 	"github.com/go-playground/validator/v10"
 	"github.com/pkg/errors"
+	"http-task-executor/internal/auth"
+	"http-task-executor/internal/config"
+	"http-task-executor/internal/egress"
 	"http-task-executor/internal/logger"
+	"http-task-executor/internal/metrics"
 	"http-task-executor/internal/models"
 	"http-task-executor/internal/tasks"
+	"http-task-executor/internal/tracing"
 	"http-task-executor/pkg/errors/general/validation"
 	httpErrors "http-task-executor/pkg/errors/http"
 	"http-task-executor/pkg/utils"
+	"net/http"
+	"slices"
+	"time"
+)
+
+const (
+	defaultListLimit = 50
+	maxListLimit     = 500
 )
 
 type TaskUseCase struct {
-	log  logger.Logger
-	repo tasks.Repository
-	exec tasks.Executor
+	cfg    *config.Config
+	log    logger.Logger
+	repo   tasks.Repository
+	pool   tasks.Pool
+	exec   tasks.Executor
+	events tasks.EventBus
+	egress *egress.Policy
 }
 
-func NewTaskUseCase(log logger.Logger, repo tasks.Repository, exec tasks.Executor) *TaskUseCase {
-	return &TaskUseCase{log: log, repo: repo, exec: exec}
+func NewTaskUseCase(cfg *config.Config, log logger.Logger, repo tasks.Repository, pool tasks.Pool, exec tasks.Executor, events tasks.EventBus, egress *egress.Policy) *TaskUseCase {
+	return &TaskUseCase{cfg: cfg, log: log, repo: repo, pool: pool, exec: exec, events: events, egress: egress}
 }
 
 func (t *TaskUseCase) Create(ctx context.Context, task *models.Task) (*models.Task, error) {
+	ctx, span := tracing.Start(ctx, "TaskUseCase.Create")
+	defer span.End()
 
-	validationErrors := validateTask(ctx, task)
-	if len(validationErrors) > 0 {
-		return nil, httpErrors.NewValidationError(validationErrors)
+	err := t.Validate(ctx, task)
+	if err != nil {
+		return nil, err
 	}
 
-	create, err := t.repo.Create(ctx, task)
+	task.TraceId, task.SpanId, task.TraceSampled = tracing.Ids(ctx)
+	task.Client = auth.ClientFromContext(ctx)
+	create, replayed, err := t.repo.Create(ctx, task)
 	if err != nil {
+		if errors.Is(err, models.ErrIdempotencyKeyConflict) {
+			return nil, httpErrors.NewRestError(http.StatusConflict, models.ErrIdempotencyKeyConflict.Error(), err)
+		}
 		return nil, err
 	}
+	if replayed {
+		return create, nil
+	}
 
-	go t.exec.ExecuteTask(*create)
+	metrics.TasksCreated.Inc()
+	t.events.Publish(models.NewTaskEvent(create))
+	if create.Status != models.StatusScheduled {
+		t.pool.Notify()
+	}
 
 	return create, nil
 }
 
+func (t *TaskUseCase) CreateBatch(ctx context.Context, tasks []models.Task, track bool) (*models.Batch, error) {
+	ctx, span := tracing.Start(ctx, "TaskUseCase.CreateBatch")
+	defer span.End()
+
+	if len(tasks) == 0 {
+		return nil, httpErrors.NewBadRequestError(errors.New("batch is empty"))
+	}
+	if t.cfg.MaxBatchSize > 0 && len(tasks) > t.cfg.MaxBatchSize {
+		return nil, httpErrors.NewBadRequestError(fmt.Errorf("batch exceeds %d tasks", t.cfg.MaxBatchSize))
+	}
+
+	itemErrors := make([]httpErrors.ItemError, 0)
+	for i := range tasks {
+		err := t.Validate(ctx, &tasks[i])
+		if err != nil {
+			itemErrors = append(itemErrors, httpErrors.NewItemError(i, err))
+		}
+	}
+	if len(itemErrors) > 0 {
+		return nil, httpErrors.NewBatchValidationError(itemErrors)
+	}
+
+	traceId, spanId, sampled := tracing.Ids(ctx)
+	client := auth.ClientFromContext(ctx)
+	for i := range tasks {
+		tasks[i].TraceId, tasks[i].SpanId, tasks[i].TraceSampled = traceId, spanId, sampled
+		tasks[i].Client = client
+	}
+
+	batch, err := t.repo.CreateBatch(ctx, tasks, track)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics.TasksCreated.Add(float64(len(tasks)))
+	for i := range tasks {
+		t.events.Publish(models.NewTaskEvent(&tasks[i]))
+	}
+	if slices.ContainsFunc(tasks, func(task models.Task) bool { return task.Status != models.StatusScheduled }) {
+		t.pool.Notify()
+	}
+
+	return batch, nil
+}
+
+func (t *TaskUseCase) GetBatchProgress(ctx context.Context, id int64) (*models.BatchProgress, error) {
+	ctx, span := tracing.Start(ctx, "TaskUseCase.GetBatchProgress")
+	defer span.End()
+
+	if id <= 0 {
+		return nil, httpErrors.NewBadRequestError(errors.New("invalid id"))
+	}
+
+	progress, err := t.repo.GetBatchProgress(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !auth.Owns(auth.ClientFromContext(ctx), progress.Client) {
+		return nil, sql.ErrNoRows
+	}
+	return progress, nil
+}
+
+func (t *TaskUseCase) Validate(ctx context.Context, task *models.Task) error {
+	ctx, span := tracing.Start(ctx, "TaskUseCase.Validate")
+	defer span.End()
+
+	validationErrors := t.validateTask(ctx, task)
+	if len(validationErrors) > 0 {
+		return httpErrors.NewValidationError(validationErrors)
+	}
+	return nil
+}
+
 func (t *TaskUseCase) GetByIdWithOutputHeaders(ctx context.Context, id int64) (*models.Task, error) {
+	ctx, span := tracing.Start(ctx, "TaskUseCase.GetByIdWithOutputHeaders")
+	defer span.End()
+
 	if id <= 0 {
 		return nil, httpErrors.NewBadRequestError(errors.New("invalid id"))
 	}
@@ -48,10 +159,153 @@ func (t *TaskUseCase) GetByIdWithOutputHeaders(ctx context.Context, id int64) (*
 	if err != nil {
 		return nil, err
 	}
+	if !auth.Owns(auth.ClientFromContext(ctx), task.Client) {
+		return nil, sql.ErrNoRows
+	}
 	return task, nil
 }
 
-func validateTask(ctx context.Context, task *models.Task) []validation.ValidationError {
+func (t *TaskUseCase) GetResponse(ctx context.Context, id int64) (*models.TaskResponse, error) {
+	ctx, span := tracing.Start(ctx, "TaskUseCase.GetResponse")
+	defer span.End()
+
+	if id <= 0 {
+		return nil, httpErrors.NewBadRequestError(errors.New("invalid id"))
+	}
+
+	err := t.authorize(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := t.repo.GetResponse(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func (t *TaskUseCase) GetAttempts(ctx context.Context, id int64) ([]models.TaskAttempt, error) {
+	ctx, span := tracing.Start(ctx, "TaskUseCase.GetAttempts")
+	defer span.End()
+
+	if id <= 0 {
+		return nil, httpErrors.NewBadRequestError(errors.New("invalid id"))
+	}
+
+	err := t.authorize(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	attempts, err := t.repo.GetAttempts(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return attempts, nil
+}
+
+func (t *TaskUseCase) Cancel(ctx context.Context, id int64) (string, error) {
+	ctx, span := tracing.Start(ctx, "TaskUseCase.Cancel")
+	defer span.End()
+
+	if id <= 0 {
+		return "", httpErrors.NewBadRequestError(errors.New("invalid id"))
+	}
+
+	err := t.authorize(ctx, id)
+	if err != nil {
+		return "", err
+	}
+
+	previousStatus, err := t.repo.Cancel(ctx, id)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidStatusTransition) {
+			return "", httpErrors.NewRestError(http.StatusConflict, err.Error(), err)
+		}
+		return "", err
+	}
+
+	metrics.TasksFinished.WithLabelValues(models.StatusCancelled).Inc()
+	// Authorized requests only cancel their own tasks, so the client is the owner.
+	t.events.Publish(models.TaskEvent{TaskId: id, Status: models.StatusCancelled, At: time.Now(), Client: auth.ClientFromContext(ctx)})
+	if previousStatus == models.StatusInProcess && !t.exec.Cancel(id) {
+		t.log.Infof("TaskUseCase.Cancel: task %v is not running on this instance", id)
+	}
+	return previousStatus, nil
+}
+
+func (t *TaskUseCase) List(ctx context.Context, filter models.TaskFilter) (*models.TaskPage, error) {
+	ctx, span := tracing.Start(ctx, "TaskUseCase.List")
+	defer span.End()
+
+	if filter.Limit == 0 {
+		filter.Limit = defaultListLimit
+	}
+	if filter.Limit < 0 || filter.Limit > maxListLimit {
+		return nil, httpErrors.NewBadRequestError(fmt.Errorf("limit must be between 1 and %d", maxListLimit))
+	}
+	if filter.SortBy == "" {
+		filter.SortBy = models.SortByCreatedAt
+	}
+	if filter.SortBy != models.SortByCreatedAt && filter.SortBy != models.SortById {
+		return nil, httpErrors.NewBadRequestError(errors.New("invalid sort field"))
+	}
+	for _, status := range filter.Statuses {
+		if !slices.Contains(models.Statuses, status) {
+			return nil, httpErrors.NewBadRequestError(fmt.Errorf("invalid status %s", status))
+		}
+	}
+
+	filter.Client = auth.ClientFromContext(ctx)
+	limit := filter.Limit
+	filter.Limit = limit + 1
+	found, err := t.repo.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.TaskPage{Tasks: found}
+	if len(found) > limit {
+		page.Tasks = found[:limit]
+		last := page.Tasks[limit-1]
+		page.Next = &models.TaskCursor{Id: last.Id, CreatedAt: last.CreatedAt}
+	}
+	return page, nil
+}
+
+func (t *TaskUseCase) Subscribe(ctx context.Context, filter models.TaskEventFilter) (<-chan models.TaskEvent, func(), error) {
+	for _, status := range filter.Statuses {
+		if !slices.Contains(models.Statuses, status) {
+			return nil, nil, httpErrors.NewBadRequestError(fmt.Errorf("invalid status %s", status))
+		}
+	}
+
+	filter.Client = auth.ClientFromContext(ctx)
+	events, unsubscribe := t.events.Subscribe(filter)
+	return events, unsubscribe, nil
+}
+
+// authorize hides the tasks of other clients behind not found, so their ids can't be probed.
+func (t *TaskUseCase) authorize(ctx context.Context, id int64) error {
+	client := auth.ClientFromContext(ctx)
+	if client == nil {
+		return nil
+	}
+	owner, err := t.repo.GetClient(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !auth.Owns(client, owner) {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (t *TaskUseCase) validateTask(ctx context.Context, task *models.Task) []validation.ValidationError {
+	ctx, span := tracing.Start(ctx, "TaskUseCase.validateTask")
+	defer span.End()
+
 	errors := make([]validation.ValidationError, 0)
 	err := utils.ValidateStruct(ctx, task)
 	if err != nil {
@@ -64,5 +318,41 @@ func validateTask(ctx context.Context, task *models.Task) []validation.Validatio
 	if errMethod != nil {
 		errors = append(errors, errMethod)
 	}
+	errBody := validateBody(task, t.cfg.MaxRequestBodySize)
+	if errBody != nil {
+		errors = append(errors, errBody)
+	}
+	if task.TlsProfile != nil {
+		if _, ok := t.cfg.HttpClient.TLSProfiles[*task.TlsProfile]; !ok {
+			errors = append(errors, validation.CustomFiledError{Fld: "TlsProfile", Msg: fmt.Sprintf("unknown tls profile %s", *task.TlsProfile), Tag: "tls-profile"})
+		}
+	}
+	err = t.egress.CheckURL(ctx, task.Url)
+	if err != nil {
+		errors = append(errors, validation.CustomFiledError{Fld: "Url", Msg: err.Error(), Tag: "egress"})
+	}
+	if task.Callback != nil {
+		err = t.egress.CheckURL(ctx, task.Callback.Url)
+		if err != nil {
+			errors = append(errors, validation.CustomFiledError{Fld: "Callback.Url", Msg: err.Error(), Tag: "egress"})
+		}
+	}
 	return errors
 }
+
+func validateBody(task *models.Task, maxSize int64) validation.ValidationError {
+	if task.Body == "" {
+		return nil
+	}
+	body, err := task.DecodedBody()
+	if err != nil {
+		return validation.CustomFiledError{Fld: "Body", Msg: "invalid base64 body", Tag: "base64"}
+	}
+	if maxSize > 0 && int64(len(body)) > maxSize {
+		return validation.CustomFiledError{Fld: "Body", Msg: fmt.Sprintf("body exceeds %d bytes", maxSize), Tag: "max"}
+	}
+	if task.BodyEncoding == models.BodyEncodingJSON && !json.Valid(body) {
+		return validation.CustomFiledError{Fld: "Body", Msg: "invalid json body", Tag: "json"}
+	}
+	return nil
+}
@@ -2,31 +2,40 @@ package usecase
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"http-task-executor/internal/auth"
+	"http-task-executor/internal/config"
+	"http-task-executor/internal/egress"
 	"http-task-executor/internal/models"
+	"http-task-executor/internal/tasks/events"
 	"http-task-executor/internal/tasks/mock"
+	"http-task-executor/internal/tracing"
 	errorsHttp "http-task-executor/pkg/errors/http"
 	"net/http"
 	"testing"
 	"time"
 )
 
+const maxRequestBodySize = 16
+
 func TestTaskUseCase_Create(t *testing.T) {
 	t.Parallel()
 	ctrx := gomock.NewController(t)
 	defer ctrx.Finish()
 
 	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+	cfg := &config.Config{MaxRequestBodySize: maxRequestBodySize}
 
 	mockTasksRepo := mock.NewMockRepository(ctrx)
-	mockExecutor := mock.NewMockExecutor(ctrx)
+	mockPool := mock.NewMockPool(ctrx)
 
-	useCase := NewTaskUseCase(sugar, mockTasksRepo, mockExecutor)
+	useCase := NewTaskUseCase(cfg, sugar, mockTasksRepo, mockPool, mock.NewMockExecutor(ctrx), events.NewBus(sugar, 0), nil)
 
 	task := &models.Task{
 		Method: "GET",
@@ -36,37 +45,94 @@ func TestTaskUseCase_Create(t *testing.T) {
 
 	ctx := context.Background()
 
-	mockTasksRepo.EXPECT().Create(ctx, gomock.Eq(task)).Return(task, nil).Times(1)
-	called := make(chan struct{}, 1)
-	mockExecutor.EXPECT().ExecuteTask(gomock.Any()).Do(func(task models.Task) {
-		called <- struct{}{}
-	}).Times(1)
+	mockTasksRepo.EXPECT().Create(gomock.Any(), gomock.Eq(task)).Return(task, false, nil).Times(1)
+	mockPool.EXPECT().Notify().Times(1)
 
 	create, err := useCase.Create(ctx, task)
 
 	require.NoError(t, err)
 	require.Nil(t, err)
 	require.NotNil(t, create)
+}
+
+func TestTaskUseCase_CreateStoresTraceId(t *testing.T) {
+	t.Parallel()
+	ctrx := gomock.NewController(t)
+	defer ctrx.Finish()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+	cfg := &config.Config{MaxRequestBodySize: maxRequestBodySize}
+
+	mockTasksRepo := mock.NewMockRepository(ctrx)
+	mockPool := mock.NewMockPool(ctrx)
+
+	useCase := NewTaskUseCase(cfg, sugar, mockTasksRepo, mockPool, mock.NewMockExecutor(ctrx), events.NewBus(sugar, 0), nil)
+
+	task := &models.Task{
+		Method: "GET",
+		Url:    "https://www.google.com",
+		Status: models.StatusNew,
+	}
+
+	traceId := "4bf92f3577b34da6a3ce929d0e0e4736"
+	spanId := "00f067aa0ba902b7"
+	ctx := tracing.WithParent(context.Background(), &traceId, &spanId, true)
 
-	select {
-	case <-called:
-	case <-time.After(500 * time.Millisecond):
-		t.Fatal("Expected ExecuteTask to be called in goroutine")
+	mockTasksRepo.EXPECT().Create(gomock.Any(), gomock.Cond(func(x *models.Task) bool {
+		return x.TraceId != nil && *x.TraceId == traceId && x.SpanId != nil && x.TraceSampled
+	})).Return(task, false, nil).Times(1)
+	mockPool.EXPECT().Notify().Times(1)
+
+	_, err := useCase.Create(ctx, task)
+
+	require.NoError(t, err)
+}
+
+func TestTaskUseCase_CreateScheduledNotNotifyPool(t *testing.T) {
+	t.Parallel()
+	ctrx := gomock.NewController(t)
+	defer ctrx.Finish()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+	cfg := &config.Config{MaxRequestBodySize: maxRequestBodySize}
+
+	mockTasksRepo := mock.NewMockRepository(ctrx)
+	mockPool := mock.NewMockPool(ctrx)
+
+	useCase := NewTaskUseCase(cfg, sugar, mockTasksRepo, mockPool, mock.NewMockExecutor(ctrx), events.NewBus(sugar, 0), nil)
+
+	runAt := time.Now().Add(time.Hour)
+	task := &models.Task{
+		Method: "GET",
+		Url:    "https://www.google.com",
+		Status: models.StatusScheduled,
+		RunAt:  &runAt,
 	}
+
+	ctx := context.Background()
+
+	mockTasksRepo.EXPECT().Create(gomock.Any(), gomock.Eq(task)).Return(task, false, nil).Times(1)
+	mockPool.EXPECT().Notify().Times(0)
+
+	create, err := useCase.Create(ctx, task)
+
+	require.NoError(t, err)
+	require.Equal(t, models.StatusScheduled, create.Status)
 }
 
-func TestTaskUseCase_CreateWithErrorsNotExecuteTask(t *testing.T) {
+func TestTaskUseCase_CreateWithErrorsNotNotifyPool(t *testing.T) {
 	t.Parallel()
 
 	ctrx := gomock.NewController(t)
 	defer ctrx.Finish()
 
 	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+	cfg := &config.Config{MaxRequestBodySize: maxRequestBodySize}
 
 	mockTasksRepo := mock.NewMockRepository(ctrx)
-	mockExecutor := mock.NewMockExecutor(ctrx)
+	mockPool := mock.NewMockPool(ctrx)
 
-	useCase := NewTaskUseCase(sugar, mockTasksRepo, mockExecutor)
+	useCase := NewTaskUseCase(cfg, sugar, mockTasksRepo, mockPool, mock.NewMockExecutor(ctrx), events.NewBus(sugar, 0), nil)
 
 	task := &models.Task{
 		Method: "GET",
@@ -76,36 +142,28 @@ func TestTaskUseCase_CreateWithErrorsNotExecuteTask(t *testing.T) {
 
 	ctx := context.Background()
 
-	mockTasksRepo.EXPECT().Create(ctx, gomock.Eq(task)).Return(nil, errors.New("error"))
-	called := make(chan struct{}, 1)
-	mockExecutor.EXPECT().ExecuteTask(gomock.Any()).Do(func(task models.Task) {
-		called <- struct{}{}
-	}).Times(0)
+	mockTasksRepo.EXPECT().Create(gomock.Any(), gomock.Eq(task)).Return(nil, false, errors.New("error"))
+	mockPool.EXPECT().Notify().Times(0)
 
 	create, err := useCase.Create(ctx, task)
 
 	require.Error(t, err)
 	require.Nil(t, create)
-
-	select {
-	case <-called:
-		t.Fatal("Expected ExecuteTask to NOT called in goroutine")
-	case <-time.After(500 * time.Millisecond):
-	}
 }
 
-func TestTaskUseCase_CreateWithInvalidMethodNotExecuteTask(t *testing.T) {
+func TestTaskUseCase_CreateWithInvalidMethodNotNotifyPool(t *testing.T) {
 	t.Parallel()
 
 	ctrx := gomock.NewController(t)
 	defer ctrx.Finish()
 
 	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+	cfg := &config.Config{MaxRequestBodySize: maxRequestBodySize}
 
 	mockTasksRepo := mock.NewMockRepository(ctrx)
-	mockExecutor := mock.NewMockExecutor(ctrx)
+	mockPool := mock.NewMockPool(ctrx)
 
-	useCase := NewTaskUseCase(sugar, mockTasksRepo, mockExecutor)
+	useCase := NewTaskUseCase(cfg, sugar, mockTasksRepo, mockPool, mock.NewMockExecutor(ctrx), events.NewBus(sugar, 0), nil)
 
 	task := &models.Task{
 		Method: "tersfasd",
@@ -115,11 +173,8 @@ func TestTaskUseCase_CreateWithInvalidMethodNotExecuteTask(t *testing.T) {
 
 	ctx := context.Background()
 
-	mockTasksRepo.EXPECT().Create(ctx, gomock.Eq(task)).Times(0)
-	called := make(chan struct{}, 1)
-	mockExecutor.EXPECT().ExecuteTask(gomock.Any()).Do(func(task models.Task) {
-		called <- struct{}{}
-	}).Times(0)
+	mockTasksRepo.EXPECT().Create(gomock.Any(), gomock.Eq(task)).Times(0)
+	mockPool.EXPECT().Notify().Times(0)
 
 	create, err := useCase.Create(ctx, task)
 
@@ -127,26 +182,21 @@ func TestTaskUseCase_CreateWithInvalidMethodNotExecuteTask(t *testing.T) {
 	require.Nil(t, create)
 	require.NotEmpty(t, err.(errorsHttp.RestError))
 	require.Equal(t, err.(errorsHttp.RestError).ErrStatus, http.StatusBadRequest)
-
-	select {
-	case <-called:
-		t.Fatal("Expected ExecuteTask to NOT called in goroutine")
-	case <-time.After(500 * time.Millisecond):
-	}
 }
 
-func TestTaskUseCase_CreateWithInvalidUrlNotExecuteTask(t *testing.T) {
+func TestTaskUseCase_CreateWithInvalidUrlNotNotifyPool(t *testing.T) {
 	t.Parallel()
 
 	ctrx := gomock.NewController(t)
 	defer ctrx.Finish()
 
 	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+	cfg := &config.Config{MaxRequestBodySize: maxRequestBodySize}
 
 	mockTasksRepo := mock.NewMockRepository(ctrx)
-	mockExecutor := mock.NewMockExecutor(ctrx)
+	mockPool := mock.NewMockPool(ctrx)
 
-	useCase := NewTaskUseCase(sugar, mockTasksRepo, mockExecutor)
+	useCase := NewTaskUseCase(cfg, sugar, mockTasksRepo, mockPool, mock.NewMockExecutor(ctrx), events.NewBus(sugar, 0), nil)
 
 	task := &models.Task{
 		Method: "GET",
@@ -156,11 +206,8 @@ func TestTaskUseCase_CreateWithInvalidUrlNotExecuteTask(t *testing.T) {
 
 	ctx := context.Background()
 
-	mockTasksRepo.EXPECT().Create(ctx, gomock.Eq(task)).Times(0)
-	called := make(chan struct{}, 1)
-	mockExecutor.EXPECT().ExecuteTask(gomock.Any()).Do(func(task models.Task) {
-		called <- struct{}{}
-	}).Times(0)
+	mockTasksRepo.EXPECT().Create(gomock.Any(), gomock.Eq(task)).Times(0)
+	mockPool.EXPECT().Notify().Times(0)
 
 	create, err := useCase.Create(ctx, task)
 
@@ -168,12 +215,210 @@ func TestTaskUseCase_CreateWithInvalidUrlNotExecuteTask(t *testing.T) {
 	require.Nil(t, create)
 	require.NotEmpty(t, err.(errorsHttp.RestError))
 	require.Equal(t, err.(errorsHttp.RestError).ErrStatus, http.StatusBadRequest)
+}
+
+func TestTaskUseCase_CreateWithDeniedUrlNotNotifyPool(t *testing.T) {
+	t.Parallel()
+
+	ctrx := gomock.NewController(t)
+	defer ctrx.Finish()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+	cfg := &config.Config{MaxRequestBodySize: maxRequestBodySize}
+
+	mockTasksRepo := mock.NewMockRepository(ctrx)
+	mockPool := mock.NewMockPool(ctrx)
+
+	policy, err := egress.NewPolicy(config.EgressConfig{Schemes: []string{"http", "https"}})
+	require.NoError(t, err)
+	useCase := NewTaskUseCase(cfg, sugar, mockTasksRepo, mockPool, mock.NewMockExecutor(ctrx), events.NewBus(sugar, 0), policy)
+
+	mockTasksRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
+	mockPool.EXPECT().Notify().Times(0)
+
+	for _, url := range []string{"http://169.254.169.254/latest/meta-data/", "http://localhost:8081/tasks", "http://10.0.0.5/", "ftp://www.google.com/"} {
+		t.Run(url, func(t *testing.T) {
+			create, err := useCase.Create(context.Background(), &models.Task{Method: "GET", Url: url, Status: models.StatusNew})
+
+			require.Error(t, err)
+			require.Nil(t, create)
+			require.Equal(t, http.StatusBadRequest, err.(errorsHttp.RestError).ErrStatus)
+		})
+	}
+
+	t.Run("Callback", func(t *testing.T) {
+		create, err := useCase.Create(context.Background(), &models.Task{
+			Method:   "GET",
+			Url:      "https://93.184.215.14/",
+			Status:   models.StatusNew,
+			Callback: &models.Callback{Url: "http://127.0.0.1:9000/hook"},
+		})
+
+		require.Error(t, err)
+		require.Nil(t, create)
+	})
+}
+
+func TestTaskUseCase_CreateWithTLSProfile(t *testing.T) {
+	t.Parallel()
+
+	ctrx := gomock.NewController(t)
+	defer ctrx.Finish()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+	cfg := &config.Config{MaxRequestBodySize: maxRequestBodySize}
+	cfg.HttpClient.TLSProfiles = map[string]config.TLSConfig{"partner-mtls": {}}
+
+	mockTasksRepo := mock.NewMockRepository(ctrx)
+	mockPool := mock.NewMockPool(ctrx)
+
+	useCase := NewTaskUseCase(cfg, sugar, mockTasksRepo, mockPool, mock.NewMockExecutor(ctrx), events.NewBus(sugar, 0), nil)
+
+	t.Run("Known profile", func(t *testing.T) {
+		profile := "partner-mtls"
+		task := &models.Task{Method: "GET", Url: "https://www.google.com", Status: models.StatusNew, TlsProfile: &profile}
+
+		mockTasksRepo.EXPECT().Create(gomock.Any(), task).Return(task, false, nil).Times(1)
+		mockPool.EXPECT().Notify().Times(1)
+
+		_, err := useCase.Create(context.Background(), task)
+		require.NoError(t, err)
+	})
+
+	t.Run("Unknown profile", func(t *testing.T) {
+		profile := "other"
+		task := &models.Task{Method: "GET", Url: "https://www.google.com", Status: models.StatusNew, TlsProfile: &profile}
+
+		mockTasksRepo.EXPECT().Create(gomock.Any(), task).Times(0)
+
+		create, err := useCase.Create(context.Background(), task)
+		require.Error(t, err)
+		require.Nil(t, create)
+		require.Equal(t, http.StatusBadRequest, err.(errorsHttp.RestError).ErrStatus)
+	})
+}
+
+func TestTaskUseCase_CreateWithInvalidBodyNotNotifyPool(t *testing.T) {
+	t.Parallel()
+
+	ctrx := gomock.NewController(t)
+	defer ctrx.Finish()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+	cfg := &config.Config{MaxRequestBodySize: maxRequestBodySize}
+
+	mockTasksRepo := mock.NewMockRepository(ctrx)
+	mockPool := mock.NewMockPool(ctrx)
+
+	useCase := NewTaskUseCase(cfg, sugar, mockTasksRepo, mockPool, mock.NewMockExecutor(ctrx), events.NewBus(sugar, 0), nil)
+
+	mockTasksRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
+	mockPool.EXPECT().Notify().Times(0)
+
+	bodies := map[string]struct {
+		body     string
+		encoding string
+	}{
+		"Too large body":    {body: "12345678901234567", encoding: models.BodyEncodingText},
+		"Invalid base64":    {body: "not base64!", encoding: models.BodyEncodingBase64},
+		"Invalid json":      {body: `{"key":`, encoding: models.BodyEncodingJSON},
+		"Unknown encoding":  {body: "text", encoding: "xml"},
+		"Too large decoded": {body: "MTIzNDU2Nzg5MDEyMzQ1Njc=", encoding: models.BodyEncodingBase64},
+	}
+
+	for name, body := range bodies {
+		t.Run(name, func(t *testing.T) {
+			task := &models.Task{
+				Method:       "POST",
+				Url:          "https://www.google.com",
+				Status:       models.StatusNew,
+				Body:         body.body,
+				BodyEncoding: body.encoding,
+			}
+
+			create, err := useCase.Create(context.Background(), task)
+
+			require.Error(t, err)
+			require.Nil(t, create)
+			require.Equal(t, err.(errorsHttp.RestError).ErrStatus, http.StatusBadRequest)
+		})
+	}
+}
+
+func TestTaskUseCase_CreateWithInvalidRetryPolicyNotNotifyPool(t *testing.T) {
+	t.Parallel()
+
+	ctrx := gomock.NewController(t)
+	defer ctrx.Finish()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+	cfg := &config.Config{MaxRequestBodySize: maxRequestBodySize}
+
+	mockTasksRepo := mock.NewMockRepository(ctrx)
+	mockPool := mock.NewMockPool(ctrx)
+
+	useCase := NewTaskUseCase(cfg, sugar, mockTasksRepo, mockPool, mock.NewMockExecutor(ctrx), events.NewBus(sugar, 0), nil)
+
+	mockTasksRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
+	mockPool.EXPECT().Notify().Times(0)
+
+	policies := map[string]*models.RetryPolicy{
+		"No attempts":         {MaxAttempts: 0, BackoffBaseMs: 100, BackoffMaxMs: 100},
+		"Max below base":      {MaxAttempts: 3, BackoffBaseMs: 1000, BackoffMaxMs: 100},
+		"Jitter out of range": {MaxAttempts: 3, BackoffBaseMs: 100, BackoffMaxMs: 100, Jitter: 2},
+		"Invalid status code": {MaxAttempts: 3, BackoffBaseMs: 100, BackoffMaxMs: 100, RetryOnStatus: []int{42}},
+		"Unknown error class": {MaxAttempts: 3, BackoffBaseMs: 100, BackoffMaxMs: 100, RetryOnErrors: []string{"oops"}},
+	}
+
+	for name, policy := range policies {
+		t.Run(name, func(t *testing.T) {
+			task := &models.Task{
+				Method:      "GET",
+				Url:         "https://www.google.com",
+				Status:      models.StatusNew,
+				RetryPolicy: policy,
+			}
+
+			create, err := useCase.Create(context.Background(), task)
+
+			require.Error(t, err)
+			require.Nil(t, create)
+			require.Equal(t, err.(errorsHttp.RestError).ErrStatus, http.StatusBadRequest)
+		})
+	}
+}
 
-	select {
-	case <-called:
-		t.Fatal("Expected ExecuteTask to NOT called in goroutine")
-	case <-time.After(500 * time.Millisecond):
+func TestTaskUseCase_CreateWithBody(t *testing.T) {
+	t.Parallel()
+
+	ctrx := gomock.NewController(t)
+	defer ctrx.Finish()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+	cfg := &config.Config{MaxRequestBodySize: maxRequestBodySize}
+
+	mockTasksRepo := mock.NewMockRepository(ctrx)
+	mockPool := mock.NewMockPool(ctrx)
+
+	useCase := NewTaskUseCase(cfg, sugar, mockTasksRepo, mockPool, mock.NewMockExecutor(ctrx), events.NewBus(sugar, 0), nil)
+
+	task := &models.Task{
+		Method:       "POST",
+		Url:          "https://www.google.com",
+		Status:       models.StatusNew,
+		Body:         `{"key":"value"}`,
+		BodyEncoding: models.BodyEncodingJSON,
 	}
+
+	ctx := context.Background()
+
+	mockTasksRepo.EXPECT().Create(gomock.Any(), gomock.Eq(task)).Return(task, false, nil).Times(1)
+	mockPool.EXPECT().Notify().Times(1)
+
+	create, err := useCase.Create(ctx, task)
+
+	require.NoError(t, err)
+	require.NotNil(t, create)
 }
 
 func TestTaskUseCase_GetByIdWithOutputHeadersInvalidId(t *testing.T) {
@@ -183,17 +428,18 @@ func TestTaskUseCase_GetByIdWithOutputHeadersInvalidId(t *testing.T) {
 	defer ctrx.Finish()
 
 	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+	cfg := &config.Config{MaxRequestBodySize: maxRequestBodySize}
 
 	mockTasksRepo := mock.NewMockRepository(ctrx)
-	mockExecutor := mock.NewMockExecutor(ctrx)
+	mockPool := mock.NewMockPool(ctrx)
 
-	useCase := NewTaskUseCase(sugar, mockTasksRepo, mockExecutor)
+	useCase := NewTaskUseCase(cfg, sugar, mockTasksRepo, mockPool, mock.NewMockExecutor(ctrx), events.NewBus(sugar, 0), nil)
 
 	id := int64(-1)
 
 	ctx := context.Background()
 
-	mockTasksRepo.EXPECT().GetByIdWithOutputHeaders(ctx, id).Times(0)
+	mockTasksRepo.EXPECT().GetByIdWithOutputHeaders(gomock.Any(), id).Times(0)
 
 	task, err := useCase.GetByIdWithOutputHeaders(ctx, id)
 
@@ -204,6 +450,80 @@ func TestTaskUseCase_GetByIdWithOutputHeadersInvalidId(t *testing.T) {
 
 }
 
+func TestTaskUseCase_ClientScope(t *testing.T) {
+	t.Parallel()
+
+	ctrx := gomock.NewController(t)
+	defer ctrx.Finish()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+	cfg := &config.Config{MaxRequestBodySize: maxRequestBodySize}
+
+	mockTasksRepo := mock.NewMockRepository(ctrx)
+	mockPool := mock.NewMockPool(ctrx)
+
+	useCase := NewTaskUseCase(cfg, sugar, mockTasksRepo, mockPool, mock.NewMockExecutor(ctrx), events.NewBus(sugar, 0), nil)
+
+	owner := "billing"
+	other := "reports"
+	ctx := auth.WithClient(context.Background(), owner)
+
+	t.Run("Created task is owned by client", func(t *testing.T) {
+		task := &models.Task{Method: "GET", Url: "https://www.google.com", Status: models.StatusNew}
+
+		mockTasksRepo.EXPECT().Create(gomock.Any(), gomock.Cond(func(x *models.Task) bool {
+			return x.Client != nil && *x.Client == owner
+		})).Return(task, false, nil).Times(1)
+		mockPool.EXPECT().Notify().Times(1)
+
+		_, err := useCase.Create(ctx, task)
+
+		require.NoError(t, err)
+	})
+
+	t.Run("Own task is found", func(t *testing.T) {
+		mockTasksRepo.EXPECT().GetByIdWithOutputHeaders(gomock.Any(), int64(1)).Return(&models.Task{Id: 1, Client: &owner}, nil).Times(1)
+
+		task, err := useCase.GetByIdWithOutputHeaders(ctx, 1)
+
+		require.NoError(t, err)
+		require.Equal(t, int64(1), task.Id)
+	})
+
+	t.Run("Task of other client is not found", func(t *testing.T) {
+		mockTasksRepo.EXPECT().GetByIdWithOutputHeaders(gomock.Any(), int64(2)).Return(&models.Task{Id: 2, Client: &other}, nil).Times(1)
+
+		_, err := useCase.GetByIdWithOutputHeaders(ctx, 2)
+
+		require.ErrorIs(t, err, sql.ErrNoRows)
+	})
+
+	t.Run("Response of other client is not found", func(t *testing.T) {
+		mockTasksRepo.EXPECT().GetClient(gomock.Any(), int64(2)).Return(&other, nil).Times(1)
+		mockTasksRepo.EXPECT().GetResponse(gomock.Any(), gomock.Any()).Times(0)
+
+		_, err := useCase.GetResponse(ctx, 2)
+
+		require.ErrorIs(t, err, sql.ErrNoRows)
+	})
+
+	t.Run("Batch of other client is not found", func(t *testing.T) {
+		mockTasksRepo.EXPECT().GetBatchProgress(gomock.Any(), int64(5)).Return(&models.BatchProgress{Id: 5, Client: &other}, nil).Times(1)
+
+		_, err := useCase.GetBatchProgress(ctx, 5)
+
+		require.ErrorIs(t, err, sql.ErrNoRows)
+	})
+
+	t.Run("Unauthenticated request sees any task", func(t *testing.T) {
+		mockTasksRepo.EXPECT().GetByIdWithOutputHeaders(gomock.Any(), int64(2)).Return(&models.Task{Id: 2, Client: &other}, nil).Times(1)
+
+		_, err := useCase.GetByIdWithOutputHeaders(context.Background(), 2)
+
+		require.NoError(t, err)
+	})
+}
+
 func TestTaskUseCase_GetByIdWithOutputHeadersValidId(t *testing.T) {
 	t.Parallel()
 
@@ -211,11 +531,12 @@ func TestTaskUseCase_GetByIdWithOutputHeadersValidId(t *testing.T) {
 	defer ctrx.Finish()
 
 	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+	cfg := &config.Config{MaxRequestBodySize: maxRequestBodySize}
 
 	mockTasksRepo := mock.NewMockRepository(ctrx)
-	mockExecutor := mock.NewMockExecutor(ctrx)
+	mockPool := mock.NewMockPool(ctrx)
 
-	useCase := NewTaskUseCase(sugar, mockTasksRepo, mockExecutor)
+	useCase := NewTaskUseCase(cfg, sugar, mockTasksRepo, mockPool, mock.NewMockExecutor(ctrx), events.NewBus(sugar, 0), nil)
 
 	id := int64(15)
 
@@ -228,7 +549,7 @@ func TestTaskUseCase_GetByIdWithOutputHeadersValidId(t *testing.T) {
 
 	ctx := context.Background()
 
-	mockTasksRepo.EXPECT().GetByIdWithOutputHeaders(ctx, id).Return(task, nil).Times(1)
+	mockTasksRepo.EXPECT().GetByIdWithOutputHeaders(gomock.Any(), id).Return(task, nil).Times(1)
 
 	returnedTask, err := useCase.GetByIdWithOutputHeaders(ctx, id)
 
@@ -239,3 +560,361 @@ func TestTaskUseCase_GetByIdWithOutputHeadersValidId(t *testing.T) {
 	assert.Equal(t, task.Method, returnedTask.Method)
 	assert.Equal(t, task.Url, returnedTask.Url)
 }
+
+func TestTaskUseCase_GetAttemptsInvalidId(t *testing.T) {
+	t.Parallel()
+
+	ctrx := gomock.NewController(t)
+	defer ctrx.Finish()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+	cfg := &config.Config{MaxRequestBodySize: maxRequestBodySize}
+
+	mockTasksRepo := mock.NewMockRepository(ctrx)
+	mockPool := mock.NewMockPool(ctrx)
+
+	useCase := NewTaskUseCase(cfg, sugar, mockTasksRepo, mockPool, mock.NewMockExecutor(ctrx), events.NewBus(sugar, 0), nil)
+
+	mockTasksRepo.EXPECT().GetAttempts(gomock.Any(), gomock.Any()).Times(0)
+
+	attempts, err := useCase.GetAttempts(context.Background(), 0)
+
+	require.Error(t, err)
+	require.Nil(t, attempts)
+	require.Equal(t, err.(errorsHttp.RestError).ErrStatus, http.StatusBadRequest)
+}
+
+func TestTaskUseCase_List(t *testing.T) {
+	t.Parallel()
+
+	ctrx := gomock.NewController(t)
+	defer ctrx.Finish()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+	cfg := &config.Config{MaxRequestBodySize: maxRequestBodySize}
+
+	mockTasksRepo := mock.NewMockRepository(ctrx)
+	mockPool := mock.NewMockPool(ctrx)
+
+	useCase := NewTaskUseCase(cfg, sugar, mockTasksRepo, mockPool, mock.NewMockExecutor(ctrx), events.NewBus(sugar, 0), nil)
+
+	ctx := context.Background()
+	createdAt := time.Now()
+
+	t.Run("Last page", func(t *testing.T) {
+		found := []models.Task{{Id: 1, CreatedAt: createdAt}, {Id: 2, CreatedAt: createdAt}}
+
+		mockTasksRepo.EXPECT().List(gomock.Any(), gomock.Cond(func(x models.TaskFilter) bool {
+			return x.Limit == 3 && x.SortBy == models.SortByCreatedAt
+		})).Return(found, nil).Times(1)
+
+		page, err := useCase.List(ctx, models.TaskFilter{Limit: 2})
+
+		require.NoError(t, err)
+		require.Len(t, page.Tasks, 2)
+		require.Nil(t, page.Next)
+	})
+
+	t.Run("Page with next cursor", func(t *testing.T) {
+		found := []models.Task{{Id: 3, CreatedAt: createdAt}, {Id: 2, CreatedAt: createdAt}, {Id: 1, CreatedAt: createdAt}}
+
+		mockTasksRepo.EXPECT().List(gomock.Any(), gomock.Cond(func(x models.TaskFilter) bool {
+			return x.Limit == 3 && x.SortBy == models.SortById
+		})).Return(found, nil).Times(1)
+
+		page, err := useCase.List(ctx, models.TaskFilter{Limit: 2, SortBy: models.SortById, SortDesc: true})
+
+		require.NoError(t, err)
+		require.Len(t, page.Tasks, 2)
+		require.NotNil(t, page.Next)
+		require.Equal(t, int64(2), page.Next.Id)
+		require.Equal(t, createdAt, page.Next.CreatedAt)
+	})
+
+	t.Run("Invalid filters", func(t *testing.T) {
+		filters := map[string]models.TaskFilter{
+			"Too large limit": {Limit: maxListLimit + 1},
+			"Unknown sort":    {SortBy: "url"},
+			"Unknown status":  {Statuses: []string{"unknown"}},
+		}
+		for name, filter := range filters {
+			page, err := useCase.List(ctx, filter)
+
+			require.Error(t, err, name)
+			require.Nil(t, page, name)
+			require.Equal(t, err.(errorsHttp.RestError).ErrStatus, http.StatusBadRequest, name)
+		}
+	})
+}
+
+func TestTaskUseCase_Cancel(t *testing.T) {
+	t.Parallel()
+
+	ctrx := gomock.NewController(t)
+	defer ctrx.Finish()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+	cfg := &config.Config{MaxRequestBodySize: maxRequestBodySize}
+
+	mockTasksRepo := mock.NewMockRepository(ctrx)
+	mockPool := mock.NewMockPool(ctrx)
+	mockExecutor := mock.NewMockExecutor(ctrx)
+
+	useCase := NewTaskUseCase(cfg, sugar, mockTasksRepo, mockPool, mockExecutor, events.NewBus(sugar, 0), nil)
+
+	ctx := context.Background()
+
+	t.Run("New task", func(t *testing.T) {
+		mockTasksRepo.EXPECT().Cancel(gomock.Any(), int64(1)).Return(models.StatusNew, nil).Times(1)
+		mockExecutor.EXPECT().Cancel(gomock.Any()).Times(0)
+
+		previousStatus, err := useCase.Cancel(ctx, 1)
+
+		require.NoError(t, err)
+		require.Equal(t, models.StatusNew, previousStatus)
+	})
+
+	t.Run("In process task is aborted", func(t *testing.T) {
+		mockTasksRepo.EXPECT().Cancel(gomock.Any(), int64(2)).Return(models.StatusInProcess, nil).Times(1)
+		mockExecutor.EXPECT().Cancel(int64(2)).Return(true).Times(1)
+
+		previousStatus, err := useCase.Cancel(ctx, 2)
+
+		require.NoError(t, err)
+		require.Equal(t, models.StatusInProcess, previousStatus)
+	})
+
+	t.Run("Finished task", func(t *testing.T) {
+		mockTasksRepo.EXPECT().Cancel(gomock.Any(), int64(3)).Return("", models.ErrInvalidStatusTransition).Times(1)
+
+		_, err := useCase.Cancel(ctx, 3)
+
+		require.Error(t, err)
+		require.Equal(t, http.StatusConflict, err.(errorsHttp.RestError).ErrStatus)
+	})
+
+	t.Run("Invalid id", func(t *testing.T) {
+		_, err := useCase.Cancel(ctx, 0)
+
+		require.Error(t, err)
+		require.Equal(t, http.StatusBadRequest, err.(errorsHttp.RestError).ErrStatus)
+	})
+}
+
+func TestTaskUseCase_CreateWithInvalidCallback(t *testing.T) {
+	t.Parallel()
+	ctrx := gomock.NewController(t)
+	defer ctrx.Finish()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+	cfg := &config.Config{MaxRequestBodySize: maxRequestBodySize}
+
+	mockTasksRepo := mock.NewMockRepository(ctrx)
+	mockPool := mock.NewMockPool(ctrx)
+
+	useCase := NewTaskUseCase(cfg, sugar, mockTasksRepo, mockPool, mock.NewMockExecutor(ctrx), events.NewBus(sugar, 0), nil)
+
+	task := &models.Task{
+		Method:   "GET",
+		Url:      "https://www.google.com",
+		Status:   models.StatusNew,
+		Callback: &models.Callback{Url: "not a url", Secret: "secret"},
+	}
+
+	mockTasksRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
+
+	_, err := useCase.Create(context.Background(), task)
+
+	require.Error(t, err)
+	require.Equal(t, http.StatusBadRequest, err.(errorsHttp.RestError).ErrStatus)
+}
+
+func TestTaskUseCase_CreateBatch(t *testing.T) {
+	t.Parallel()
+	ctrx := gomock.NewController(t)
+	defer ctrx.Finish()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+	cfg := &config.Config{MaxRequestBodySize: maxRequestBodySize, MaxBatchSize: 3}
+
+	mockTasksRepo := mock.NewMockRepository(ctrx)
+	mockPool := mock.NewMockPool(ctrx)
+
+	useCase := NewTaskUseCase(cfg, sugar, mockTasksRepo, mockPool, mock.NewMockExecutor(ctrx), events.NewBus(sugar, 0), nil)
+
+	ctx := context.Background()
+
+	t.Run("Created", func(t *testing.T) {
+		tasks := []models.Task{
+			{Method: "GET", Url: "https://www.google.com", Status: models.StatusNew},
+			{Method: "POST", Url: "https://www.google.com", Status: models.StatusNew},
+		}
+		batchId := int64(1)
+
+		mockTasksRepo.EXPECT().CreateBatch(gomock.Any(), tasks, true).Return(&models.Batch{Id: &batchId, TaskIds: []int64{1, 2}}, nil).Times(1)
+		mockPool.EXPECT().Notify().Times(1)
+
+		batch, err := useCase.CreateBatch(ctx, tasks, true)
+
+		require.NoError(t, err)
+		require.Equal(t, []int64{1, 2}, batch.TaskIds)
+	})
+
+	t.Run("Errors reported per index", func(t *testing.T) {
+		tasks := []models.Task{
+			{Method: "GET", Url: "https://www.google.com", Status: models.StatusNew},
+			{Method: "GET", Url: "not a url", Status: models.StatusNew},
+			{Method: "WRONG", Url: "https://www.google.com", Status: models.StatusNew},
+		}
+
+		mockTasksRepo.EXPECT().CreateBatch(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+		_, err := useCase.CreateBatch(ctx, tasks, false)
+
+		require.Error(t, err)
+		batchErr, ok := err.(errorsHttp.BatchError)
+		require.True(t, ok)
+		require.Equal(t, http.StatusBadRequest, batchErr.Status())
+		require.Len(t, batchErr.Items, 2)
+		assert.Equal(t, 1, batchErr.Items[0].Index)
+		assert.Equal(t, "field Url: is not a valid URL", batchErr.Items[0].Error)
+		assert.Equal(t, 2, batchErr.Items[1].Index)
+	})
+
+	t.Run("Too large", func(t *testing.T) {
+		tasks := make([]models.Task, 4)
+
+		_, err := useCase.CreateBatch(ctx, tasks, false)
+
+		require.Error(t, err)
+		require.Equal(t, http.StatusBadRequest, err.(errorsHttp.RestError).ErrStatus)
+	})
+}
+
+func TestTaskUseCase_CreateWithConflictingIdempotencyKey(t *testing.T) {
+	t.Parallel()
+	ctrx := gomock.NewController(t)
+	defer ctrx.Finish()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+	cfg := &config.Config{MaxRequestBodySize: maxRequestBodySize}
+
+	mockTasksRepo := mock.NewMockRepository(ctrx)
+	mockPool := mock.NewMockPool(ctrx)
+
+	useCase := NewTaskUseCase(cfg, sugar, mockTasksRepo, mockPool, mock.NewMockExecutor(ctrx), events.NewBus(sugar, 0), nil)
+
+	key := "key"
+	task := &models.Task{
+		Method:         "GET",
+		Url:            "https://www.google.com",
+		Status:         models.StatusNew,
+		IdempotencyKey: &key,
+	}
+
+	mockTasksRepo.EXPECT().Create(gomock.Any(), task).Return(nil, false, models.ErrIdempotencyKeyConflict).Times(1)
+	mockPool.EXPECT().Notify().Times(0)
+
+	_, err := useCase.Create(context.Background(), task)
+
+	require.Error(t, err)
+	require.Equal(t, http.StatusConflict, err.(errorsHttp.RestError).ErrStatus)
+}
+
+func TestTaskUseCase_CreateReplayed(t *testing.T) {
+	t.Parallel()
+	ctrx := gomock.NewController(t)
+	defer ctrx.Finish()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+	cfg := &config.Config{MaxRequestBodySize: maxRequestBodySize}
+
+	mockTasksRepo := mock.NewMockRepository(ctrx)
+	mockPool := mock.NewMockPool(ctrx)
+	bus := events.NewBus(sugar, 1)
+
+	useCase := NewTaskUseCase(cfg, sugar, mockTasksRepo, mockPool, mock.NewMockExecutor(ctrx), bus, nil)
+
+	received, unsubscribe := bus.Subscribe(models.TaskEventFilter{})
+	defer unsubscribe()
+
+	key := "key"
+	task := &models.Task{
+		Method:         "GET",
+		Url:            "https://www.google.com",
+		Status:         models.StatusNew,
+		IdempotencyKey: &key,
+	}
+	existing := &models.Task{Id: 5, Method: "GET", Url: "https://www.google.com", Status: models.StatusDone, IdempotencyKey: &key}
+
+	mockTasksRepo.EXPECT().Create(gomock.Any(), task).Return(existing, true, nil).Times(1)
+	mockPool.EXPECT().Notify().Times(0)
+
+	created, err := useCase.Create(context.Background(), task)
+
+	require.NoError(t, err)
+	require.Equal(t, int64(5), created.Id)
+	require.Empty(t, received)
+}
+
+func TestTaskUseCase_Subscribe(t *testing.T) {
+	t.Parallel()
+	ctrx := gomock.NewController(t)
+	defer ctrx.Finish()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+	cfg := &config.Config{MaxRequestBodySize: maxRequestBodySize}
+
+	mockTasksRepo := mock.NewMockRepository(ctrx)
+	mockPool := mock.NewMockPool(ctrx)
+
+	useCase := NewTaskUseCase(cfg, sugar, mockTasksRepo, mockPool, mock.NewMockExecutor(ctrx), events.NewBus(sugar, 0), nil)
+
+	t.Run("Receives created task", func(t *testing.T) {
+		received, unsubscribe, err := useCase.Subscribe(context.Background(), models.TaskEventFilter{Statuses: []string{models.StatusNew}})
+		require.NoError(t, err)
+		defer unsubscribe()
+
+		mockTasksRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, task *models.Task) (*models.Task, bool, error) {
+			task.Id = 10
+			return task, false, nil
+		})
+		mockPool.EXPECT().Notify()
+
+		_, err = useCase.Create(context.Background(), &models.Task{Method: "GET", Url: "https://www.google.com", Status: models.StatusNew})
+		require.NoError(t, err)
+
+		event := <-received
+		require.Equal(t, int64(10), event.TaskId)
+		require.Equal(t, models.StatusNew, event.Status)
+	})
+
+	t.Run("Receives only own tasks", func(t *testing.T) {
+		ctx := auth.WithClient(context.Background(), "acme")
+		received, unsubscribe, err := useCase.Subscribe(ctx, models.TaskEventFilter{})
+		require.NoError(t, err)
+		defer unsubscribe()
+
+		mockTasksRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, task *models.Task) (*models.Task, bool, error) {
+			task.Id = int64(len(*task.Client))
+			return task, false, nil
+		}).Times(2)
+		mockPool.EXPECT().Notify().Times(2)
+
+		_, err = useCase.Create(auth.WithClient(context.Background(), "other"), &models.Task{Method: "GET", Url: "https://www.google.com", Status: models.StatusNew})
+		require.NoError(t, err)
+		_, err = useCase.Create(auth.WithClient(context.Background(), "acme"), &models.Task{Method: "GET", Url: "https://www.google.com", Status: models.StatusNew})
+		require.NoError(t, err)
+
+		event := <-received
+		require.Equal(t, "acme", *event.Client)
+		require.Empty(t, received)
+	})
+
+	t.Run("Invalid status", func(t *testing.T) {
+		_, _, err := useCase.Subscribe(context.Background(), models.TaskEventFilter{Statuses: []string{"unknown"}})
+
+		require.Error(t, err)
+		require.Equal(t, http.StatusBadRequest, err.(errorsHttp.RestError).ErrStatus)
+	})
+}
@@ -0,0 +1,108 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"http-task-executor/internal/config"
+	"http-task-executor/internal/logger"
+	"http-task-executor/internal/tasks"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Pool runs a fixed number of workers that claim new tasks from the database
+// and hand them to the executor.
+type Pool struct {
+	log          logger.Logger
+	repo         tasks.Repository
+	exec         tasks.Executor
+	workers      int
+	pollInterval time.Duration
+	wakeup       chan struct{}
+	busy         atomic.Int64
+	wg           sync.WaitGroup
+}
+
+func NewPool(log logger.Logger, repo tasks.Repository, exec tasks.Executor, cfg config.WorkerPoolConfig) *Pool {
+	workers := cfg.Workers
+	if workers <= 0 {
+		workers = 1
+	}
+	pollInterval := cfg.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = time.Second
+	}
+	return &Pool{
+		log:          log,
+		repo:         repo,
+		exec:         exec,
+		workers:      workers,
+		pollInterval: pollInterval,
+		wakeup:       make(chan struct{}, workers),
+	}
+}
+
+func (p *Pool) Start(ctx context.Context) {
+	p.log.Infof("Starting worker pool with %d workers", p.workers)
+	for i := 0; i < p.workers; i++ {
+		p.wg.Add(1)
+		go p.work(ctx)
+	}
+}
+
+func (p *Pool) Wait() {
+	p.wg.Wait()
+}
+
+func (p *Pool) Workers() int {
+	return p.workers
+}
+
+// Busy returns how many workers are executing a task right now.
+func (p *Pool) Busy() int {
+	return int(p.busy.Load())
+}
+
+// Notify wakes up an idle worker without waiting for the next poll.
+func (p *Pool) Notify() {
+	select {
+	case p.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+func (p *Pool) work(ctx context.Context) {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(p.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+
+		task, err := p.repo.ClaimNew(ctx)
+		switch {
+		case err == nil:
+			p.busy.Add(1)
+			p.exec.ExecuteTask(*task)
+			p.busy.Add(-1)
+			continue
+		case errors.Is(err, sql.ErrNoRows):
+		case ctx.Err() != nil:
+			return
+		default:
+			p.log.Errorf("Pool.work.ClaimNew : %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-p.wakeup:
+		case <-ticker.C:
+		}
+	}
+}
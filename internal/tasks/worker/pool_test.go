@@ -0,0 +1,97 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"http-task-executor/internal/config"
+	"http-task-executor/internal/models"
+	"http-task-executor/internal/tasks/mock"
+	"testing"
+	"time"
+)
+
+func TestPool_ExecutesClaimedTasks(t *testing.T) {
+	t.Parallel()
+	ctrx := gomock.NewController(t)
+	defer ctrx.Finish()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	mockTasksRepo := mock.NewMockRepository(ctrx)
+	mockExecutor := mock.NewMockExecutor(ctrx)
+
+	pool := NewPool(sugar, mockTasksRepo, mockExecutor, config.WorkerPoolConfig{Workers: 1, PollInterval: time.Hour})
+
+	task := &models.Task{Id: 1, Method: "GET", Url: "https://www.google.com", Status: models.StatusInProcess}
+
+	executed := make(chan models.Task, 1)
+	gomock.InOrder(
+		mockTasksRepo.EXPECT().ClaimNew(gomock.Any()).Return(task, nil),
+		mockExecutor.EXPECT().ExecuteTask(gomock.Any()).Do(func(task models.Task) {
+			executed <- task
+		}),
+		mockTasksRepo.EXPECT().ClaimNew(gomock.Any()).Return(nil, sql.ErrNoRows).AnyTimes(),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	pool.Start(ctx)
+
+	select {
+	case got := <-executed:
+		require.Equal(t, task.Id, got.Id)
+	case <-time.After(time.Second):
+		t.Fatal("Expected claimed task to be executed")
+	}
+
+	cancel()
+	pool.Wait()
+}
+
+func TestPool_NotifyWakesIdleWorker(t *testing.T) {
+	t.Parallel()
+	ctrx := gomock.NewController(t)
+	defer ctrx.Finish()
+
+	sugar := zap.New(zapcore.NewNopCore()).Sugar()
+
+	mockTasksRepo := mock.NewMockRepository(ctrx)
+	mockExecutor := mock.NewMockExecutor(ctrx)
+
+	pool := NewPool(sugar, mockTasksRepo, mockExecutor, config.WorkerPoolConfig{Workers: 1, PollInterval: time.Hour})
+
+	task := &models.Task{Id: 2, Method: "GET", Url: "https://www.google.com", Status: models.StatusInProcess}
+
+	claimed := make(chan struct{}, 1)
+	executed := make(chan models.Task, 1)
+	gomock.InOrder(
+		mockTasksRepo.EXPECT().ClaimNew(gomock.Any()).DoAndReturn(func(ctx context.Context) (*models.Task, error) {
+			claimed <- struct{}{}
+			return nil, sql.ErrNoRows
+		}),
+		mockTasksRepo.EXPECT().ClaimNew(gomock.Any()).Return(task, nil),
+		mockExecutor.EXPECT().ExecuteTask(gomock.Any()).Do(func(task models.Task) {
+			executed <- task
+		}),
+		mockTasksRepo.EXPECT().ClaimNew(gomock.Any()).Return(nil, sql.ErrNoRows).AnyTimes(),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	pool.Start(ctx)
+
+	<-claimed
+	pool.Notify()
+
+	select {
+	case got := <-executed:
+		require.Equal(t, task.Id, got.Id)
+	case <-time.After(time.Second):
+		t.Fatal("Expected Notify to wake up the worker")
+	}
+
+	cancel()
+	pool.Wait()
+}
@@ -0,0 +1,35 @@
+package tracing
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"testing"
+)
+
+func TestWithParent(t *testing.T) {
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.AlwaysSample())))
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+
+	for _, sampled := range []bool{true, false} {
+		request := trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID: trace.TraceID{1},
+			SpanID:  trace.SpanID{1},
+		})
+		if sampled {
+			request = request.WithTraceFlags(trace.FlagsSampled)
+		}
+		traceId, spanId, isSampled := Ids(trace.ContextWithSpanContext(context.Background(), request))
+		require.Equal(t, sampled, isSampled)
+
+		_, span := Start(WithParent(context.Background(), traceId, spanId, isSampled), "execution")
+		span.End()
+
+		require.Equal(t, request.TraceID(), span.SpanContext().TraceID())
+		require.Equal(t, sampled, span.SpanContext().IsSampled())
+	}
+}
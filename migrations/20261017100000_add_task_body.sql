@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE task
+    ADD COLUMN body          TEXT        NOT NULL DEFAULT '',
+    ADD COLUMN body_encoding VARCHAR(10) NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE task
+    DROP COLUMN body,
+    DROP COLUMN body_encoding;
+-- +goose StatementEnd
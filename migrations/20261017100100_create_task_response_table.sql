@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS task_response
+(
+    task_id      BIGINT PRIMARY KEY REFERENCES task (id),
+    content_type TEXT    NOT NULL DEFAULT '',
+    body         BYTEA   NOT NULL,
+    truncated    BOOLEAN NOT NULL DEFAULT FALSE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS task_response;
+-- +goose StatementEnd
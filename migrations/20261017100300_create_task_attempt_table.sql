@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS task_attempt
+(
+    id                   SERIAL PRIMARY KEY,
+    task_id              BIGINT      NOT NULL REFERENCES task (id),
+    attempt              INT         NOT NULL,
+    started_at           TIMESTAMPTZ NOT NULL,
+    finished_at          TIMESTAMPTZ NOT NULL,
+    duration_ms          BIGINT      NOT NULL,
+    response_status_code SMALLINT,
+    response_length      BIGINT,
+    error                TEXT
+);
+
+CREATE INDEX IF NOT EXISTS task_attempt_task_id_idx ON task_attempt (task_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS task_attempt;
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE task
+    ADD COLUMN created_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+CREATE INDEX IF NOT EXISTS task_created_at_id_idx ON task (created_at, id);
+CREATE INDEX IF NOT EXISTS task_status_idx ON task (status);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS task_status_idx;
+DROP INDEX IF EXISTS task_created_at_id_idx;
+ALTER TABLE task
+    DROP COLUMN created_at;
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE task
+    ADD COLUMN run_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS task_scheduled_run_at_idx ON task (run_at) WHERE status = 'scheduled';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS task_scheduled_run_at_idx;
+ALTER TABLE task
+    DROP COLUMN run_at;
+-- +goose StatementEnd
@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS recurring_task
+(
+    id            SERIAL PRIMARY KEY,
+    url           TEXT        NOT NULL,
+    method        TEXT        NOT NULL,
+    headers       JSONB       NOT NULL DEFAULT '{}',
+    body          TEXT        NOT NULL DEFAULT '',
+    body_encoding VARCHAR(10) NOT NULL DEFAULT '',
+    retry_policy  JSONB,
+    cron          TEXT,
+    interval_ms   BIGINT,
+    paused        BOOLEAN     NOT NULL DEFAULT false,
+    next_run_at   TIMESTAMPTZ NOT NULL,
+    last_run_at   TIMESTAMPTZ,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CHECK ((cron IS NULL) <> (interval_ms IS NULL))
+);
+
+CREATE INDEX IF NOT EXISTS recurring_task_next_run_at_idx ON recurring_task (next_run_at) WHERE NOT paused;
+
+ALTER TABLE task
+    ADD COLUMN recurring_task_id BIGINT REFERENCES recurring_task (id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS task_recurring_task_id_idx ON task (recurring_task_id, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS task_recurring_task_id_idx;
+ALTER TABLE task
+    DROP COLUMN recurring_task_id;
+DROP TABLE IF EXISTS recurring_task;
+-- +goose StatementEnd
@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE task
+    ADD COLUMN callback_url             TEXT,
+    ADD COLUMN callback_secret          TEXT,
+    ADD COLUMN callback_status          VARCHAR(20),
+    ADD COLUMN callback_attempts        INT NOT NULL DEFAULT 0,
+    ADD COLUMN callback_next_attempt_at TIMESTAMPTZ,
+    ADD COLUMN callback_last_error      TEXT;
+
+CREATE INDEX IF NOT EXISTS task_callback_pending_idx ON task (callback_next_attempt_at) WHERE callback_status = 'pending';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS task_callback_pending_idx;
+ALTER TABLE task
+    DROP COLUMN callback_url,
+    DROP COLUMN callback_secret,
+    DROP COLUMN callback_status,
+    DROP COLUMN callback_attempts,
+    DROP COLUMN callback_next_attempt_at,
+    DROP COLUMN callback_last_error;
+-- +goose StatementEnd
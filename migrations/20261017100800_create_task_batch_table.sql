@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS task_batch
+(
+    id         SERIAL PRIMARY KEY,
+    size       INT         NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+ALTER TABLE task
+    ADD COLUMN batch_id BIGINT REFERENCES task_batch (id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS task_batch_id_idx ON task (batch_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS task_batch_id_idx;
+ALTER TABLE task
+    DROP COLUMN batch_id;
+DROP TABLE IF EXISTS task_batch;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE task
+    ADD COLUMN idempotency_key TEXT,
+    ADD COLUMN request_hash    TEXT,
+    ADD CONSTRAINT task_idempotency_key_key UNIQUE (idempotency_key);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE task
+    DROP CONSTRAINT task_idempotency_key_key,
+    DROP COLUMN idempotency_key,
+    DROP COLUMN request_hash;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE task
+    ADD COLUMN trace_id VARCHAR(32),
+    ADD COLUMN span_id  VARCHAR(16);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE task
+    DROP COLUMN trace_id,
+    DROP COLUMN span_id;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE task
+    ADD COLUMN started_at   TIMESTAMPTZ,
+    ADD COLUMN heartbeat_at TIMESTAMPTZ;
+
+-- Tasks already running get a full lease before the reaper considers them stuck.
+UPDATE task SET started_at = now(), heartbeat_at = now() WHERE status = 'in_process';
+
+CREATE INDEX IF NOT EXISTS task_in_process_heartbeat_at_idx ON task (heartbeat_at) WHERE status = 'in_process';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS task_in_process_heartbeat_at_idx;
+ALTER TABLE task
+    DROP COLUMN started_at,
+    DROP COLUMN heartbeat_at;
+-- +goose StatementEnd
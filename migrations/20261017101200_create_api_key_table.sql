@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS api_key
+(
+    id         SERIAL PRIMARY KEY,
+    client     VARCHAR(255) NOT NULL,
+    key_hash   VARCHAR(64)  NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    revoked_at TIMESTAMPTZ
+);
+
+ALTER TABLE task
+    ADD COLUMN client VARCHAR(255);
+
+CREATE INDEX IF NOT EXISTS task_client_idx ON task (client);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS task_client_idx;
+ALTER TABLE task
+    DROP COLUMN client;
+DROP TABLE IF EXISTS api_key;
+-- +goose StatementEnd
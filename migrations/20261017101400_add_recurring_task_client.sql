@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE recurring_task
+    ADD COLUMN client VARCHAR(255);
+
+CREATE INDEX IF NOT EXISTS recurring_task_client_idx ON recurring_task (client);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS recurring_task_client_idx;
+ALTER TABLE recurring_task
+    DROP COLUMN client;
+-- +goose StatementEnd
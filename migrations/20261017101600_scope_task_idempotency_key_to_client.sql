@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE task DROP CONSTRAINT task_idempotency_key_key;
+CREATE UNIQUE INDEX IF NOT EXISTS task_client_idempotency_key_idx ON task (COALESCE(client, ''), idempotency_key) WHERE idempotency_key IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS task_client_idempotency_key_idx;
+ALTER TABLE task ADD CONSTRAINT task_idempotency_key_key UNIQUE (idempotency_key);
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE recurring_task
+    ADD COLUMN callback_url    TEXT,
+    ADD COLUMN callback_secret TEXT,
+    ADD COLUMN tls_profile     VARCHAR(255);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE recurring_task
+    DROP COLUMN callback_url,
+    DROP COLUMN callback_secret,
+    DROP COLUMN tls_profile;
+-- +goose StatementEnd
@@ -15,7 +15,10 @@ import (
 var (
 	ErrBadRequest          = errors.New("bad request")
 	ErrNotFound            = errors.New("not Found")
+	ErrUnauthorized        = errors.New("unauthorized")
+	ErrForbidden           = errors.New("forbidden")
 	ErrRequestTimeoutError = errors.New("request Timeout")
+	ErrPayloadTooLarge     = errors.New("payload Too Large")
 	ErrInternalServerError = errors.New("internal Server Error")
 )
 
@@ -51,6 +54,22 @@ func NewBadRequestError(causes interface{}) RestErr {
 	}
 }
 
+func NewUnauthorizedError(causes interface{}) RestErr {
+	return RestError{
+		ErrStatus: http.StatusUnauthorized,
+		ErrError:  ErrUnauthorized.Error(),
+		ErrCauses: causes,
+	}
+}
+
+func NewForbiddenError(causes interface{}) RestErr {
+	return RestError{
+		ErrStatus: http.StatusForbidden,
+		ErrError:  ErrForbidden.Error(),
+		ErrCauses: causes,
+	}
+}
+
 func NewValidationError(errs []validation.ValidationError) RestErr {
 
 	var errMsgs []string
@@ -72,6 +91,39 @@ func NewValidationError(errs []validation.ValidationError) RestErr {
 	}
 }
 
+// ItemError describes why a single item of a batch request was rejected.
+type ItemError struct {
+	Index int    `json:"index"`
+	Error string `json:"error"`
+}
+
+func NewItemError(index int, err error) ItemError {
+	var restErr RestError
+	if errors.As(err, &restErr) {
+		if cause, ok := restErr.ErrCauses.(error); ok {
+			return ItemError{Index: index, Error: cause.Error()}
+		}
+		return ItemError{Index: index, Error: restErr.ErrError}
+	}
+	return ItemError{Index: index, Error: err.Error()}
+}
+
+type BatchError struct {
+	RestError
+	Items []ItemError `json:"items"`
+}
+
+func NewBatchValidationError(items []ItemError) RestErr {
+	return BatchError{
+		RestError: RestError{
+			ErrStatus: http.StatusBadRequest,
+			ErrError:  fmt.Sprintf("%d invalid items", len(items)),
+			ErrCauses: items,
+		},
+		Items: items,
+	}
+}
+
 func ErrorResponse(err error) (int, interface{}) {
 	return ParseErrors(err).Status(), ParseErrors(err)
 }
@@ -97,6 +149,7 @@ func ParseErrors(err error) RestErr {
 	var unmarshalTypeError *json.UnmarshalTypeError
 	var jsonSyntaxType *json.SyntaxError
 	var strconvNumError *strconv.NumError
+	var maxBytesError *http.MaxBytesError
 	switch {
 	case errors.Is(err, sql.ErrNoRows):
 		return NewRestError(http.StatusNotFound, ErrNotFound.Error(), err)
@@ -108,6 +161,8 @@ func ParseErrors(err error) RestErr {
 		return NewRestError(http.StatusBadRequest, ErrBadRequest.Error(), err)
 	case errors.As(err, &strconvNumError):
 		return NewRestError(http.StatusBadRequest, ErrBadRequest.Error(), err)
+	case errors.As(err, &maxBytesError):
+		return NewRestError(http.StatusRequestEntityTooLarge, ErrPayloadTooLarge.Error(), err)
 	default:
 		if restErr, ok := err.(RestErr); ok {
 			return restErr